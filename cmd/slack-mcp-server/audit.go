@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
+)
+
+// runVerifyAudit implements the verify-audit subcommand, it checks the hash chain of an
+// audit log file and its rotated files.
+func runVerifyAudit(args []string) int {
+	fs := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify-audit [-log <file>]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	var path string
+	fs.StringVar(&path, "log", os.Getenv("SLACK_MCP_AUDIT_LOG"), "Audit log file, rotated files next to it are verified first (default: SLACK_MCP_AUDIT_LOG)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if path == "" {
+		fs.Usage()
+		return 2
+	}
+
+	files, err := audit.Files(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list audit log files: %v\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "No audit log at %s\n", path)
+		return 1
+	}
+
+	var v audit.Verifier
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", file, err)
+			return 1
+		}
+		err = v.Verify(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			return 1
+		}
+	}
+
+	fmt.Printf("%d records in %d files, chain intact\n", v.Records, len(files))
+	if v.Anchor != "" {
+		fmt.Printf("The first record follows %s, which was rotated away\n", v.Anchor)
+	}
+	return 0
+}
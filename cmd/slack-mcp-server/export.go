@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/handler"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// runExport implements the export subcommand, it writes a channel or thread to disk with
+// the same code path as the conversations_export tool and prints the written path.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export -channel <id|#name|@user> [flags]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	var workspace string
+	fs.StringVar(&workspace, "workspace", "", "Workspace of SLACK_MCP_WORKSPACES to export from (default: the first one)")
+	opts := handler.ExportOptions{IncludeThreads: true}
+	fs.StringVar(&opts.Channel, "channel", "", "Channel ID or name, e.g. C1234567890, #general or @username_dm (required)")
+	fs.StringVar(&opts.ThreadTs, "thread", "", "Timestamp of a thread's parent message to export only that thread")
+	fs.StringVar(&opts.Since, "since", "", "First day to export, inclusive, e.g. 2025-01-01")
+	fs.StringVar(&opts.Until, "until", "", "Last day to export, inclusive, e.g. 2025-01-31")
+	fs.StringVar(&opts.Format, "format", handler.ExportFormatMarkdown, "Output format: jsonl, markdown or html")
+	fs.BoolVar(&opts.IncludeThreads, "threads", true, "Export thread replies under their parent message")
+	fs.IntVar(&opts.MaxMessages, "max-messages", 10000, "Maximum number of messages, including replies, to export")
+	fs.StringVar(&opts.Output, "out", "", "Output file or directory (default: SLACK_MCP_EXPORT_DIR)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.Channel == "" {
+		fs.Usage()
+		return 2
+	}
+
+	logger, err := newLogger("stdio")
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	p, err := provider.New("stdio", logger).Workspace(workspace)
+	if err != nil {
+		logger.Error("Invalid -workspace", zap.String("context", "console"), zap.Error(err))
+		return 2
+	}
+	if err := p.RefreshUsers(ctx); err != nil {
+		logger.Error("Failed to load users", zap.String("context", "console"), zap.Error(err))
+		return 1
+	}
+	if err := p.RefreshChannels(ctx); err != nil {
+		logger.Error("Failed to load channels", zap.String("context", "console"), zap.Error(err))
+		return 1
+	}
+
+	res, err := handler.NewConversationsHandler(p, logger).Export(ctx, opts)
+	if err != nil {
+		logger.Error("Export failed", zap.String("context", "console"), zap.Error(err))
+		return 1
+	}
+	if res.Truncated {
+		logger.Warn("Export stopped at -max-messages, narrow the date range or raise the limit to export the rest",
+			zap.String("context", "console"),
+			zap.Int("max_messages", opts.MaxMessages),
+		)
+	}
+	fmt.Println(res.Path)
+	return 0
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/korotovsky/slack-mcp-server/pkg/server"
 	"github.com/mattn/go-isatty"
@@ -20,7 +21,17 @@ import (
 var defaultSseHost = "127.0.0.1"
 var defaultSsePort = 13080
 
+// How long stdio waits for the caches before serving anyway, tools answer "not ready" until then
+var startupReadyTimeout = 10 * time.Second
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		os.Exit(runVerifyAudit(os.Args[2:]))
+	}
+
 	var transport string
 	var enabledToolsFlag string
 	flag.StringVar(&transport, "t", "stdio", "Transport type (stdio, sse or http)")
@@ -49,14 +60,29 @@ func main() {
 	}
 	defer logger.Sync()
 
-	addMessageToolEnv := os.Getenv("SLACK_MCP_ADD_MESSAGE_TOOL")
-	err = validateToolConfig(addMessageToolEnv)
+	toolPolicy, err := policy.Current()
 	if err != nil {
-		logger.Fatal("error in SLACK_MCP_ADD_MESSAGE_TOOL",
+		logger.Fatal("Invalid tool policy",
 			zap.String("context", "console"),
 			zap.Error(err),
 		)
 	}
+	if toolPolicy.FromFile() {
+		if err := server.ValidateEnabledTools(toolPolicy.Tools()); err != nil {
+			logger.Fatal("error in SLACK_MCP_POLICY_FILE",
+				zap.String("context", "console"),
+				zap.Error(err),
+			)
+		}
+		for _, env := range []string{"SLACK_MCP_ADD_MESSAGE_TOOL", "SLACK_MCP_REACTION_TOOL", "SLACK_MCP_ATTACHMENT_TOOL", "SLACK_MCP_ADD_MESSAGE_UNFURLING", "SLACK_MCP_READ_CHANNELS",
+			"SLACK_MCP_MESSAGE_MAX_LENGTH", "SLACK_MCP_MESSAGE_BROADCASTS", "SLACK_MCP_MESSAGE_LINKS", "SLACK_MCP_MESSAGE_ALLOW_SECRETS"} {
+			if os.Getenv(env) != "" {
+				logger.Warn(env+" is ignored, SLACK_MCP_POLICY_FILE takes precedence",
+					zap.String("context", "console"),
+				)
+			}
+		}
+	}
 
 	err = server.ValidateEnabledTools(enabledTools)
 	if err != nil {
@@ -69,17 +95,37 @@ func main() {
 	p := provider.New(transport, logger)
 	s := server.NewMCPServer(p, logger, enabledTools)
 
-	go func() {
-		var once sync.Once
+	for _, ws := range p.Workspaces() {
+		wsLogger := logger
+		if ws.Name() != "" {
+			wsLogger = logger.With(zap.String("workspace", ws.Name()))
+		}
 
-		newUsersWatcher(p, &once, logger)()
-		newChannelsWatcher(p, &once, logger)()
-	}()
+		go func() {
+			var once sync.Once
+
+			newUsersWatcher(ws, &once, wsLogger)()
+			newChannelsWatcher(ws, &once, wsLogger)()
+			newUsergroupsEmojiWatcher(ws, wsLogger)()
+
+			// Retries what failed above and keeps the caches fresh from now on
+			ws.StartRefresher(context.Background())
+
+			newArchiveWatcher(ws, wsLogger)()
+		}()
+	}
 
 	switch transport {
 	case "stdio":
+		deadline := time.Now().Add(startupReadyTimeout)
 		for {
-			if ready, _ := p.IsReady(); ready {
+			if allReady(p) {
+				break
+			}
+			if time.Now().After(deadline) {
+				logger.Warn("Caches are not ready yet, serving in degraded mode while they load",
+					zap.String("context", "console"),
+				)
 				break
 			}
 			time.Sleep(100 * time.Millisecond)
@@ -108,7 +154,7 @@ func main() {
 			zap.String("port", port),
 		)
 
-		if ready, _ := p.IsReady(); !ready {
+		if !allReady(p) {
 			logger.Info("Slack MCP Server is still warming up caches",
 				zap.String("context", "console"),
 			)
@@ -138,7 +184,7 @@ func main() {
 			zap.String("port", port),
 		)
 
-		if ready, _ := p.IsReady(); !ready {
+		if !allReady(p) {
 			logger.Info("Slack MCP Server is still warming up caches",
 				zap.String("context", "console"),
 			)
@@ -159,6 +205,16 @@ func main() {
 	}
 }
 
+// allReady reports whether the users and channels caches of every workspace are loaded.
+func allReady(p *provider.ApiProvider) bool {
+	for _, ws := range p.Workspaces() {
+		if ready, _ := ws.IsReady(); !ready {
+			return false
+		}
+	}
+	return true
+}
+
 func newUsersWatcher(p *provider.ApiProvider, once *sync.Once, logger *zap.Logger) func() {
 	return func() {
 		logger.Info("Caching users collection...",
@@ -174,7 +230,7 @@ func newUsersWatcher(p *provider.ApiProvider, once *sync.Once, logger *zap.Logge
 
 		err := p.RefreshUsers(context.Background())
 		if err != nil {
-			logger.Fatal("Error booting provider",
+			logger.Warn("Failed to cache users, retrying in the background",
 				zap.String("context", "console"),
 				zap.Error(err),
 			)
@@ -206,7 +262,7 @@ func newChannelsWatcher(p *provider.ApiProvider, once *sync.Once, logger *zap.Lo
 
 		err := p.RefreshChannels(context.Background())
 		if err != nil {
-			logger.Fatal("Error booting provider",
+			logger.Warn("Failed to cache channels, retrying in the background",
 				zap.String("context", "console"),
 				zap.Error(err),
 			)
@@ -223,32 +279,55 @@ func newChannelsWatcher(p *provider.ApiProvider, once *sync.Once, logger *zap.Lo
 	}
 }
 
-func validateToolConfig(config string) error {
-	if config == "" || config == "true" || config == "1" {
-		return nil
-	}
-
-	items := strings.Split(config, ",")
-	hasNegated := false
-	hasPositive := false
+// newUsergroupsEmojiWatcher caches user groups and emoji. Failures are not fatal, the
+// refresher retries them.
+func newUsergroupsEmojiWatcher(p *provider.ApiProvider, logger *zap.Logger) func() {
+	return func() {
+		if os.Getenv("SLACK_MCP_XOXP_TOKEN") == "demo" || (os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo") {
+			return
+		}
 
-	for _, item := range items {
-		item = strings.TrimSpace(item)
-		if item == "" {
-			continue
+		if err := p.RefreshUsergroups(context.Background()); err != nil {
+			logger.Warn("Failed to cache user groups",
+				zap.String("context", "console"),
+				zap.Error(err),
+			)
 		}
-		if strings.HasPrefix(item, "!") {
-			hasNegated = true
-		} else {
-			hasPositive = true
+
+		if err := p.RefreshEmoji(context.Background()); err != nil {
+			logger.Warn("Failed to cache emoji",
+				zap.String("context", "console"),
+				zap.Error(err),
+			)
 		}
 	}
+}
 
-	if hasNegated && hasPositive {
-		return fmt.Errorf("cannot mix allowed and disallowed (! prefixed) channels")
-	}
+// newArchiveWatcher keeps the local message archive in sync, it never returns when archiving is enabled.
+// It runs after the channels watcher so channels configured by #name can be resolved.
+func newArchiveWatcher(p *provider.ApiProvider, logger *zap.Logger) func() {
+	return func() {
+		archive := p.Archive()
+		// An offline data source never changes, there is nothing to sync
+		if archive == nil || p.IsOffline() {
+			return
+		}
+
+		logger.Info("Syncing message archive...",
+			zap.String("context", "console"),
+			zap.Duration("interval", archive.Interval()),
+		)
 
-	return nil
+		for {
+			if err := archive.Sync(context.Background()); err != nil {
+				logger.Warn("Message archive sync failed",
+					zap.String("context", "console"),
+					zap.Error(err),
+				)
+			}
+			time.Sleep(archive.Interval())
+		}
+	}
 }
 
 func newLogger(transport string) (*zap.Logger, error) {
@@ -0,0 +1,283 @@
+// Package audit keeps an append-only record of tool calls: who called which tool, in which
+// channel, with what content, whether the policy allowed it and how it ended. Every record
+// carries the hash of the record before it, so removing or editing a record breaks the
+// chain, see Verifier.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy decisions and outcomes of a Record.
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// Record is one line of the audit log.
+type Record struct {
+	// RFC 3339 time in UTC
+	Time string `json:"time"`
+	// "slack:<team ID>:<user ID>" of a pass-through Slack token, otherwise the name of the
+	// API key of the call or "oauth:" and the subject of an OAuth access token, empty
+	// without any, e.g. on stdio
+	Principal string `json:"principal,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Tool      string `json:"tool"`
+	// Channel ID the call resolved, empty for tools without a channel
+	Channel string `json:"channel,omitempty"`
+	// Timestamp of the message the call posted or reacted to
+	MessageTs string `json:"message_ts,omitempty"`
+	// SHA-256 of the content the call sent, e.g. the message text, or of its arguments
+	ContentHash string `json:"content_hash,omitempty"`
+	Decision    string `json:"decision"`
+	Outcome     string `json:"outcome"`
+	Error       string `json:"error,omitempty"`
+	// DryRun is set for calls that only returned what they would have sent to Slack
+	DryRun bool `json:"dry_run,omitempty"`
+	// Hash of the previous record, empty for the first record of a chain
+	Prev string `json:"prev"`
+	// SHA-256 of this record with an empty hash
+	Hash string `json:"hash,omitempty"`
+}
+
+// sink stores the lines of the audit log.
+type sink interface {
+	// write appends one line, without its newline
+	write(line []byte) error
+	// last returns the hash of the last record of the sink, empty if it cannot tell
+	last() (string, error)
+	close() error
+}
+
+// locker is implemented by sinks other processes append to as well. While locked, last
+// returns the hash of the last record any of them wrote.
+type locker interface {
+	lock() (unlock func(), err error)
+}
+
+// Log writes records to a sink, chaining each to the one before it.
+type Log struct {
+	sink  sink
+	reads bool
+
+	mu   sync.Mutex
+	prev string
+}
+
+func newLog(s sink, reads bool) (*Log, error) {
+	prev, err := s.last()
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	return &Log{sink: s, reads: reads, prev: prev}, nil
+}
+
+// FromEnv opens the audit log of SLACK_MCP_AUDIT_LOG, nil if it is not set:
+//   - a file path writes JSON lines, rotated after SLACK_MCP_AUDIT_LOG_MAX_SIZE megabytes
+//     (default 100) keeping SLACK_MCP_AUDIT_LOG_MAX_FILES rotated files (default 10, 0
+//     keeps all)
+//   - "syslog" writes to the local syslog daemon, "syslog://host:514" or
+//     "syslog+tcp://host:514" to a remote one
+//
+// SLACK_MCP_AUDIT_READS set to true also records calls of read-only tools.
+func FromEnv() (*Log, error) {
+	target := os.Getenv("SLACK_MCP_AUDIT_LOG")
+	if target == "" {
+		return nil, nil
+	}
+	reads := false
+	if v := os.Getenv("SLACK_MCP_AUDIT_READS"); v != "" {
+		var err error
+		if reads, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid SLACK_MCP_AUDIT_READS %q, expected true or false", v)
+		}
+	}
+
+	var (
+		s   sink
+		err error
+	)
+	switch {
+	case target == "syslog":
+		s, err = openSyslog("", "")
+	case strings.HasPrefix(target, "syslog://"):
+		s, err = openSyslog("udp", strings.TrimPrefix(target, "syslog://"))
+	case strings.HasPrefix(target, "syslog+tcp://"):
+		s, err = openSyslog("tcp", strings.TrimPrefix(target, "syslog+tcp://"))
+	default:
+		s, err = openFileFromEnv(target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newLog(s, reads)
+}
+
+func openFileFromEnv(path string) (sink, error) {
+	maxSize, err := envInt("SLACK_MCP_AUDIT_LOG_MAX_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles, err := envInt("SLACK_MCP_AUDIT_LOG_MAX_FILES", 10)
+	if err != nil {
+		return nil, err
+	}
+	return openFile(path, int64(maxSize)<<20, maxFiles)
+}
+
+func envInt(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a number", name, v)
+	}
+	return n, nil
+}
+
+// Reads reports whether calls of read-only tools are recorded.
+func (l *Log) Reads() bool {
+	return l.reads
+}
+
+// Write appends r to the log, setting its time if unset and its place in the chain.
+func (l *Log) Write(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lk, ok := l.sink.(locker); ok {
+		unlock, err := lk.lock()
+		if err != nil {
+			return fmt.Errorf("failed to write audit record: %w", err)
+		}
+		defer unlock()
+		if l.prev, err = l.sink.last(); err != nil {
+			return fmt.Errorf("failed to write audit record: %w", err)
+		}
+	}
+
+	if r.Time == "" {
+		r.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	r.Prev = l.prev
+	r.Hash = ""
+	line, err := seal(&r)
+	if err != nil {
+		return err
+	}
+	if err := l.sink.write(line); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	l.prev = r.Hash
+	return nil
+}
+
+// Close closes the sink of the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sink.close()
+}
+
+// seal sets the hash of r and returns its line.
+func seal(r *Record) ([]byte, error) {
+	unsealed, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(unsealed)
+	r.Hash = hex.EncodeToString(sum[:])
+	return json.Marshal(r)
+}
+
+// HashContent returns the content hash of a record for content.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// entry collects what handlers learn about a call, for the middleware to record.
+type entry struct {
+	mu        sync.Mutex
+	channel   string
+	messageTs string
+	content   *string
+	dryRun    bool
+	principal string
+}
+
+type entryKey struct{}
+
+func withEntry(ctx context.Context, e *entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
+
+func entryFromContext(ctx context.Context) *entry {
+	e, _ := ctx.Value(entryKey{}).(*entry)
+	return e
+}
+
+// SetChannel records the channel ID a tool call resolved. It does nothing for calls that
+// are not recorded.
+func SetChannel(ctx context.Context, channelID string) {
+	if e := entryFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.channel = channelID
+		e.mu.Unlock()
+	}
+}
+
+// SetMessage records the timestamp of the message a tool call posted or acted on.
+func SetMessage(ctx context.Context, ts string) {
+	if e := entryFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.messageTs = ts
+		e.mu.Unlock()
+	}
+}
+
+// SetPrincipal records the caller of a tool call that is only known once the call runs,
+// such as the Slack user of a pass-through token. It takes precedence over the API key
+// the request was authenticated with.
+func SetPrincipal(ctx context.Context, name string) {
+	if e := entryFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.principal = name
+		e.mu.Unlock()
+	}
+}
+
+// SetDryRun records that a tool call is a dry run, which sends nothing to Slack.
+func SetDryRun(ctx context.Context) {
+	if e := entryFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.dryRun = true
+		e.mu.Unlock()
+	}
+}
+
+// SetContent records the content a tool call sends to Slack, e.g. the message text. Without
+// it the content hash covers the arguments of the call.
+func SetContent(ctx context.Context, content string) {
+	if e := entryFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.content = &content
+		e.mu.Unlock()
+	}
+}
@@ -0,0 +1,308 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+	var records []Record
+	files, err := Files(path)
+	require.NoError(t, err)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var r Record
+			require.NoError(t, json.Unmarshal([]byte(line), &r))
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+func verifyFiles(t *testing.T, path string) (Verifier, error) {
+	t.Helper()
+	var v Verifier
+	files, err := Files(path)
+	require.NoError(t, err)
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		err = v.Verify(f)
+		f.Close()
+		if err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func TestUnitLogChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s, err := openFile(path, 0, 0)
+	require.NoError(t, err)
+	l, err := newLog(s, false)
+	require.NoError(t, err)
+	require.NoError(t, l.Write(Record{Tool: "conversations_add_message", Channel: "C1", Decision: DecisionAllowed, Outcome: OutcomeOK}))
+	require.NoError(t, l.Write(Record{Tool: "reactions_add", Channel: "C1", Decision: DecisionDenied, Outcome: OutcomeError}))
+	require.NoError(t, l.Close())
+
+	// A restarted server continues the chain
+	s, err = openFile(path, 0, 0)
+	require.NoError(t, err)
+	l, err = newLog(s, false)
+	require.NoError(t, err)
+	require.NoError(t, l.Write(Record{Tool: "reactions_remove", Decision: DecisionAllowed, Outcome: OutcomeOK}))
+	require.NoError(t, l.Close())
+
+	records := readRecords(t, path)
+	require.Len(t, records, 3)
+	assert.Empty(t, records[0].Prev)
+	assert.Equal(t, records[0].Hash, records[1].Prev)
+	assert.Equal(t, records[1].Hash, records[2].Prev)
+	assert.NotEmpty(t, records[0].Time)
+
+	// Servers sharing the file continue each other's chain
+	logs := make([]*Log, 2)
+	for i := range logs {
+		s, err := openFile(path, 0, 0)
+		require.NoError(t, err)
+		logs[i], err = newLog(s, false)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 4; i++ {
+		require.NoError(t, logs[i%2].Write(Record{Tool: "reactions_add", Decision: DecisionAllowed, Outcome: OutcomeOK}))
+	}
+	for _, l := range logs {
+		require.NoError(t, l.Close())
+	}
+
+	v, err := verifyFiles(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, 7, v.Records)
+	assert.Empty(t, v.Anchor)
+}
+
+func TestUnitVerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s, err := openFile(path, 0, 0)
+	require.NoError(t, err)
+	l, err := newLog(s, false)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Write(Record{Tool: "conversations_add_message", Channel: fmt.Sprintf("C%d", i), Decision: DecisionAllowed, Outcome: OutcomeOK}))
+	}
+	require.NoError(t, l.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+
+	var v Verifier
+	assert.ErrorContains(t, v.Verify(strings.NewReader(strings.Replace(string(data), `"C1"`, `"C9"`, 1))), "line 2: record was modified")
+	v = Verifier{}
+	assert.ErrorContains(t, v.Verify(strings.NewReader(lines[0]+lines[2])), "line 2: chain is broken")
+	v = Verifier{}
+	assert.ErrorContains(t, v.Verify(strings.NewReader("{")), "line 1: invalid record")
+
+	// A log starting after records that were removed names what it follows
+	v = Verifier{}
+	require.NoError(t, v.Verify(strings.NewReader(lines[1]+lines[2])))
+	assert.Equal(t, 2, v.Records)
+	assert.NotEmpty(t, v.Anchor)
+}
+
+func TestUnitFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s, err := openFile(path, 600, 2)
+	require.NoError(t, err)
+	l, err := newLog(s, false)
+	require.NoError(t, err)
+	for i := 0; i < 12; i++ {
+		require.NoError(t, l.Write(Record{Tool: "conversations_add_message", Channel: fmt.Sprintf("C%d", i), Decision: DecisionAllowed, Outcome: OutcomeOK}))
+	}
+	require.NoError(t, l.Close())
+
+	files, err := Files(path)
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "two rotated files and the current one")
+	for _, file := range files {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(600))
+	}
+
+	// The oldest records were rotated away, the rest still form one chain
+	v, err := verifyFiles(t, path)
+	require.NoError(t, err)
+	assert.Less(t, v.Records, 12)
+	assert.NotEmpty(t, v.Anchor)
+	records := readRecords(t, path)
+	assert.Equal(t, "C11", records[len(records)-1].Channel)
+
+	// An empty current file continues the chain of the newest rotated file
+	line, err := lastLine(files[1])
+	require.NoError(t, err)
+	var newest Record
+	require.NoError(t, json.Unmarshal(line, &newest))
+	require.NoError(t, os.Truncate(path, 0))
+	s, err = openFile(path, 600, 2)
+	require.NoError(t, err)
+	prev, err := s.last()
+	require.NoError(t, err)
+	assert.Equal(t, newest.Hash, prev)
+	require.NoError(t, s.close())
+
+	// A log ending with a broken record is not continued
+	require.NoError(t, os.WriteFile(path, []byte("{\"tool\":"), 0600))
+	s, err = openFile(path, 600, 2)
+	require.NoError(t, err)
+	_, err = newLog(s, false)
+	assert.ErrorContains(t, err, "ends with an invalid record")
+}
+
+func TestUnitMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s, err := openFile(path, 0, 0)
+	require.NoError(t, err)
+	l, err := newLog(s, false)
+	require.NoError(t, err)
+	defer l.Close()
+
+	middleware := BuildMiddleware(l, "stdio", zap.NewNop())
+	post := middleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		SetPrincipal(ctx, "slack:T1:U1")
+		SetChannel(ctx, "C1")
+		SetContent(ctx, "hello")
+		SetMessage(ctx, "1700000000.000100")
+		return mcp.NewToolResultText("ok"), nil
+	})
+	denied := middleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		SetChannel(ctx, "C2")
+		return nil, fmt.Errorf("%w: not in this channel", policy.ErrDenied)
+	})
+	failed := middleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("channel_not_found")
+	})
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "conversations_add_message"
+	req.Params.Arguments = map[string]any{"channel_id": "#general", "text": "hello", "workspace": "acme"}
+	_, err = post(context.Background(), req)
+	require.NoError(t, err)
+	_, err = denied(context.Background(), req)
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	req.Params.Name = "usergroups_create"
+	req.Params.Arguments = map[string]any{"name": "oncall"}
+	_, err = failed(context.Background(), req)
+	assert.Error(t, err)
+
+	records := readRecords(t, path)
+	require.Len(t, records, 3)
+	assert.Equal(t, "conversations_add_message", records[0].Tool)
+	assert.Equal(t, "acme", records[0].Workspace)
+	assert.Equal(t, "slack:T1:U1", records[0].Principal)
+	assert.Equal(t, "C1", records[0].Channel)
+	assert.Equal(t, "1700000000.000100", records[0].MessageTs)
+	assert.Equal(t, HashContent("hello"), records[0].ContentHash)
+	assert.Equal(t, DecisionAllowed, records[0].Decision)
+	assert.Equal(t, OutcomeOK, records[0].Outcome)
+
+	assert.Equal(t, "C2", records[1].Channel)
+	assert.Equal(t, DecisionDenied, records[1].Decision)
+	assert.Equal(t, OutcomeError, records[1].Outcome)
+	assert.Contains(t, records[1].Error, "not in this channel")
+
+	// Without content the hash covers the arguments
+	assert.Equal(t, HashContent(`{"name":"oncall"}`), records[2].ContentHash)
+	assert.Equal(t, DecisionAllowed, records[2].Decision)
+	assert.Equal(t, "channel_not_found", records[2].Error)
+
+	var v Verifier
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, v.Verify(bytes.NewReader(data)))
+}
+
+func TestUnitMiddlewareRecordsAPIKeyDenials(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(keysFile, []byte(`{"keys":[{"name":"reader","key":"reader-secret","tools":["channels_list"]}]}`), 0600))
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", keysFile)
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	require.NoError(t, auth.LoadAPIKeysFromEnv())
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s, err := openFile(path, 0, 0)
+	require.NoError(t, err)
+	l, err := newLog(s, false)
+	require.NoError(t, err)
+	defer l.Close()
+
+	handler := BuildMiddleware(l, "http", zap.NewNop())(auth.BuildMiddleware("http", zap.NewNop())(
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// The Slack user of a pass-through token behind the key
+			SetPrincipal(ctx, "slack:T1:U2")
+			return mcp.NewToolResultText("ok"), nil
+		}))
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	r.Header.Set("Authorization", "Bearer reader-secret")
+	ctx := auth.AuthFromRequest(zap.NewNop())(context.Background(), r)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "conversations_add_message"
+	_, err = handler(ctx, req)
+	assert.ErrorIs(t, err, auth.ErrToolNotAllowed)
+
+	req.Params.Name = "channels_list"
+	_, err = handler(ctx, req)
+	require.NoError(t, err)
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, "reader", records[0].Principal)
+	assert.Equal(t, DecisionDenied, records[0].Decision)
+	assert.Equal(t, "slack:T1:U2", records[1].Principal)
+}
+
+func TestUnitFromEnv(t *testing.T) {
+	t.Setenv("SLACK_MCP_AUDIT_LOG", "")
+	l, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, l)
+
+	t.Setenv("SLACK_MCP_AUDIT_LOG", filepath.Join(t.TempDir(), "audit.jsonl"))
+	t.Setenv("SLACK_MCP_AUDIT_READS", "true")
+	l, err = FromEnv()
+	require.NoError(t, err)
+	assert.True(t, l.Reads())
+	require.NoError(t, l.Close())
+
+	t.Setenv("SLACK_MCP_AUDIT_LOG_MAX_SIZE", "big")
+	_, err = FromEnv()
+	assert.ErrorContains(t, err, "invalid SLACK_MCP_AUDIT_LOG_MAX_SIZE")
+
+	t.Setenv("SLACK_MCP_AUDIT_LOG_MAX_SIZE", "")
+	t.Setenv("SLACK_MCP_AUDIT_READS", "sometimes")
+	_, err = FromEnv()
+	assert.ErrorContains(t, err, "invalid SLACK_MCP_AUDIT_READS")
+}
@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxRecordSize bounds the length of a record when reading the log back.
+const maxRecordSize = 1 << 20
+
+// rotatedSuffix is appended to the file name of rotated files, they sort oldest first.
+const rotatedSuffix = "2006-01-02T15-04-05.000000000"
+
+// fileSink writes JSON lines to a file, moving it aside once it reaches maxSize. Several
+// server processes may append to the same file, see lock.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	f    *os.File
+	size int64
+	// lockFile is locked while a record is appended, it is not matched by rotatedFiles
+	lockFile *os.File
+}
+
+func openFile(path string, maxSize int64, maxFiles int) (*fileSink, error) {
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	lf, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log lock: %w", err)
+	}
+	s := &fileSink{path: path, maxSize: maxSize, maxFiles: maxFiles, lockFile: lf}
+	if err := s.open(); err != nil {
+		lf.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	s.f, s.size = f, info.Size()
+	return nil
+}
+
+// lock takes an advisory lock shared with other server processes writing to the file,
+// released by the OS if the process dies. The file is opened again if another process
+// rotated it since it was opened.
+func (s *fileSink) lock() (unlock func(), err error) {
+	if err := lockFile(s.lockFile); err != nil {
+		return nil, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	unlock = func() { _ = unlockFile(s.lockFile) }
+
+	current, err := s.f.Stat()
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	if info, err := os.Stat(s.path); err != nil || !os.SameFile(info, current) {
+		s.f.Close()
+		if err := s.open(); err != nil {
+			unlock()
+			return nil, err
+		}
+		return unlock, nil
+	}
+	// Other processes may have appended to it
+	s.size = current.Size()
+	return unlock, nil
+}
+
+func (s *fileSink) write(line []byte) error {
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line))+1 > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(append(line, '\n'))
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// rotate moves the file aside and removes the oldest rotated files beyond maxFiles.
+func (s *fileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path, s.path+"."+time.Now().UTC().Format(rotatedSuffix)); err != nil {
+		return err
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	if s.maxFiles == 0 {
+		return nil
+	}
+	rotated, err := rotatedFiles(s.path)
+	if err != nil {
+		return err
+	}
+	for len(rotated) > s.maxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// last returns the hash of the last record of the file, or of the newest rotated file if
+// the file is empty.
+func (s *fileSink) last() (string, error) {
+	files, err := Files(s.path)
+	if err != nil {
+		return "", err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		line, err := lastLine(files[i])
+		if err != nil {
+			return "", err
+		}
+		if line == nil {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil || r.Hash == "" {
+			return "", fmt.Errorf("audit log %s ends with an invalid record, fix or move it to start a new chain", files[i])
+		}
+		return r.Hash, nil
+	}
+	return "", nil
+}
+
+func (s *fileSink) close() error {
+	s.lockFile.Close()
+	return s.f.Close()
+}
+
+// lastLine returns the last non-empty line of the file at path, nil if there is none. Only
+// the end of the file is read, growing up to maxRecordSize until it holds a whole line.
+func lastLine(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	for n := int64(4096); ; n *= 2 {
+		offset := max(info.Size()-min(n, maxRecordSize), 0)
+		data := make([]byte, info.Size()-offset)
+		if _, err := f.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		data = bytes.TrimRight(data, "\n")
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 || offset == 0 || n >= maxRecordSize {
+			if len(data) == 0 {
+				return nil, nil
+			}
+			return data[i+1:], nil
+		}
+	}
+}
+
+func rotatedFiles(path string) ([]string, error) {
+	rotated, err := filepath.Glob(globEscape(path) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+func globEscape(path string) string {
+	var b bytes.Buffer
+	for _, r := range path {
+		if r == '*' || r == '?' || r == '[' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Files returns the rotated files of the audit log at path, oldest first, followed by path
+// itself if it exists.
+func Files(path string) ([]string, error) {
+	files, err := rotatedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Verifier checks the hash chain of audit records, across files when they are verified in
+// order.
+type Verifier struct {
+	// Records verified so far
+	Records int
+	// Prev of the first record, empty if the chain starts there, otherwise the hash of a
+	// record that was rotated away
+	Anchor string
+
+	prev string
+}
+
+// Verify reads the records of r and returns an error at the first record that is malformed,
+// whose hash does not match its content, or that does not follow the record before it.
+func (v *Verifier) Verify(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("line %d: invalid record: %w", line, err)
+		}
+		hash := rec.Hash
+		rec.Hash = ""
+		if _, err := seal(&rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Hash != hash {
+			return fmt.Errorf("line %d: record was modified, its hash does not match its content", line)
+		}
+		if v.Records == 0 {
+			v.Anchor = rec.Prev
+		} else if rec.Prev != v.prev {
+			return fmt.Errorf("line %d: chain is broken, a record before it was removed or modified", line)
+		}
+		v.prev = hash
+		v.Records++
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// BuildMiddleware records the calls of tools that are not read-only, and of every tool if
+// the log records reads. It must run before the authentication middleware of transport, so
+// calls an API key may not make are recorded too, and resolves the caller itself.
+func BuildMiddleware(log *Log, transport string, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !log.Reads() && isReadOnly(ctx, req.Params.Name) {
+				return next(ctx, req)
+			}
+
+			// Failures are left to the authentication middleware, the record has no caller then
+			callerCtx, _ := auth.Authenticate(ctx, transport, zap.NewNop())
+
+			e := &entry{}
+			res, err := next(withEntry(ctx, e), req)
+
+			r := Record{
+				Principal: principal(callerCtx),
+				Workspace: req.GetString("workspace", ""),
+				Tool:      req.Params.Name,
+				Decision:  DecisionAllowed,
+				Outcome:   OutcomeOK,
+			}
+			e.mu.Lock()
+			r.Channel, r.MessageTs, r.DryRun = e.channel, e.messageTs, e.dryRun
+			if e.principal != "" {
+				r.Principal = e.principal
+			}
+			if e.content != nil {
+				r.ContentHash = HashContent(*e.content)
+			} else if args, err := json.Marshal(req.GetArguments()); err == nil {
+				r.ContentHash = HashContent(string(args))
+			}
+			e.mu.Unlock()
+
+			switch {
+			case err != nil:
+				r.Outcome, r.Error = OutcomeError, err.Error()
+				if isDenied(err) {
+					r.Decision = DecisionDenied
+				}
+			case res != nil && res.IsError:
+				r.Outcome, r.Error = OutcomeError, resultText(res)
+			}
+
+			if werr := log.Write(r); werr != nil {
+				logger.Error("Failed to write audit record",
+					zap.String("tool", req.Params.Name),
+					zap.Error(werr),
+				)
+			}
+			return res, err
+		}
+	}
+}
+
+// isReadOnly reports whether the tool is annotated as read-only. Unknown tools are not.
+func isReadOnly(ctx context.Context, name string) bool {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return false
+	}
+	tool := srv.GetTool(name)
+	if tool == nil {
+		return false
+	}
+	hint := tool.Tool.Annotations.ReadOnlyHint
+	return hint != nil && *hint
+}
+
+// principal names the caller: the API key, or the subject of the OAuth access token.
+func principal(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		return p.Name
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		return "oauth:" + claims.Subject
+	}
+	return ""
+}
+
+func isDenied(err error) bool {
+	return errors.Is(err, policy.ErrDenied) ||
+		errors.Is(err, auth.ErrToolNotAllowed) ||
+		errors.Is(err, auth.ErrInsufficientScope)
+}
+
+func resultText(res *mcp.CallToolResult) string {
+	for _, c := range res.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
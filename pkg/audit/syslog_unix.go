@@ -0,0 +1,36 @@
+//go:build unix
+
+package audit
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink writes records to syslog. It cannot read them back, so a chain starts over
+// when the server starts.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// openSyslog connects to the syslog daemon at addr over network, the local one if both are
+// empty.
+func openSyslog(network, addr string) (sink, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_AUTH, "slack-mcp-server")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) write(line []byte) error {
+	return s.w.Info(string(line))
+}
+
+func (s *syslogSink) last() (string, error) {
+	return "", nil
+}
+
+func (s *syslogSink) close() error {
+	return s.w.Close()
+}
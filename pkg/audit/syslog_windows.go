@@ -0,0 +1,9 @@
+//go:build windows
+
+package audit
+
+import "errors"
+
+func openSyslog(network, addr string) (sink, error) {
+	return nil, errors.New("syslog audit logs are not supported on Windows, set SLACK_MCP_AUDIT_LOG to a file")
+}
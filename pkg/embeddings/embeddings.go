@@ -0,0 +1,134 @@
+// Package embeddings turns text into vectors using an OpenAI-compatible embeddings API.
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const (
+	defaultModel = "text-embedding-3-small"
+	// maxBatchSize keeps requests well below the 2048 inputs accepted by the API.
+	maxBatchSize = 64
+	// maxInputChars roughly keeps a single input below the 8192 token limit of embedding models.
+	maxInputChars = 8000
+)
+
+// Embedder returns one vector per input text, in input order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model identifies the vector space, vectors of different models must not be compared.
+	Model() string
+}
+
+// OpenAI calls the /embeddings endpoint of OpenAI or of any server implementing
+// the same API (Ollama, vLLM, LM Studio, LocalAI, ...).
+type OpenAI struct {
+	client openai.Client
+	model  string
+}
+
+func NewOpenAI(baseURL, apiKey, model string, opts ...option.RequestOption) *OpenAI {
+	if model == "" {
+		model = defaultModel
+	}
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	return &OpenAI{
+		client: openai.NewClient(opts...),
+		model:  model,
+	}
+}
+
+// NewOpenAIFromEnv returns nil unless SLACK_MCP_EMBEDDINGS_URL or SLACK_MCP_EMBEDDINGS_API_KEY is set.
+func NewOpenAIFromEnv() *OpenAI {
+	baseURL := os.Getenv("SLACK_MCP_EMBEDDINGS_URL")
+	apiKey := os.Getenv("SLACK_MCP_EMBEDDINGS_API_KEY")
+	if baseURL == "" && apiKey == "" {
+		return nil
+	}
+	if baseURL != "" && apiKey == "" {
+		// Local servers usually ignore the key, but the client refuses to send requests without one.
+		apiKey = "none"
+	}
+	return NewOpenAI(baseURL, apiKey, os.Getenv("SLACK_MCP_EMBEDDINGS_MODEL"))
+}
+
+func (o *OpenAI) Model() string {
+	return o.model
+}
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchSize {
+		batch := texts[start:min(start+maxBatchSize, len(texts))]
+		res, err := o.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, res...)
+	}
+	return vectors, nil
+}
+
+func (o *OpenAI) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	inputs := make([]string, len(texts))
+	for i, t := range texts {
+		inputs[i] = prepareInput(t)
+	}
+
+	resp, err := o.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input:          openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: inputs},
+		Model:          o.model,
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings API returned %d vectors for %d inputs", len(resp.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || int(d.Index) >= len(texts) {
+			return nil, fmt.Errorf("embeddings API returned out of range index %d", d.Index)
+		}
+		vec := make([]float32, len(d.Embedding))
+		for i, v := range d.Embedding {
+			vec[i] = float32(v)
+		}
+		vectors[d.Index] = vec
+	}
+	for _, v := range vectors {
+		if len(v) == 0 {
+			return nil, errors.New("embeddings API returned an empty vector")
+		}
+	}
+	return vectors, nil
+}
+
+// prepareInput truncates overly long texts, the API also rejects empty strings.
+func prepareInput(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return " "
+	}
+	if len(text) > maxInputChars {
+		runes := []rune(text)
+		if len(runes) > maxInputChars {
+			text = string(runes[:maxInputChars])
+		}
+	}
+	return text
+}
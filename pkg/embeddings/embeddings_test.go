@@ -0,0 +1,98 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStandInServer mimics the /embeddings endpoint, returning [len(input), index] for every input
+// in reverse order to check that vectors are matched by index.
+func newStandInServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/embeddings", r.URL.Path)
+		*requests++
+
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-model", req.Model)
+
+		data := make([]map[string]any, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]any{
+				"object":    "embedding",
+				"index":     i,
+				"embedding": []float64{float64(len(req.Input[i])), float64(i)},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"model":  req.Model,
+			"data":   data,
+			"usage":  map[string]any{"prompt_tokens": 0, "total_tokens": 0},
+		})
+	}))
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	var requests int
+	srv := newStandInServer(t, &requests)
+	defer srv.Close()
+
+	e := NewOpenAI(srv.URL, "none", "test-model", option.WithMaxRetries(0))
+	assert.Equal(t, "test-model", e.Model())
+
+	texts := make([]string, maxBatchSize+1)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	vectors, err := e.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	require.Len(t, vectors, len(texts))
+	assert.Equal(t, 2, requests, "inputs should be split into batches")
+
+	assert.Equal(t, []float32{1, 0}, vectors[0])
+	assert.Equal(t, []float32{2, 1}, vectors[1])
+	// The last input is the first one of the second batch.
+	assert.Equal(t, []float32{float32(maxBatchSize + 1), 0}, vectors[maxBatchSize])
+}
+
+func TestOpenAIEmbedError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"boom"}}`, http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	e := NewOpenAI(srv.URL, "none", "test-model", option.WithMaxRetries(0))
+	_, err := e.Embed(context.Background(), []string{"hello"})
+	assert.Error(t, err)
+}
+
+func TestNewOpenAIFromEnv(t *testing.T) {
+	t.Setenv("SLACK_MCP_EMBEDDINGS_URL", "")
+	t.Setenv("SLACK_MCP_EMBEDDINGS_API_KEY", "")
+	assert.Nil(t, NewOpenAIFromEnv())
+
+	t.Setenv("SLACK_MCP_EMBEDDINGS_URL", "http://localhost:11434/v1")
+	t.Setenv("SLACK_MCP_EMBEDDINGS_MODEL", "")
+	e := NewOpenAIFromEnv()
+	require.NotNil(t, e)
+	assert.Equal(t, defaultModel, e.Model())
+}
+
+func TestPrepareInput(t *testing.T) {
+	assert.Equal(t, " ", prepareInput("  "))
+	assert.Equal(t, "hi", prepareInput(" hi\n"))
+	assert.Len(t, []rune(prepareInput(strings.Repeat("é", maxInputChars+10))), maxInputChars)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMsg(ts, text string) slack.Message {
+	return slack.Message{Msg: slack.Msg{Timestamp: ts, Text: text}}
+}
+
+func texts(msgs []slack.Message) []string {
+	res := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, m.Text)
+	}
+	return res
+}
+
+// testArchive holds messages up to its last sync at 500.
+type testArchive struct {
+	history []slack.Message
+	replies []slack.Message
+}
+
+func (a *testArchive) History(_, _, latest string, limit int) ([]slack.Message, bool, string, bool) {
+	newer := ""
+	if latest == "" {
+		newer = "500.000000"
+	}
+	if len(a.history) > limit {
+		return a.history[:limit], true, newer, true
+	}
+	return a.history, false, newer, true
+}
+
+func (a *testArchive) Replies(_, _ string) ([]slack.Message, string, bool) {
+	return a.replies, "500.000000", true
+}
+
+// recentSlack returns the messages posted after the last sync.
+type recentSlack struct {
+	provider.SlackAPI
+	history []slack.Message
+	replies []slack.Message
+	oldest  string
+}
+
+func (c *recentSlack) GetConversationHistoryContext(_ context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	c.oldest = params.Oldest
+	resp := &slack.GetConversationHistoryResponse{Messages: c.history}
+	if params.Limit > 0 && len(c.history) > params.Limit {
+		resp.Messages, resp.HasMore = c.history[:params.Limit], true
+	}
+	return resp, nil
+}
+
+func (c *recentSlack) GetConversationRepliesContext(_ context.Context, params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error) {
+	c.oldest = params.Oldest
+	return c.replies, false, "", nil
+}
+
+func TestUnitHistoryFromArchive(t *testing.T) {
+	ctx := context.Background()
+	archive := &testArchive{history: []slack.Message{testMsg("400.000000", "c"), testMsg("300.000000", "b"), testMsg("200.000000", "a")}}
+
+	t.Run("messages posted after the sync come first", func(t *testing.T) {
+		client := &recentSlack{history: []slack.Message{testMsg("600.000000", "new")}}
+		msgs, cursor, ok, err := historyFromArchive(ctx, archive, client, &conversationParams{channel: "C1", limit: 3})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "500.000000", client.oldest)
+		assert.Equal(t, []string{"new", "c", "b"}, texts(msgs))
+		assert.Equal(t, archiveCursorPrefix+"300.000000", cursor)
+	})
+
+	t.Run("page filled by new messages continues from them", func(t *testing.T) {
+		client := &recentSlack{history: []slack.Message{testMsg("700.000000", "newest"), testMsg("600.000000", "new"), testMsg("550.000000", "older new")}}
+		msgs, cursor, ok, err := historyFromArchive(ctx, archive, client, &conversationParams{channel: "C1", limit: 2})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []string{"newest", "new"}, texts(msgs))
+		assert.Equal(t, archiveCursorPrefix+"600.000000", cursor)
+	})
+
+	t.Run("archived range is not topped up", func(t *testing.T) {
+		client := &recentSlack{history: []slack.Message{testMsg("600.000000", "new")}}
+		msgs, _, ok, err := historyFromArchive(ctx, archive, client, &conversationParams{channel: "C1", limit: 10, cursor: archiveCursorPrefix + "450.000000"})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Empty(t, client.oldest)
+		assert.Equal(t, []string{"c", "b", "a"}, texts(msgs))
+	})
+}
+
+func TestUnitRepliesFromArchive(t *testing.T) {
+	ctx := context.Background()
+	archive := &testArchive{replies: []slack.Message{testMsg("200.000000", "parent"), testMsg("210.000000", "reply")}}
+	client := &recentSlack{replies: []slack.Message{testMsg("200.000000", "parent"), testMsg("600.000000", "new reply")}}
+
+	msgs, ok, err := repliesFromArchive(ctx, archive, client, &conversationParams{channel: "C1"}, "200.000000")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "500.000000", client.oldest)
+	assert.Equal(t, []string{"parent", "reply", "new reply"}, texts(msgs))
+}
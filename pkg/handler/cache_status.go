@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type CacheStatusRow struct {
+	Cache               string `csv:"Cache"`
+	Ready               bool   `csv:"Ready"`
+	Count               int    `csv:"Count"`
+	LastSuccess         string `csv:"LastSuccess"`
+	LastFailure         string `csv:"LastFailure"`
+	LastError           string `csv:"LastError"`
+	ConsecutiveFailures int    `csv:"ConsecutiveFailures"`
+	NextRefresh         string `csv:"NextRefresh"`
+}
+
+type CacheStatusHandler struct {
+	apiProvider *provider.ApiProvider
+	logger      *zap.Logger
+}
+
+func NewCacheStatusHandler(apiProvider *provider.ApiProvider, logger *zap.Logger) *CacheStatusHandler {
+	return &CacheStatusHandler{
+		apiProvider: apiProvider,
+		logger:      logger,
+	}
+}
+
+// CacheStatusHandler reports readiness, size and refresh history of the workspace caches.
+func (h *CacheStatusHandler) CacheStatusHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	h.logger.Debug("CacheStatusHandler called", zap.Any("params", request.Params))
+
+	rows := cacheStatusRows(h.apiProvider.CacheStatuses())
+	csvBytes, err := gocsv.MarshalBytes(&rows)
+	if err != nil {
+		h.logger.Error("Failed to marshal cache status to CSV", zap.Error(err))
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(csvBytes)), nil
+}
+
+func cacheStatusRows(statuses []provider.CacheStatus) []CacheStatusRow {
+	rows := make([]CacheStatusRow, 0, len(statuses))
+	for _, st := range statuses {
+		rows = append(rows, CacheStatusRow{
+			Cache:               st.Name,
+			Ready:               st.Ready,
+			Count:               st.Count,
+			LastSuccess:         formatStatusTime(st.LastSuccess),
+			LastFailure:         formatStatusTime(st.LastFailure),
+			LastError:           st.LastError,
+			ConsecutiveFailures: st.ConsecutiveFailures,
+			NextRefresh:         formatStatusTime(st.NextRefresh),
+		})
+	}
+	return rows
+}
+
+func formatStatusTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
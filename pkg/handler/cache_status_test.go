@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitCacheStatusRows(t *testing.T) {
+	success := time.Date(2025, 3, 10, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	rows := cacheStatusRows([]provider.CacheStatus{
+		{Name: provider.CacheUsers, Ready: true, Count: 42, LastSuccess: success},
+		{Name: provider.CacheEmoji, LastError: "ratelimited", ConsecutiveFailures: 3},
+	})
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, "users", rows[0].Cache)
+	assert.Equal(t, "2025-03-10T11:00:00Z", rows[0].LastSuccess)
+	assert.Empty(t, rows[0].LastFailure)
+	assert.Equal(t, 3, rows[1].ConsecutiveFailures)
+	assert.Empty(t, rows[1].NextRefresh)
+}
@@ -47,7 +47,8 @@ func (ch *ChannelsHandler) ChannelsResource(ctx context.Context, request mcp.Rea
 	ch.logger.Debug("ChannelsResource called", zap.Any("params", request.Params))
 
 	// mark3labs/mcp-go does not support middlewares for resources.
-	if authenticated, err := auth.IsAuthenticated(ctx, ch.apiProvider.ServerTransport(), ch.logger); !authenticated {
+	ctx, err := auth.Authenticate(ctx, ch.apiProvider.ServerTransport(), ch.logger)
+	if err != nil {
 		ch.logger.Error("Authentication failed for channels resource", zap.Error(err))
 		return nil, err
 	}
@@ -77,7 +78,11 @@ func (ch *ChannelsHandler) ChannelsResource(ctx context.Context, request mcp.Rea
 	channels := ch.apiProvider.ProvideChannelsMaps().Channels
 	ch.logger.Debug("Retrieved channels from provider", zap.Int("count", len(channels)))
 
+	allowed := readChannelFilter(ctx, ch.apiProvider)
 	for _, channel := range channels {
+		if allowed != nil && !allowed(channel.ID) {
+			continue
+		}
 		channelList = append(channelList, Channel{
 			ID:          channel.ID,
 			Name:        channel.Name,
@@ -162,6 +167,16 @@ func (ch *ChannelsHandler) ChannelsHandler(ctx context.Context, request mcp.Call
 	channels := filterChannelsByTypes(allChannels, channelTypes)
 	ch.logger.Debug("Channels after filtering by type", zap.Int("count", len(channels)))
 
+	if allowed := readChannelFilter(ctx, ch.apiProvider); allowed != nil {
+		readable := channels[:0]
+		for _, c := range channels {
+			if allowed(c.ID) {
+				readable = append(readable, c)
+			}
+		}
+		channels = readable
+	}
+
 	var chans []provider.Channel
 
 	chans, nextcur = paginateChannels(
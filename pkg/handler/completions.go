@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// maxCompletionValues is the maximum number of values allowed in a completion result by the MCP spec.
+const maxCompletionValues = 100
+
+const (
+	matchPrefix = iota
+	matchSubstring
+	matchFuzzy
+	matchNone
+)
+
+type completionCandidate struct {
+	value   string
+	aliases []string
+	weight  int
+}
+
+type rankedCandidate struct {
+	value  string
+	rank   int
+	weight int
+}
+
+type CompletionsHandler struct {
+	apiProvider *provider.ApiProvider
+	logger      *zap.Logger
+}
+
+func NewCompletionsHandler(apiProvider *provider.ApiProvider, logger *zap.Logger) *CompletionsHandler {
+	return &CompletionsHandler{
+		apiProvider: apiProvider,
+		logger:      logger,
+	}
+}
+
+// CompletePromptArgument implements server.PromptCompletionProvider.
+// Arguments are completed by name, so prompts share the vocabulary of the tools.
+func (h *CompletionsHandler) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, _ mcp.CompleteContext) (*mcp.Completion, error) {
+	h.logger.Debug("CompletePromptArgument called",
+		zap.String("prompt", promptName),
+		zap.String("argument", argument.Name),
+		zap.String("value", argument.Value),
+	)
+	return h.complete(ctx, argument.Name, argument.Value)
+}
+
+// CompleteResourceArgument implements server.ResourceCompletionProvider.
+func (h *CompletionsHandler) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, _ mcp.CompleteContext) (*mcp.Completion, error) {
+	h.logger.Debug("CompleteResourceArgument called",
+		zap.String("uri", uri),
+		zap.String("argument", argument.Name),
+		zap.String("value", argument.Value),
+	)
+	return h.complete(ctx, argument.Name, argument.Value)
+}
+
+// complete only offers what the caller could list: channels allowed by the read policy
+// and its API key, and users without the ones hidden by the read policy.
+func (h *CompletionsHandler) complete(ctx context.Context, name, value string) (*mcp.Completion, error) {
+	// mark3labs/mcp-go does not support middlewares for completions.
+	ctx, err := auth.Authenticate(ctx, h.apiProvider.ServerTransport(), h.logger)
+	if err != nil {
+		h.logger.Error("Authentication failed for completion", zap.Error(err))
+		return nil, err
+	}
+
+	var candidates []completionCandidate
+	switch name {
+	case "channel_id", "filter_in_channel":
+		candidates = h.channelCandidates(readChannelFilter(ctx, h.apiProvider))
+	case "filter_users_from", "filter_users_with":
+		if candidates, err = h.userCandidates(); err != nil {
+			return nil, err
+		}
+	default:
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+
+	return rankCompletions(candidates, value), nil
+}
+
+func (h *CompletionsHandler) channelCandidates(allowed func(channelID string) bool) []completionCandidate {
+	channels := h.apiProvider.ProvideChannelsMaps()
+	candidates := make([]completionCandidate, 0, len(channels.ChannelsInv))
+	for name, id := range channels.ChannelsInv {
+		c, ok := channels.Channels[id]
+		if !ok || (allowed != nil && !allowed(id)) {
+			continue
+		}
+		candidates = append(candidates, completionCandidate{
+			value:   name,
+			aliases: []string{id},
+			weight:  c.MemberCount,
+		})
+	}
+	return candidates
+}
+
+func (h *CompletionsHandler) userCandidates() ([]completionCandidate, error) {
+	var teamID string
+	hideExternal := hidesExternalUsers()
+	if hideExternal {
+		ar, err := h.apiProvider.Slack().AuthTest()
+		if err != nil {
+			h.logger.Error("Slack AuthTest failed", zap.Error(err))
+			return nil, err
+		}
+		teamID = ar.TeamID
+	}
+
+	users := h.apiProvider.ProvideUsersMap()
+	candidates := make([]completionCandidate, 0, len(users.UsersInv))
+	for name, id := range users.UsersInv {
+		u, ok := users.Users[id]
+		if !ok || u.Deleted || (hideExternal && isExternalUser(u, teamID)) {
+			continue
+		}
+		candidates = append(candidates, completionCandidate{
+			value:   "@" + name,
+			aliases: []string{id, u.Profile.DisplayName, u.RealName},
+		})
+	}
+	return candidates, nil
+}
+
+// rankCompletions orders candidates by match quality (prefix, substring, fuzzy),
+// then by weight (e.g. channel member count) and finally alphabetically.
+func rankCompletions(candidates []completionCandidate, value string) *mcp.Completion {
+	query := strings.ToLower(strings.TrimSpace(value))
+
+	var ranked []rankedCandidate
+	for _, c := range candidates {
+		best := matchRank(c.value, query)
+		for _, alias := range c.aliases {
+			if r := matchRank(alias, query); r < best {
+				best = r
+			}
+		}
+		if best == matchNone {
+			continue
+		}
+		ranked = append(ranked, rankedCandidate{value: c.value, rank: best, weight: c.weight})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		if ranked[i].weight != ranked[j].weight {
+			return ranked[i].weight > ranked[j].weight
+		}
+		return ranked[i].value < ranked[j].value
+	})
+
+	total := len(ranked)
+	if len(ranked) > maxCompletionValues {
+		ranked = ranked[:maxCompletionValues]
+	}
+
+	values := make([]string, 0, len(ranked))
+	for _, r := range ranked {
+		values = append(values, r.value)
+	}
+
+	return &mcp.Completion{
+		Values:  values,
+		Total:   total,
+		HasMore: total > len(values),
+	}
+}
+
+func matchRank(candidate, query string) int {
+	if candidate == "" {
+		return matchNone
+	}
+	if query == "" {
+		return matchPrefix
+	}
+
+	c := strings.ToLower(candidate)
+	bare := strings.TrimLeft(c, "#@")
+	q := strings.TrimLeft(query, "#@")
+	if q == "" {
+		return matchPrefix
+	}
+
+	switch {
+	case strings.HasPrefix(c, query) || strings.HasPrefix(bare, q):
+		return matchPrefix
+	case strings.Contains(bare, q):
+		return matchSubstring
+	case isSubsequence(bare, q):
+		return matchFuzzy
+	default:
+		return matchNone
+	}
+}
+
+// isSubsequence reports whether all runes of q appear in s in the same order.
+func isSubsequence(s, q string) bool {
+	qr := []rune(q)
+	i := 0
+	for _, r := range s {
+		if i < len(qr) && r == qr[i] {
+			i++
+		}
+	}
+	return i == len(qr)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitRankCompletions(t *testing.T) {
+	candidates := []completionCandidate{
+		{value: "#general", aliases: []string{"C001"}, weight: 500},
+		{value: "#gen-ai", aliases: []string{"C002"}, weight: 20},
+		{value: "#engineering", aliases: []string{"C003"}, weight: 300},
+		{value: "#random", aliases: []string{"C004"}, weight: 450},
+		{value: "#green-team", aliases: []string{"C005"}, weight: 5},
+	}
+
+	t.Run("prefix matches first, popular channels first", func(t *testing.T) {
+		res := rankCompletions(candidates, "gen")
+		assert.Equal(t, []string{"#general", "#gen-ai", "#engineering", "#green-team"}, res.Values)
+		assert.Equal(t, 4, res.Total)
+		assert.False(t, res.HasMore)
+	})
+
+	t.Run("leading sigil is ignored", func(t *testing.T) {
+		res := rankCompletions(candidates, "#ran")
+		assert.Equal(t, []string{"#random"}, res.Values)
+	})
+
+	t.Run("aliases match channel IDs", func(t *testing.T) {
+		res := rankCompletions(candidates, "C003")
+		assert.Equal(t, []string{"#engineering"}, res.Values)
+	})
+
+	t.Run("empty value returns all by popularity", func(t *testing.T) {
+		res := rankCompletions(candidates, "")
+		assert.Equal(t, []string{"#general", "#random", "#engineering", "#gen-ai", "#green-team"}, res.Values)
+	})
+
+	t.Run("no match returns empty values", func(t *testing.T) {
+		res := rankCompletions(candidates, "zzz")
+		assert.Empty(t, res.Values)
+		assert.Equal(t, 0, res.Total)
+	})
+
+	t.Run("results are capped to spec maximum", func(t *testing.T) {
+		many := make([]completionCandidate, 0, 150)
+		for i := 0; i < 150; i++ {
+			many = append(many, completionCandidate{value: "x" + string(rune('a'+i%26)), weight: i})
+		}
+		res := rankCompletions(many, "x")
+		assert.Len(t, res.Values, maxCompletionValues)
+		assert.Equal(t, 150, res.Total)
+		assert.True(t, res.HasMore)
+	})
+}
+
+func TestUnitIsSubsequence(t *testing.T) {
+	assert.True(t, isSubsequence("engineering", "egr"))
+	assert.True(t, isSubsequence("general", ""))
+	assert.False(t, isSubsequence("general", "lag"))
+}
+
+func TestUnitCompleteArgument(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[
+		{"id":"U1","name":"alice"},
+		{"id":"U2","name":"mallory","is_stranger":true}
+	]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[
+		{"id":"C1","name":"general"},
+		{"id":"C2","name":"hr"},
+		{"id":"C3","name":"gardening"}
+	]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(`{"keys":[
+		{"name":"reader","key":"reader-secret","read_channels":["!#gardening"]}
+	]}`), 0600))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_POLICY_FILE", "")
+	t.Setenv("SLACK_MCP_READ_CHANNELS", "!#hr,!type:shared")
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", filepath.Join(dir, "keys.json"))
+
+	p := provider.New("http", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+	h := NewCompletionsHandler(p, zap.NewNop())
+
+	complete := func(token, name, value string) (*mcp.Completion, error) {
+		r := httptest.NewRequest("POST", "/mcp", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		ctx := auth.AuthFromRequest(zap.NewNop())(context.Background(), r)
+		return h.CompletePromptArgument(ctx, "p", mcp.CompleteArgument{Name: name, Value: value}, mcp.CompleteContext{})
+	}
+
+	_, err := complete("", "channel_id", "")
+	assert.Error(t, err, "unauthenticated")
+
+	res, err := complete("reader-secret", "channel_id", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"#general"}, res.Values, "read policy and API key channels are left out")
+
+	res, err = complete("reader-secret", "filter_users_from", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@alice"}, res.Values, "users of other workspaces are left out")
+
+	res, err = complete("reader-secret", "usergroup_id", "")
+	require.NoError(t, err)
+	assert.Empty(t, res.Values)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// AddMessagePreview renders what conversations_add_message is about to post, for human confirmation.
+func (ch *ConversationsHandler) AddMessagePreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	params, err := ch.parseParamsToolAddMessage(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Post a message to %s", ch.describeChannel(params.channel))
+	if params.threadTs != "" {
+		fmt.Fprintf(&b, " in thread %s", params.threadTs)
+	}
+	fmt.Fprintf(&b, " (%s):\n\n%s", params.contentType, params.text)
+	return b.String(), nil
+}
+
+// ReactionPreview renders the reaction change requested by reactions_add or reactions_remove.
+func (ch *ConversationsHandler) ReactionPreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	params, err := ch.parseParamsToolReaction(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	verb := "Add"
+	if request.Params.Name == "reactions_remove" {
+		verb = "Remove"
+	}
+	return fmt.Sprintf("%s :%s: reaction on message %s in %s", verb, params.emoji, params.timestamp, ch.describeChannel(params.channel)), nil
+}
+
+func (ch *ConversationsHandler) describeChannel(channelID string) string {
+	if c, ok := ch.apiProvider.ProvideChannelsMaps().Channels[channelID]; ok && c.Name != "" {
+		return fmt.Sprintf("%s (%s)", c.Name, channelID)
+	}
+	return channelID
+}
+
+// UsergroupsCreatePreview renders the user group about to be created.
+func (h *UsergroupsHandler) UsergroupsCreatePreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Create user group %q", name)
+	if handle := request.GetString("handle", ""); handle != "" {
+		fmt.Fprintf(&b, " with handle @%s", handle)
+	}
+	if description := request.GetString("description", ""); description != "" {
+		fmt.Fprintf(&b, "\nDescription: %s", description)
+	}
+	if channels := request.GetString("channels", ""); channels != "" {
+		fmt.Fprintf(&b, "\nDefault channels: %s", channels)
+	}
+	return b.String(), nil
+}
+
+// UsergroupsUpdatePreview renders the metadata changes about to be applied to a user group.
+func (h *UsergroupsHandler) UsergroupsUpdatePreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	usergroupID := request.GetString("usergroup_id", "")
+	if usergroupID == "" {
+		return "", errors.New("usergroup_id is required")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update user group %s", h.describeUsergroup(usergroupID))
+	for _, field := range []string{"name", "handle", "description", "channels"} {
+		if v := request.GetString(field, ""); v != "" {
+			fmt.Fprintf(&b, "\n%s: %s", field, v)
+		}
+	}
+	return b.String(), nil
+}
+
+// UsergroupsUsersUpdatePreview renders the member diff of usergroups_users_update,
+// listing every member that is going to be removed from the group.
+func (h *UsergroupsHandler) UsergroupsUsersUpdatePreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	usergroupID := request.GetString("usergroup_id", "")
+	if usergroupID == "" {
+		return "", errors.New("usergroup_id is required")
+	}
+	usersStr := request.GetString("users", "")
+	if usersStr == "" {
+		return "", errors.New("users is required")
+	}
+
+	current, err := h.apiProvider.Slack().GetUserGroupMembersContext(ctx, usergroupID)
+	if err != nil {
+		h.logger.Error("GetUserGroupMembersContext failed", zap.Error(err))
+		return "", err
+	}
+
+	added, removed := diffMembers(current, parseCommaSeparatedList(usersStr))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Replace members of user group %s", h.describeUsergroup(usergroupID))
+	fmt.Fprintf(&b, "\nAdded (%d): %s", len(added), h.describeUsers(added))
+	fmt.Fprintf(&b, "\nRemoved (%d): %s", len(removed), h.describeUsers(removed))
+	return b.String(), nil
+}
+
+// UsergroupsMePreview renders the user group usergroups_me is about to join or leave.
+func (h *UsergroupsHandler) UsergroupsMePreview(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	usergroupID := request.GetString("usergroup_id", "")
+	if usergroupID == "" {
+		return "", errors.New("usergroup_id is required for join/leave actions")
+	}
+
+	switch request.GetString("action", "") {
+	case "join":
+		return fmt.Sprintf("Join user group %s", h.describeUsergroup(usergroupID)), nil
+	case "leave":
+		return fmt.Sprintf("Leave user group %s", h.describeUsergroup(usergroupID)), nil
+	default:
+		return "", errors.New("action must be 'list', 'join', or 'leave'")
+	}
+}
+
+func (h *UsergroupsHandler) describeUsergroup(usergroupID string) string {
+	if g, ok := h.apiProvider.ProvideUsergroups().Usergroups[usergroupID]; ok && g.Handle != "" {
+		return fmt.Sprintf("@%s (%s)", g.Handle, usergroupID)
+	}
+	return usergroupID
+}
+
+func (h *UsergroupsHandler) describeUsers(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	users := h.apiProvider.ProvideUsersMap().Users
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if u, ok := users[id]; ok {
+			names = append(names, fmt.Sprintf("@%s (%s)", u.Name, id))
+		} else {
+			names = append(names, id)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// diffMembers returns the members present only in next (added) and only in current (removed).
+func diffMembers(current, next []string) (added, removed []string) {
+	currentSet := make(map[string]struct{}, len(current))
+	for _, id := range current {
+		currentSet[id] = struct{}{}
+	}
+	nextSet := make(map[string]struct{}, len(next))
+	for _, id := range next {
+		nextSet[id] = struct{}{}
+		if _, ok := currentSet[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for _, id := range current {
+		if _, ok := nextSet[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
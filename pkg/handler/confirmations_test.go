@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitDiffMembers(t *testing.T) {
+	added, removed := diffMembers([]string{"U1", "U2", "U3"}, []string{"U2", "U4"})
+	assert.Equal(t, []string{"U4"}, added)
+	assert.Equal(t, []string{"U1", "U3"}, removed)
+
+	added, removed = diffMembers(nil, []string{"U1"})
+	assert.Equal(t, []string{"U1"}, added)
+	assert.Empty(t, removed)
+}
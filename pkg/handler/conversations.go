@@ -14,6 +14,9 @@ import (
 	"time"
 
 	"github.com/gocarina/gocsv"
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
+	"github.com/korotovsky/slack-mcp-server/pkg/limiter"
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
 	"github.com/korotovsky/slack-mcp-server/pkg/text"
@@ -21,6 +24,7 @@ import (
 	"github.com/slack-go/slack"
 	slackGoUtil "github.com/takara2314/slack-go-util"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -54,6 +58,7 @@ type Message struct {
 	FileCount int    `json:"fileCount,omitempty"`
 	AttachmentIDs   string `json:"attachmentIDs,omitempty"`
 	HasMedia  bool   `json:"hasMedia,omitempty"`
+	Depth     int    `json:"depth"`
 	Cursor    string `json:"cursor"`
 }
 
@@ -80,12 +85,14 @@ type conversationParams struct {
 	latest   string
 	cursor   string
 	activity bool
+	budget   *pageBudget
 }
 
 type searchParams struct {
-	query string
-	limit int
-	page  int
+	query  string
+	limit  int
+	page   int
+	budget *pageBudget
 }
 
 type addMessageParams struct {
@@ -111,14 +118,18 @@ type usersSearchParams struct {
 }
 
 type ConversationsHandler struct {
-	apiProvider *provider.ApiProvider
-	logger      *zap.Logger
+	apiProvider    *provider.ApiProvider
+	logger         *zap.Logger
+	historyLimiter *rate.Limiter
+	searchLimiter  *rate.Limiter
 }
 
 func NewConversationsHandler(apiProvider *provider.ApiProvider, logger *zap.Logger) *ConversationsHandler {
 	return &ConversationsHandler{
-		apiProvider: apiProvider,
-		logger:      logger,
+		apiProvider:    apiProvider,
+		logger:         logger,
+		historyLimiter: limiter.Tier3.Limiter(),
+		searchLimiter:  limiter.Tier2.Limiter(),
 	}
 }
 
@@ -158,7 +169,11 @@ func (ch *ConversationsHandler) UsersResource(ctx context.Context, request mcp.R
 	usersMaps := ch.apiProvider.ProvideUsersMap()
 	users := usersMaps.Users
 	usersList := make([]User, 0, len(users))
+	hideExternal := hidesExternalUsers()
 	for _, user := range users {
+		if hideExternal && isExternalUser(user, ar.TeamID) {
+			continue
+		}
 		usersList = append(usersList, User{
 			UserID:   user.ID,
 			UserName: user.Name,
@@ -198,34 +213,9 @@ func (ch *ConversationsHandler) ConversationsAddMessageHandler(ctx context.Conte
 		return nil, err
 	}
 
-	var options []slack.MsgOption
-	if params.threadTs != "" {
-		options = append(options, slack.MsgOptionTS(params.threadTs))
-	}
-
-	switch params.contentType {
-	case "text/plain":
-		options = append(options, slack.MsgOptionDisableMarkdown())
-		options = append(options, slack.MsgOptionText(params.text, false))
-	case "text/markdown":
-		blocks, err := slackGoUtil.ConvertMarkdownTextToBlocks(params.text)
-		if err != nil {
-			ch.logger.Warn("Markdown parsing error", zap.Error(err))
-			options = append(options, slack.MsgOptionDisableMarkdown())
-			options = append(options, slack.MsgOptionText(params.text, false))
-		} else {
-			options = append(options, slack.MsgOptionBlocks(blocks...))
-		}
-	default:
-		return nil, errors.New("content_type must be either 'text/plain' or 'text/markdown'")
-	}
-
-	unfurlOpt := os.Getenv("SLACK_MCP_ADD_MESSAGE_UNFURLING")
-	if text.IsUnfurlingEnabled(params.text, unfurlOpt, ch.logger) {
-		options = append(options, slack.MsgOptionEnableLinkUnfurl())
-	} else {
-		options = append(options, slack.MsgOptionDisableLinkUnfurl())
-		options = append(options, slack.MsgOptionDisableMediaUnfurl())
+	options, err := ch.addMessageOptions(params)
+	if err != nil {
+		return nil, err
 	}
 
 	ch.logger.Debug("Posting Slack message",
@@ -238,6 +228,7 @@ func (ch *ConversationsHandler) ConversationsAddMessageHandler(ctx context.Conte
 		ch.logger.Error("Slack PostMessageContext failed", zap.Error(err))
 		return nil, err
 	}
+	audit.SetMessage(ctx, respTimestamp)
 
 	toolConfig := os.Getenv("SLACK_MCP_ADD_MESSAGE_MARK")
 	if toolConfig == "1" || toolConfig == "true" || toolConfig == "yes" {
@@ -267,6 +258,45 @@ func (ch *ConversationsHandler) ConversationsAddMessageHandler(ctx context.Conte
 	return marshalMessagesToCSV(messages)
 }
 
+// addMessageOptions renders the message of params and decides whether Slack may unfurl its
+// links.
+func (ch *ConversationsHandler) addMessageOptions(params *addMessageParams) ([]slack.MsgOption, error) {
+	var options []slack.MsgOption
+	if params.threadTs != "" {
+		options = append(options, slack.MsgOptionTS(params.threadTs))
+	}
+
+	switch params.contentType {
+	case "text/plain":
+		options = append(options, slack.MsgOptionDisableMarkdown())
+		options = append(options, slack.MsgOptionText(params.text, false))
+	case "text/markdown":
+		blocks, err := slackGoUtil.ConvertMarkdownTextToBlocks(params.text)
+		if err != nil {
+			ch.logger.Warn("Markdown parsing error", zap.Error(err))
+			options = append(options, slack.MsgOptionDisableMarkdown())
+			options = append(options, slack.MsgOptionText(params.text, false))
+		} else {
+			options = append(options, slack.MsgOptionBlocks(blocks...))
+		}
+	default:
+		return nil, errors.New("content_type must be either 'text/plain' or 'text/markdown'")
+	}
+
+	p, err := policy.Current()
+	if err != nil {
+		return nil, err
+	}
+	unfurlOpt := p.Unfurl("conversations_add_message")
+	if text.IsUnfurlingEnabled(params.text, unfurlOpt, ch.logger) {
+		options = append(options, slack.MsgOptionEnableLinkUnfurl())
+	} else {
+		options = append(options, slack.MsgOptionDisableLinkUnfurl())
+		options = append(options, slack.MsgOptionDisableMediaUnfurl())
+	}
+	return options, nil
+}
+
 // ReactionsAddHandler adds an emoji reaction to a message
 func (ch *ConversationsHandler) ReactionsAddHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	ch.logger.Debug("ReactionsAddHandler called", zap.Any("params", request.Params))
@@ -424,6 +454,11 @@ func (ch *ConversationsHandler) FilesGetHandler(ctx context.Context, request mcp
 		return nil, err
 	}
 
+	if !ch.allowsFile(ctx, fileInfo) {
+		ch.logger.Warn("File may not be read", zap.String("file_id", fileInfo.ID))
+		return nil, fmt.Errorf("file %q is not shared in a channel that may be read", fileInfo.ID)
+	}
+
 	if fileInfo.Size > maxFileSizeBytes {
 		return nil, fmt.Errorf("file size %d bytes exceeds maximum allowed size of %d bytes", fileInfo.Size, maxFileSizeBytes)
 	}
@@ -465,6 +500,23 @@ func (ch *ConversationsHandler) FilesGetHandler(ctx context.Context, request mcp
 	return mcp.NewToolResultText(result), nil
 }
 
+// allowsFile reports whether the file is shared in a channel the read policy and the API
+// key of the call allow reading.
+func (ch *ConversationsHandler) allowsFile(ctx context.Context, file *slack.File) bool {
+	allowed := readChannelFilter(ctx, ch.apiProvider)
+	if allowed == nil {
+		return true
+	}
+	for _, ids := range [][]string{file.Channels, file.Groups, file.IMs} {
+		for _, id := range ids {
+			if allowed(id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isTextMimetype(mimetype string) bool {
 	if strings.HasPrefix(mimetype, "text/") {
 		return true
@@ -505,26 +557,27 @@ func (ch *ConversationsHandler) ConversationsHistoryHandler(ctx context.Context,
 		zap.Bool("include_activity", params.activity),
 	)
 
-	historyParams := slack.GetConversationHistoryParameters{
-		ChannelID: params.channel,
-		Limit:     params.limit,
-		Oldest:    params.oldest,
-		Latest:    params.latest,
-		Cursor:    params.cursor,
-		Inclusive: false,
+	expansion, err := parseThreadExpansion(request)
+	if err != nil {
+		return nil, err
 	}
-	history, err := ch.apiProvider.Slack().GetConversationHistoryContext(ctx, &historyParams)
+
+	ctx = withRequestProgress(ctx, request, ch.logger)
+	history, nextCursor, err := ch.fetchHistory(ctx, params)
 	if err != nil {
-		ch.logger.Error("GetConversationHistoryContext failed", zap.Error(err))
 		return nil, err
 	}
 
-	ch.logger.Debug("Fetched conversation history", zap.Int("message_count", len(history.Messages)))
+	ch.logger.Debug("Fetched conversation history", zap.Int("message_count", len(history)))
 
-	messages := ch.convertMessagesFromHistory(history.Messages, params.channel, params.activity)
+	messages := ch.convertMessagesFromHistory(history, params.channel, params.activity)
+	messages, err = ch.expandThreads(ctx, params.channel, history, messages, expansion, params.activity)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(messages) > 0 && history.HasMore {
-		messages[len(messages)-1].Cursor = history.ResponseMetaData.NextCursor
+	if len(messages) > 0 && nextCursor != "" {
+		messages[len(messages)-1].Cursor = nextCursor
 	}
 	return marshalMessagesToCSV(messages)
 }
@@ -544,29 +597,262 @@ func (ch *ConversationsHandler) ConversationsRepliesHandler(ctx context.Context,
 		return nil, errors.New("thread_ts must be a string")
 	}
 
-	repliesParams := slack.GetConversationRepliesParameters{
-		ChannelID: params.channel,
-		Timestamp: threadTs,
-		Limit:     params.limit,
-		Oldest:    params.oldest,
-		Latest:    params.latest,
-		Cursor:    params.cursor,
-		Inclusive: false,
-	}
-	replies, hasMore, nextCursor, err := ch.apiProvider.Slack().GetConversationRepliesContext(ctx, &repliesParams)
+	ctx = withRequestProgress(ctx, request, ch.logger)
+	replies, nextCursor, err := ch.fetchReplies(ctx, params, threadTs)
 	if err != nil {
-		ch.logger.Error("GetConversationRepliesContext failed", zap.Error(err))
 		return nil, err
 	}
 	ch.logger.Debug("Fetched conversation replies", zap.Int("count", len(replies)))
 
 	messages := ch.convertMessagesFromHistory(replies, params.channel, params.activity)
-	if len(messages) > 0 && hasMore {
+	for i := range messages {
+		if messages[i].MsgID != messages[i].ThreadTs {
+			messages[i].Depth = 1
+		}
+	}
+	if len(messages) > 0 && nextCursor != "" {
 		messages[len(messages)-1].Cursor = nextCursor
 	}
 	return marshalMessagesToCSV(messages)
 }
 
+// fetchHistory pages through conversations.history until params.limit messages are
+// collected, reporting progress after each page. It returns the cursor of the next page, if any.
+func (ch *ConversationsHandler) fetchHistory(ctx context.Context, params *conversationParams) ([]slack.Message, string, error) {
+	if archive := ch.apiProvider.Archive(); archive != nil {
+		msgs, cursor, ok, err := historyFromArchive(ctx, archive, ch.apiProvider.Slack(), params)
+		if err != nil {
+			ch.logger.Error("GetConversationHistoryContext failed", zap.Error(err))
+			return nil, "", err
+		}
+		if ok {
+			ch.logger.Debug("History served from local archive", zap.String("channel", params.channel), zap.Int("count", len(msgs)))
+			return msgs, cursor, nil
+		}
+	}
+
+	var collected []slack.Message
+	cursor := params.cursor
+	if latest, ok := strings.CutPrefix(cursor, archiveCursorPrefix); ok {
+		// The archive no longer covers the next page, continue from Slack.
+		cursor = ""
+		params.latest = latest
+	}
+	for page := 0; ; page++ {
+		if page > 0 {
+			if err := ch.historyLimiter.Wait(ctx); err != nil {
+				return nil, "", err
+			}
+		}
+		historyParams := slack.GetConversationHistoryParameters{
+			ChannelID: params.channel,
+			Limit:     params.pageLimit(len(collected)),
+			Oldest:    params.oldest,
+			Latest:    params.latest,
+			Cursor:    cursor,
+			Inclusive: false,
+		}
+		history, err := ch.apiProvider.Slack().GetConversationHistoryContext(ctx, &historyParams)
+		if err != nil {
+			ch.logger.Error("GetConversationHistoryContext failed", zap.Error(err))
+			return nil, "", err
+		}
+		collected = append(collected, history.Messages...)
+
+		cursor = ""
+		if history.HasMore {
+			cursor = history.ResponseMetaData.NextCursor
+		}
+		provider.ReportProgress(ctx, float64(len(collected)), params.progressTotal(), fmt.Sprintf("Fetched %d messages", len(collected)))
+
+		if cursor == "" || params.done(history.Messages, len(collected)) {
+			return collected, cursor, nil
+		}
+	}
+}
+
+// fetchReplies pages through conversations.replies the same way fetchHistory does.
+func (ch *ConversationsHandler) fetchReplies(ctx context.Context, params *conversationParams, threadTs string) ([]slack.Message, string, error) {
+	if archive := ch.apiProvider.Archive(); archive != nil {
+		msgs, ok, err := repliesFromArchive(ctx, archive, ch.apiProvider.Slack(), params, threadTs)
+		if err != nil {
+			ch.logger.Error("GetConversationRepliesContext failed", zap.Error(err))
+			return nil, "", err
+		}
+		if ok {
+			ch.logger.Debug("Replies served from local archive", zap.String("channel", params.channel), zap.Int("count", len(msgs)))
+			return msgs, "", nil
+		}
+	}
+
+	var collected []slack.Message
+	cursor := params.cursor
+	for page := 0; ; page++ {
+		if page > 0 {
+			if err := ch.historyLimiter.Wait(ctx); err != nil {
+				return nil, "", err
+			}
+		}
+		repliesParams := slack.GetConversationRepliesParameters{
+			ChannelID: params.channel,
+			Timestamp: threadTs,
+			Limit:     params.pageLimit(len(collected)),
+			Oldest:    params.oldest,
+			Latest:    params.latest,
+			Cursor:    cursor,
+			Inclusive: false,
+		}
+		replies, hasMore, nextCursor, err := ch.apiProvider.Slack().GetConversationRepliesContext(ctx, &repliesParams)
+		if err != nil {
+			ch.logger.Error("GetConversationRepliesContext failed", zap.Error(err))
+			return nil, "", err
+		}
+		collected = append(collected, replies...)
+
+		cursor = ""
+		if hasMore {
+			cursor = nextCursor
+		}
+		provider.ReportProgress(ctx, float64(len(collected)), params.progressTotal(), fmt.Sprintf("Fetched %d replies", len(collected)))
+
+		if cursor == "" || params.done(replies, len(collected)) {
+			return collected, cursor, nil
+		}
+	}
+}
+
+// archiveCursorPrefix marks cursors of history pages served from the local archive,
+// the rest of the cursor is the ts of the last returned message.
+const archiveCursorPrefix = "archive:"
+
+// messageArchive is the part of provider.Archive history and replies are served from.
+type messageArchive interface {
+	History(channelID, oldest, latest string, limit int) (msgs []slack.Message, hasMore bool, newer string, ok bool)
+	Replies(channelID, threadTs string) (msgs []slack.Message, newer string, ok bool)
+}
+
+// historyFromArchive answers a history request from the local archive when it covers
+// the requested range, messages posted since the last sync are fetched from Slack.
+// Auto-paginated requests always go to Slack.
+func historyFromArchive(ctx context.Context, archive messageArchive, client provider.SlackAPI, params *conversationParams) ([]slack.Message, string, bool, error) {
+	if params.budget != nil {
+		return nil, "", false, nil
+	}
+
+	latest := params.latest
+	if params.cursor != "" {
+		var ok bool
+		if latest, ok = strings.CutPrefix(params.cursor, archiveCursorPrefix); !ok {
+			return nil, "", false, nil
+		}
+	}
+
+	limit := params.limit
+	if limit <= 0 {
+		limit = defaultConversationsNumericLimit
+	}
+	msgs, hasMore, newer, ok := archive.History(params.channel, params.oldest, latest, limit)
+	if !ok {
+		return nil, "", false, nil
+	}
+
+	if newer != "" {
+		recent, err := client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: params.channel,
+			Limit:     limit,
+			Oldest:    newer,
+			Latest:    latest,
+			Inclusive: false,
+		})
+		if err != nil {
+			return nil, "", false, err
+		}
+		msgs = append(recent.Messages, msgs...)
+		if recent.HasMore || len(msgs) > limit {
+			msgs, hasMore = msgs[:min(limit, len(msgs))], true
+		}
+	}
+
+	cursor := ""
+	if hasMore && len(msgs) > 0 {
+		cursor = archiveCursorPrefix + msgs[len(msgs)-1].Timestamp
+	}
+	return msgs, cursor, true, nil
+}
+
+// repliesFromArchive answers a replies request from the local archive when the thread
+// was refetched during the last sync, replies posted since are fetched from Slack.
+func repliesFromArchive(ctx context.Context, archive messageArchive, client provider.SlackAPI, params *conversationParams, threadTs string) ([]slack.Message, bool, error) {
+	if params.cursor != "" || params.oldest != "" || params.budget != nil {
+		return nil, false, nil
+	}
+	msgs, newer, ok := archive.Replies(params.channel, threadTs)
+	if !ok {
+		return nil, false, nil
+	}
+
+	recent, hasMore, _, err := client.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: params.channel,
+		Timestamp: threadTs,
+		Oldest:    newer,
+		Limit:     autoPaginatePageSize,
+		Inclusive: false,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if hasMore {
+		// Too many new replies, page through Slack instead
+		return nil, false, nil
+	}
+	for _, r := range recent {
+		if r.Timestamp != threadTs {
+			msgs = append(msgs, r)
+		}
+	}
+
+	if params.limit > 0 && len(msgs) > params.limit {
+		msgs = msgs[:params.limit]
+	}
+	return msgs, true, nil
+}
+
+// remainingLimit returns the page size for the next request, 0 keeps Slack's default.
+func remainingLimit(limit, collected int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return limit - collected
+}
+
+// pageLimit returns the page size for the next conversations.history/replies request.
+func (p *conversationParams) pageLimit(collected int) int {
+	if p.budget != nil {
+		return p.budget.pageSize(autoPaginatePageSize)
+	}
+	return remainingLimit(p.limit, collected)
+}
+
+// done accounts the last fetched page and reports whether paging should stop.
+// Without auto-pagination it stops once limit messages are collected, otherwise
+// it keeps going until the budget is exhausted or the time range is covered.
+func (p *conversationParams) done(page []slack.Message, collected int) bool {
+	if p.budget == nil {
+		return p.limit <= 0 || collected >= p.limit
+	}
+	for _, m := range page {
+		p.budget.add(m.Text)
+	}
+	return p.budget.exhausted()
+}
+
+func (p *conversationParams) progressTotal() float64 {
+	if p.budget != nil {
+		return float64(p.budget.maxMessages)
+	}
+	return float64(p.limit)
+}
+
 func (ch *ConversationsHandler) ConversationsSearchHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	ch.logger.Debug("ConversationsSearchHandler called", zap.Any("params", request.Params))
 
@@ -577,51 +863,88 @@ func (ch *ConversationsHandler) ConversationsSearchHandler(ctx context.Context,
 	}
 	ch.logger.Debug("Search params parsed", zap.String("query", params.query), zap.Int("limit", params.limit), zap.Int("page", params.page))
 
-	searchParams := slack.SearchParameters{
-		Sort:          slack.DEFAULT_SEARCH_SORT,
-		SortDirection: slack.DEFAULT_SEARCH_SORT_DIR,
-		Highlight:     false,
-		Count:         params.limit,
-		Page:          params.page,
-	}
-	messagesRes, _, err := ch.apiProvider.Slack().SearchContext(ctx, params.query, searchParams)
+	ctx = withRequestProgress(ctx, request, ch.logger)
+	matches, nextPage, err := ch.fetchSearch(ctx, params)
 	if err != nil {
-		ch.logger.Error("Slack SearchContext failed", zap.Error(err))
 		return nil, err
 	}
-	ch.logger.Debug("Search completed", zap.Int("matches", len(messagesRes.Matches)))
+	ch.logger.Debug("Search completed", zap.Int("matches", len(matches)))
 
-	messages := ch.convertMessagesFromSearch(messagesRes.Matches)
-	if len(messages) > 0 && messagesRes.Pagination.Page < messagesRes.Pagination.PageCount {
-		nextCursor := fmt.Sprintf("page:%d", messagesRes.Pagination.Page+1)
+	// Matches in channels that may not be read are dropped, the page cursor stays valid
+	if allowed := readChannelFilter(ctx, ch.apiProvider); allowed != nil {
+		readable := matches[:0]
+		for _, m := range matches {
+			if allowed(m.Channel.ID) {
+				readable = append(readable, m)
+			}
+		}
+		matches = readable
+	}
+
+	messages := ch.convertMessagesFromSearch(matches)
+	if len(messages) > 0 && nextPage > 0 {
+		nextCursor := fmt.Sprintf("page:%d", nextPage)
 		messages[len(messages)-1].Cursor = base64.StdEncoding.EncodeToString([]byte(nextCursor))
 	}
 	return marshalMessagesToCSV(messages)
 }
 
-func isChannelAllowedForConfig(channel, config string) bool {
-	if config == "" || config == "true" || config == "1" {
-		return true
-	}
-	items := strings.Split(config, ",")
-	isNegated := strings.HasPrefix(strings.TrimSpace(items[0]), "!")
-	for _, item := range items {
-		item = strings.TrimSpace(item)
-		if isNegated {
-			if strings.TrimPrefix(item, "!") == channel {
-				return false
-			}
-		} else {
-			if item == channel {
-				return true
+// fetchSearch runs search.messages starting at params.page. Without auto-pagination it
+// fetches a single page, otherwise it keeps fetching pages of params.limit matches until
+// the budget is exhausted or results run out. It returns the next page number, 0 if none.
+func (ch *ConversationsHandler) fetchSearch(ctx context.Context, params *searchParams) ([]slack.SearchMessage, int, error) {
+	var collected []slack.SearchMessage
+	page := params.page
+	for i := 0; ; i++ {
+		if i > 0 {
+			if err := ch.searchLimiter.Wait(ctx); err != nil {
+				return nil, 0, err
 			}
 		}
+		searchParams := slack.SearchParameters{
+			Sort:          slack.DEFAULT_SEARCH_SORT,
+			SortDirection: slack.DEFAULT_SEARCH_SORT_DIR,
+			Highlight:     false,
+			Count:         params.limit,
+			Page:          page,
+		}
+		messagesRes, _, err := ch.apiProvider.Slack().SearchContext(ctx, params.query, searchParams)
+		if err != nil {
+			ch.logger.Error("Slack SearchContext failed", zap.Error(err))
+			return nil, 0, err
+		}
+		collected = append(collected, messagesRes.Matches...)
+
+		nextPage := 0
+		if messagesRes.Pagination.Page < messagesRes.Pagination.PageCount {
+			nextPage = messagesRes.Pagination.Page + 1
+		}
+		provider.ReportProgress(ctx, float64(len(collected)), float64(messagesRes.Pagination.TotalCount), fmt.Sprintf("Fetched %d search results", len(collected)))
+
+		if nextPage == 0 || params.budget == nil {
+			return collected, nextPage, nil
+		}
+		for _, m := range messagesRes.Matches {
+			params.budget.add(m.Text)
+		}
+		if params.budget.exhausted() {
+			return collected, nextPage, nil
+		}
+		page = nextPage
 	}
-	return isNegated
 }
 
-func isChannelAllowed(channel string) bool {
-	return isChannelAllowedForConfig(channel, os.Getenv("SLACK_MCP_ADD_MESSAGE_TOOL"))
+// isChannelAllowedForConfig reports whether channel is allowed by a channel list in the
+// format of SLACK_MCP_ADD_MESSAGE_TOOL, see policy.ChannelList.
+func isChannelAllowedForConfig(channel, config string) bool {
+	if config == "" || config == "true" || config == "1" {
+		return true
+	}
+	list, err := policy.ParseChannelList(strings.Split(config, ","))
+	if err != nil {
+		return false
+	}
+	return list.Allows(policy.Channel{ID: channel})
 }
 
 func (ch *ConversationsHandler) resolveChannelID(ctx context.Context, channel string) (string, error) {
@@ -854,6 +1177,26 @@ func (ch *ConversationsHandler) parseParamsToolConversations(ctx context.Context
 		}
 		channel = resolvedChannel
 	}
+	audit.SetChannel(ctx, channel)
+	if err := checkReadChannel(ctx, ch.apiProvider, channel); err != nil {
+		ch.logger.Warn("Channel may not be read", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
+	if err := checkToolPolicy(ch.apiProvider, request.Params.Name, channel); err != nil {
+		ch.logger.Warn("Tool not allowed for channel", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
+
+	// In auto-pagination mode a numeric limit caps the number of messages, a time range is
+	// fetched until it is covered or the budget runs out.
+	fallbackMax := 0
+	if paramOldest == "" {
+		fallbackMax = paramLimit
+	}
+	budget, err := parsePageBudget(request, fallbackMax)
+	if err != nil {
+		return nil, err
+	}
 
 	return &conversationParams{
 		channel:  channel,
@@ -862,26 +1205,11 @@ func (ch *ConversationsHandler) parseParamsToolConversations(ctx context.Context
 		latest:   paramLatest,
 		cursor:   cursor,
 		activity: activity,
+		budget:   budget,
 	}, nil
 }
 
 func (ch *ConversationsHandler) parseParamsToolAddMessage(ctx context.Context, request mcp.CallToolRequest) (*addMessageParams, error) {
-	toolConfig := os.Getenv("SLACK_MCP_ADD_MESSAGE_TOOL")
-	enabledTools := os.Getenv("SLACK_MCP_ENABLED_TOOLS")
-
-	if toolConfig == "" {
-		if !strings.Contains(enabledTools, "conversations_add_message") {
-			ch.logger.Error("Add-message tool disabled by default")
-			return nil, errors.New(
-				"by default, the conversations_add_message tool is disabled to guard Slack workspaces against accidental spamming. " +
-					"To enable it, set the SLACK_MCP_ADD_MESSAGE_TOOL environment variable to true, 1, or comma separated list of channels " +
-					"to limit where the MCP can post messages, e.g. 'SLACK_MCP_ADD_MESSAGE_TOOL=C1234567890,D0987654321', 'SLACK_MCP_ADD_MESSAGE_TOOL=!C1234567890' " +
-					"to enable all except one or 'SLACK_MCP_ADD_MESSAGE_TOOL=true' for all channels and DMs",
-			)
-		}
-		toolConfig = "true"
-	}
-
 	channel := request.GetString("channel_id", "")
 	if channel == "" {
 		ch.logger.Error("channel_id missing in add-message params")
@@ -892,9 +1220,14 @@ func (ch *ConversationsHandler) parseParamsToolAddMessage(ctx context.Context, r
 		ch.logger.Error("Channel not found", zap.String("channel", channel), zap.Error(err))
 		return nil, err
 	}
-	if !isChannelAllowed(channel) {
-		ch.logger.Warn("Add-message tool not allowed for channel", zap.String("channel", channel), zap.String("policy", toolConfig))
-		return nil, fmt.Errorf("conversations_add_message tool is not allowed for channel %q, applied policy: %s", channel, toolConfig)
+	audit.SetChannel(ctx, channel)
+	if err := checkToolPolicy(ch.apiProvider, "conversations_add_message", channel); err != nil {
+		ch.logger.Warn("Add-message tool not allowed", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
+	if err := checkPrincipalChannel(ctx, ch.apiProvider, channel, true); err != nil {
+		ch.logger.Warn("API key may not write to channel", zap.String("channel", channel), zap.Error(err))
+		return nil, err
 	}
 
 	threadTs := request.GetString("thread_ts", "")
@@ -912,6 +1245,11 @@ func (ch *ConversationsHandler) parseParamsToolAddMessage(ctx context.Context, r
 		ch.logger.Error("Message text missing")
 		return nil, errors.New("text must be a string")
 	}
+	audit.SetContent(ctx, msgText)
+	if err := checkToolContent("conversations_add_message", "text", msgText); err != nil {
+		ch.logger.Warn("Message text not allowed", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
 
 	contentType := request.GetString("content_type", "text/markdown")
 	if contentType != "text/plain" && contentType != "text/markdown" {
@@ -928,22 +1266,6 @@ func (ch *ConversationsHandler) parseParamsToolAddMessage(ctx context.Context, r
 }
 
 func (ch *ConversationsHandler) parseParamsToolReaction(ctx context.Context, request mcp.CallToolRequest) (*addReactionParams, error) {
-	toolConfig := os.Getenv("SLACK_MCP_REACTION_TOOL")
-	enabledTools := os.Getenv("SLACK_MCP_ENABLED_TOOLS")
-
-	if toolConfig == "" {
-		if !strings.Contains(enabledTools, "reactions_add") && !strings.Contains(enabledTools, "reactions_remove") {
-			ch.logger.Error("Reactions tool disabled by default")
-			return nil, errors.New(
-				"by default, the reactions tools are disabled to guard Slack workspaces against accidental spamming. " +
-					"To enable them, set the SLACK_MCP_REACTION_TOOL environment variable to true, 1, or comma separated list of channels " +
-					"to limit where the MCP can manage reactions, e.g. 'SLACK_MCP_REACTION_TOOL=C1234567890,D0987654321', 'SLACK_MCP_REACTION_TOOL=!C1234567890' " +
-					"to enable all except one or 'SLACK_MCP_REACTION_TOOL=true' for all channels and DMs",
-			)
-		}
-		toolConfig = "true"
-	}
-
 	channel := request.GetString("channel_id", "")
 	if channel == "" {
 		return nil, errors.New("channel_id is required")
@@ -953,20 +1275,32 @@ func (ch *ConversationsHandler) parseParamsToolReaction(ctx context.Context, req
 		ch.logger.Error("Channel not found", zap.String("channel", channel), zap.Error(err))
 		return nil, err
 	}
-	if !isChannelAllowedForConfig(channel, toolConfig) {
-		ch.logger.Warn("Reactions tool not allowed for channel", zap.String("channel", channel), zap.String("policy", toolConfig))
-		return nil, fmt.Errorf("reactions tools are not allowed for channel %q, applied policy: %s", channel, toolConfig)
+	audit.SetChannel(ctx, channel)
+	// reactions_add and reactions_remove are enabled together by SLACK_MCP_REACTION_TOOL
+	tool := request.Params.Name
+	if tool != "reactions_remove" {
+		tool = "reactions_add"
+	}
+	if err := checkToolPolicy(ch.apiProvider, tool, channel); err != nil {
+		ch.logger.Warn("Reactions tool not allowed", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
+	if err := checkPrincipalChannel(ctx, ch.apiProvider, channel, true); err != nil {
+		ch.logger.Warn("API key may not write to channel", zap.String("channel", channel), zap.Error(err))
+		return nil, err
 	}
 
 	timestamp := request.GetString("timestamp", "")
 	if timestamp == "" {
 		return nil, errors.New("timestamp is required")
 	}
+	audit.SetMessage(ctx, timestamp)
 
 	emoji := strings.Trim(request.GetString("emoji", ""), ":")
 	if emoji == "" {
 		return nil, errors.New("emoji is required")
 	}
+	audit.SetContent(ctx, emoji)
 
 	return &addReactionParams{
 		channel:   channel,
@@ -976,22 +1310,13 @@ func (ch *ConversationsHandler) parseParamsToolReaction(ctx context.Context, req
 }
 
 func (ch *ConversationsHandler) parseParamsToolFilesGet(request mcp.CallToolRequest) (*filesGetParams, error) {
-	toolConfig := os.Getenv("SLACK_MCP_ATTACHMENT_TOOL")
-	enabledTools := os.Getenv("SLACK_MCP_ENABLED_TOOLS")
-
-	if toolConfig == "" {
-		if !strings.Contains(enabledTools, "attachment_get_data") {
-			ch.logger.Error("Attachment tool disabled by default")
-			return nil, errors.New(
-				"by default, the attachment_get_data tool is disabled. " +
-					"To enable it, set the SLACK_MCP_ATTACHMENT_TOOL environment variable to true or 1",
-			)
-		}
-		toolConfig = "true"
+	p, err := policy.Current()
+	if err != nil {
+		return nil, err
 	}
-	if toolConfig != "true" && toolConfig != "1" && toolConfig != "yes" {
-		ch.logger.Error("Attachment tool disabled", zap.String("config", toolConfig))
-		return nil, errors.New("SLACK_MCP_ATTACHMENT_TOOL must be set to 'true', '1', or 'yes' to enable")
+	if err := p.CheckEnabled("attachment_get_data"); err != nil {
+		ch.logger.Error("Attachment tool disabled", zap.Error(err))
+		return nil, err
 	}
 
 	fileID := request.GetString("file_id", "")
@@ -1110,10 +1435,16 @@ func (ch *ConversationsHandler) parseParamsToolSearch(req mcp.CallToolRequest) (
 		zap.Int("limit", limit),
 		zap.Int("page", page),
 	)
+	budget, err := parsePageBudget(req, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	return &searchParams{
-		query: finalQuery,
-		limit: limit,
-		page:  page,
+		query:  finalQuery,
+		limit:  limit,
+		page:   page,
+		budget: budget,
 	}, nil
 }
 
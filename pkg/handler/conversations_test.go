@@ -653,3 +653,9 @@ func TestUnitIsSlackUserIDPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitRemainingLimit(t *testing.T) {
+	assert.Equal(t, 0, remainingLimit(0, 10))
+	assert.Equal(t, 100, remainingLimit(100, 0))
+	assert.Equal(t, 15, remainingLimit(100, 85))
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// DryRun is what a write tool would have sent to Slack, returned by a dry run of the tool
+// instead of calling Slack.
+type DryRun struct {
+	DryRun bool `json:"dry_run"`
+	// Slack API method the tool would have called, e.g. chat.postMessage
+	Method string `json:"method"`
+	// Arguments of the method, with blocks rendered as JSON
+	Request map[string]any `json:"request"`
+	// Members a user group would gain and lose
+	Added   []DryRunUser `json:"added,omitempty"`
+	Removed []DryRunUser `json:"removed,omitempty"`
+}
+
+// DryRunUser is a user a dry run would add to or remove from a user group.
+type DryRunUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// AddMessageDryRun validates a conversations_add_message call and returns the
+// chat.postMessage request it would send.
+func (ch *ConversationsHandler) AddMessageDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if ready, err := ch.apiProvider.IsReady(); !ready {
+		ch.logger.Error("API provider not ready", zap.Error(err))
+		return nil, err
+	}
+
+	params, err := ch.parseParamsToolAddMessage(ctx, request)
+	if err != nil {
+		ch.logger.Error("Failed to parse add-message params", zap.Error(err))
+		return nil, err
+	}
+	options, err := ch.addMessageOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	method, values, err := slack.UnsafeApplyMsgOptions("", params.channel, "", options...)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDryRun(DryRun{Method: method, Request: dryRunRequest(values)})
+}
+
+// ReactionDryRun validates a reactions_add or reactions_remove call and returns the request
+// it would send.
+func (ch *ConversationsHandler) ReactionDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if ready, err := ch.apiProvider.IsReady(); !ready {
+		ch.logger.Error("API provider not ready", zap.Error(err))
+		return nil, err
+	}
+
+	params, err := ch.parseParamsToolReaction(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	method := "reactions.add"
+	if request.Params.Name == "reactions_remove" {
+		method = "reactions.remove"
+	}
+	return marshalDryRun(DryRun{Method: method, Request: map[string]any{
+		"channel":   params.channel,
+		"timestamp": params.timestamp,
+		"name":      params.emoji,
+	}})
+}
+
+// UsergroupsCreateDryRun validates a usergroups_create call and returns the request it would
+// send.
+func (h *UsergroupsHandler) UsergroupsCreateDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	userGroup, err := h.parseParamsToolCreate(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]any{"name": userGroup.Name}
+	setIfNotEmpty(req, "handle", userGroup.Handle)
+	setIfNotEmpty(req, "description", userGroup.Description)
+	setIfNotEmpty(req, "channels", strings.Join(userGroup.Prefs.Channels, ","))
+	return marshalDryRun(DryRun{Method: "usergroups.create", Request: req})
+}
+
+// UsergroupsUpdateDryRun validates a usergroups_update call and returns the request it would
+// send.
+func (h *UsergroupsHandler) UsergroupsUpdateDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params, err := h.parseParamsToolUpdate(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]any{"usergroup": params.usergroupID}
+	setIfNotEmpty(req, "name", params.name)
+	setIfNotEmpty(req, "handle", params.handle)
+	setIfNotEmpty(req, "description", params.description)
+	setIfNotEmpty(req, "channels", strings.Join(params.channels, ","))
+	return marshalDryRun(DryRun{Method: "usergroups.update", Request: req})
+}
+
+// UsergroupsUsersUpdateDryRun validates a usergroups_users_update call and returns the
+// request it would send, with the members the group would gain and lose.
+func (h *UsergroupsHandler) UsergroupsUsersUpdateDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if ready, err := h.apiProvider.IsReady(); !ready {
+		h.logger.Error("API provider not ready", zap.Error(err))
+		return nil, err
+	}
+
+	usergroupID, usersStr, err := h.parseParamsToolUsersUpdate(request)
+	if err != nil {
+		return nil, err
+	}
+	current, err := h.apiProvider.Slack().GetUserGroupMembersContext(ctx, usergroupID)
+	if err != nil {
+		h.logger.Error("GetUserGroupMembersContext failed", zap.Error(err))
+		return nil, err
+	}
+	return h.membersDryRun(usergroupID, current, parseCommaSeparatedList(usersStr))
+}
+
+// UsergroupsMeDryRun validates a usergroups_me call and returns the request joining or
+// leaving a group would send. Listing groups sends nothing and runs as usual.
+func (h *UsergroupsHandler) UsergroupsMeDryRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	action := request.GetString("action", "")
+	if action == "list" {
+		return h.UsergroupsMeHandler(ctx, request)
+	}
+	if action != "join" && action != "leave" {
+		return nil, errors.New("action must be 'list', 'join', or 'leave'")
+	}
+	if ready, err := h.apiProvider.IsReady(); !ready {
+		h.logger.Error("API provider not ready", zap.Error(err))
+		return nil, err
+	}
+
+	authResp, err := h.apiProvider.Slack().AuthTest()
+	if err != nil {
+		h.logger.Error("AuthTest failed", zap.Error(err))
+		return nil, err
+	}
+	change, err := h.membershipChange(ctx, request, action, authResp.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !change.changed {
+		return mcp.NewToolResultText(change.message), nil
+	}
+	return h.membersDryRun(change.usergroupID, change.current, change.members)
+}
+
+// membersDryRun returns the usergroups.users.update request that replaces the members of
+// a group with next.
+func (h *UsergroupsHandler) membersDryRun(usergroupID string, current, next []string) (*mcp.CallToolResult, error) {
+	added, removed := diffMembers(current, next)
+	return marshalDryRun(DryRun{
+		Method: "usergroups.users.update",
+		Request: map[string]any{
+			"usergroup": usergroupID,
+			"users":     strings.Join(next, ","),
+		},
+		Added:   h.dryRunUsers(added),
+		Removed: h.dryRunUsers(removed),
+	})
+}
+
+func (h *UsergroupsHandler) dryRunUsers(ids []string) []DryRunUser {
+	users := h.apiProvider.ProvideUsersMap().Users
+	result := make([]DryRunUser, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, DryRunUser{ID: id, Name: users[id].Name})
+	}
+	return result
+}
+
+// dryRunJSONValues are the form values of chat.postMessage that hold JSON.
+var dryRunJSONValues = []string{"blocks", "attachments", "metadata"}
+
+// dryRunRequest returns the form values of a Slack request without its token, with the
+// JSON values like blocks decoded.
+func dryRunRequest(values url.Values) map[string]any {
+	req := make(map[string]any, len(values))
+	for key, v := range values {
+		if key == "token" || len(v) == 0 {
+			continue
+		}
+		if slices.Contains(dryRunJSONValues, key) && json.Valid([]byte(v[0])) {
+			req[key] = json.RawMessage(v[0])
+			continue
+		}
+		req[key] = v[0]
+	}
+	return req
+}
+
+func setIfNotEmpty(m map[string]any, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func marshalDryRun(d DryRun) (*mcp.CallToolResult, error) {
+	d.DryRun = true
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitDryRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":"U1","name":"alice"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[{"id":"C1","name":"general"},{"id":"C2","name":"hr"}]`), 0644))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_POLICY_FILE", "")
+	t.Setenv("SLACK_MCP_ENABLED_TOOLS", "")
+	t.Setenv("SLACK_MCP_ADD_MESSAGE_TOOL", "!C2")
+	t.Setenv("SLACK_MCP_ADD_MESSAGE_UNFURLING", "")
+
+	p := provider.New("stdio", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+	ch := NewConversationsHandler(p, zap.NewNop())
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "conversations_add_message"
+	req.Params.Arguments = map[string]any{"channel_id": "#general", "text": "# Release\nShipped *today*", "thread_ts": "1700000000.000100"}
+	res, err := ch.AddMessageDryRun(context.Background(), req)
+	require.NoError(t, err)
+
+	var d struct {
+		DryRun  bool           `json:"dry_run"`
+		Method  string         `json:"method"`
+		Request map[string]any `json:"request"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &d))
+	assert.True(t, d.DryRun)
+	assert.Equal(t, "chat.postMessage", d.Method)
+	assert.Equal(t, "C1", d.Request["channel"])
+	assert.Equal(t, "1700000000.000100", d.Request["thread_ts"])
+	assert.Equal(t, "false", d.Request["unfurl_links"])
+	assert.IsType(t, []any{}, d.Request["blocks"], "blocks are rendered as JSON")
+	assert.NotContains(t, d.Request, "token")
+
+	// Dry runs are checked like calls
+	req.Params.Arguments = map[string]any{"channel_id": "#hr", "text": "hello"}
+	_, err = ch.AddMessageDryRun(context.Background(), req)
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	req.Params.Arguments = map[string]any{"channel_id": "#general", "text": "<!channel> hello"}
+	_, err = ch.AddMessageDryRun(context.Background(), req)
+	assert.ErrorContains(t, err, "mentions @channel")
+
+	h := NewUsergroupsHandler(p, zap.NewNop())
+	req = mcp.CallToolRequest{}
+	req.Params.Name = "usergroups_update"
+	req.Params.Arguments = map[string]any{"usergroup_id": "S1", "handle": "oncall", "channels": "C1, C2"}
+	res, err = h.UsergroupsUpdateDryRun(context.Background(), req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"dry_run":true,"method":"usergroups.update","request":{"usergroup":"S1","handle":"oncall","channels":"C1,C2"}}`, res.Content[0].(mcp.TextContent).Text)
+
+	req.Params.Arguments = map[string]any{"usergroup_id": "S1"}
+	_, err = h.UsergroupsUpdateDryRun(context.Background(), req)
+	assert.ErrorContains(t, err, "at least one update field")
+
+	// The default channels of a group are checked against the channels of the policy
+	policyFile := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("tools:\n  usergroups_update:\n    channels: [\"!#hr\"]\n"), 0644))
+	t.Setenv("SLACK_MCP_POLICY_FILE", policyFile)
+	req.Params.Arguments = map[string]any{"usergroup_id": "S1", "channels": "C1, C2"}
+	_, err = h.UsergroupsUpdateDryRun(context.Background(), req)
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	req.Params.Arguments = map[string]any{"usergroup_id": "S1", "channels": "C1"}
+	_, err = h.UsergroupsUpdateDryRun(context.Background(), req)
+	assert.NoError(t, err)
+	t.Setenv("SLACK_MCP_POLICY_FILE", "")
+
+	res, err = h.membersDryRun("S1", []string{"U1", "U2"}, []string{"U2", "U3"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"dry_run":true,"method":"usergroups.users.update","request":{"usergroup":"S1","users":"U2,U3"},"added":[{"id":"U3"}],"removed":[{"id":"U1","name":"alice"}]}`, res.Content[0].(mcp.TextContent).Text)
+}
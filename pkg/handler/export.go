@@ -0,0 +1,639 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	ExportFormatJSONL    = "jsonl"
+	ExportFormatMarkdown = "markdown"
+	ExportFormatHTML     = "html"
+
+	defaultExportMaxMessages = 10000
+)
+
+var (
+	slackTokenRe  = regexp.MustCompile(`<([^<>]+)>`)
+	unsafeNameRe  = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	exportFormats = map[string]struct {
+		ext  string
+		mime string
+	}{
+		ExportFormatJSONL:    {".jsonl", "application/jsonl"},
+		ExportFormatMarkdown: {".md", "text/markdown"},
+		ExportFormatHTML:     {".html", "text/html"},
+	}
+)
+
+// ExportOptions selects what Export writes. Since and Until are inclusive dates in any
+// format accepted by the search date filters, e.g. 2025-01-31.
+type ExportOptions struct {
+	Channel        string
+	ThreadTs       string
+	Since          string
+	Until          string
+	Format         string
+	IncludeThreads bool
+	MaxMessages    int
+	// Output is a file or an existing directory, defaults to ExportDir.
+	Output string
+}
+
+type ExportResult struct {
+	Path      string
+	URI       string
+	MIMEType  string
+	Messages  int
+	Truncated bool
+}
+
+// exportThread is a top-level message with its replies, replies are empty for plain messages.
+type exportThread struct {
+	Message slack.Message
+	Replies []slack.Message
+}
+
+// ExportDir returns SLACK_MCP_EXPORT_DIR or the exports directory in the cache dir.
+func ExportDir() string {
+	if dir := os.Getenv("SLACK_MCP_EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(provider.CacheDir(), "exports")
+}
+
+// ConversationsExportHandler writes a channel or thread to a file in ExportDir and
+// returns a link to it.
+func (ch *ConversationsHandler) ConversationsExportHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ch.logger.Debug("ConversationsExportHandler called", zap.Any("params", request.Params))
+
+	opts := ExportOptions{
+		Channel:        request.GetString("channel_id", ""),
+		ThreadTs:       request.GetString("thread_ts", ""),
+		Since:          request.GetString("since", ""),
+		Until:          request.GetString("until", ""),
+		Format:         request.GetString("format", ExportFormatMarkdown),
+		IncludeThreads: request.GetBool("include_threads", true),
+		MaxMessages:    request.GetInt("max_messages", defaultExportMaxMessages),
+	}
+
+	ctx = withRequestProgress(ctx, request, ch.logger)
+	res, err := ch.Export(ctx, opts)
+	if err != nil {
+		ch.logger.Error("Export failed", zap.Error(err))
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("Exported %d messages to %s", res.Messages, res.Path)
+	if res.Truncated {
+		summary += fmt.Sprintf(" (stopped at max_messages=%d, narrow the date range or raise the limit to export the rest)", opts.MaxMessages)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(summary),
+			mcp.NewResourceLink(res.URI, filepath.Base(res.Path), "Slack conversation export", res.MIMEType),
+		},
+	}, nil
+}
+
+// ExportResource serves files written by ConversationsExportHandler, only from ExportDir and
+// only to callers allowed to read the exported channel.
+func (ch *ConversationsHandler) ExportResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	ch.logger.Debug("ExportResource called", zap.Any("params", request.Params))
+
+	// mark3labs/mcp-go does not support middlewares for resources.
+	ctx, err := auth.Authenticate(ctx, ch.apiProvider.ServerTransport(), ch.logger)
+	if err != nil {
+		ch.logger.Error("Authentication failed for export resource", zap.Error(err))
+		return nil, err
+	}
+
+	prefix := "file://" + filepath.ToSlash(ExportDir()) + "/"
+	name, ok := strings.CutPrefix(request.Params.URI, prefix)
+	if !ok || name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("resource %q is not an export", request.Params.URI)
+	}
+
+	channelID, err := os.ReadFile(exportChannelPath(filepath.Join(ExportDir(), name)))
+	if err != nil {
+		ch.logger.Warn("Export has no channel record", zap.String("name", name), zap.Error(err))
+		return nil, fmt.Errorf("resource %q is not an export", request.Params.URI)
+	}
+	if err := checkReadChannel(ctx, ch.apiProvider, string(channelID)); err != nil {
+		ch.logger.Warn("Export may not be read", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(ExportDir(), name))
+	if err != nil {
+		return nil, err
+	}
+	mimeType := "text/plain"
+	for _, f := range exportFormats {
+		if strings.HasSuffix(name, f.ext) {
+			mimeType = f.mime
+		}
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: mimeType,
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// Export pages through the history (or a single thread) of a channel with the same paging,
+// rate limiting and name resolution as the conversations tools, and writes it to disk.
+func (ch *ConversationsHandler) Export(ctx context.Context, opts ExportOptions) (*ExportResult, error) {
+	format, ok := exportFormats[opts.Format]
+	if !ok {
+		return nil, fmt.Errorf("invalid format %q, expected one of: jsonl, markdown, html", opts.Format)
+	}
+	if opts.Channel == "" {
+		return nil, errors.New("channel_id is required")
+	}
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = defaultExportMaxMessages
+	}
+
+	channelID, err := ch.resolveChannelID(ctx, opts.Channel)
+	if err != nil {
+		return nil, err
+	}
+	audit.SetChannel(ctx, channelID)
+	if err := checkReadChannel(ctx, ch.apiProvider, channelID); err != nil {
+		return nil, err
+	}
+	oldest, latest, err := exportRange(opts.Since, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, truncated, err := ch.fetchExport(ctx, channelID, oldest, latest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch opts.Format {
+	case ExportFormatJSONL:
+		err = writeExportJSONL(&buf, threads)
+	case ExportFormatMarkdown:
+		err = ch.writeExportMarkdown(&buf, channelID, opts, threads)
+	case ExportFormatHTML:
+		err = ch.writeExportHTML(&buf, channelID, opts, threads)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := exportPath(opts.Output, ch.exportName(channelID, opts)+format.ext)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.WriteFileAtomic(path, buf.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+	if filepath.Clean(filepath.Dir(path)) == filepath.Clean(ExportDir()) {
+		if err := provider.WriteFileAtomic(exportChannelPath(path), []byte(channelID), 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	count := 0
+	for _, t := range threads {
+		count += 1 + len(t.Replies)
+	}
+	ch.logger.Info("Exported conversation",
+		zap.String("channel", channelID),
+		zap.String("path", path),
+		zap.Int("messages", count),
+		zap.Bool("truncated", truncated))
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return &ExportResult{
+		Path:      abs,
+		URI:       "file://" + filepath.ToSlash(abs),
+		MIMEType:  format.mime,
+		Messages:  count,
+		Truncated: truncated,
+	}, nil
+}
+
+// fetchExport returns the requested messages oldest first, thread replies grouped under
+// their parent. It stops once MaxMessages messages are collected.
+func (ch *ConversationsHandler) fetchExport(ctx context.Context, channelID, oldest, latest string, opts ExportOptions) ([]exportThread, bool, error) {
+	if opts.ThreadTs != "" {
+		params := &conversationParams{
+			channel: channelID,
+			oldest:  oldest,
+			latest:  latest,
+			budget:  &pageBudget{maxMessages: opts.MaxMessages},
+		}
+		msgs, cursor, err := ch.fetchReplies(ctx, params, opts.ThreadTs)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(msgs) == 0 {
+			return nil, false, nil
+		}
+		// The parent is only returned when it falls into the requested range,
+		// without it the replies are exported as plain messages.
+		if msgs[0].Timestamp == opts.ThreadTs {
+			return []exportThread{{Message: msgs[0], Replies: msgs[1:]}}, cursor != "", nil
+		}
+		threads := make([]exportThread, 0, len(msgs))
+		for _, m := range msgs {
+			threads = append(threads, exportThread{Message: m})
+		}
+		return threads, cursor != "", nil
+	}
+
+	params := &conversationParams{
+		channel: channelID,
+		oldest:  oldest,
+		latest:  latest,
+		budget:  &pageBudget{maxMessages: opts.MaxMessages},
+	}
+	history, cursor, err := ch.fetchHistory(ctx, params)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := cursor != ""
+	slices.Reverse(history)
+
+	threads := make([]exportThread, 0, len(history))
+	collected := len(history)
+	for _, msg := range history {
+		thread := exportThread{Message: msg}
+		if opts.IncludeThreads && isThreadParent(msg) {
+			if collected >= opts.MaxMessages {
+				truncated = true
+			} else {
+				if err := ch.historyLimiter.Wait(ctx); err != nil {
+					return nil, false, err
+				}
+				replies, next, err := ch.fetchReplies(ctx, &conversationParams{
+					channel: channelID,
+					budget:  &pageBudget{maxMessages: opts.MaxMessages - collected},
+				}, msg.Timestamp)
+				if err != nil {
+					return nil, false, err
+				}
+				for _, r := range replies {
+					if r.Timestamp != msg.Timestamp {
+						thread.Replies = append(thread.Replies, r)
+					}
+				}
+				collected += len(thread.Replies)
+				truncated = truncated || next != ""
+			}
+		}
+		threads = append(threads, thread)
+	}
+	return threads, truncated, nil
+}
+
+func (ch *ConversationsHandler) exportName(channelID string, opts ExportOptions) string {
+	name := channelID
+	if c, ok := ch.apiProvider.ProvideChannelsMaps().Channels[channelID]; ok && c.Name != "" {
+		name = c.Name
+	}
+	parts := []string{unsafeNameRe.ReplaceAllString(strings.TrimLeft(name, "#@"), "_")}
+	if opts.ThreadTs != "" {
+		parts = append(parts, "thread-"+opts.ThreadTs)
+	}
+	if opts.Since != "" || opts.Until != "" {
+		parts = append(parts, unsafeNameRe.ReplaceAllString(opts.Since+"--"+opts.Until, "_"))
+	}
+	parts = append(parts, time.Now().UTC().Format("20060102T150405Z"))
+	return strings.Join(parts, "_")
+}
+
+// exportPath resolves where to write: an explicit file, a file in an explicit directory,
+// or a file in ExportDir.
+func exportPath(output, name string) (string, error) {
+	if output != "" {
+		if fi, err := os.Stat(output); err != nil || !fi.IsDir() {
+			return output, nil
+		}
+		return filepath.Join(output, name), nil
+	}
+	dir := ExportDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// exportChannelPath returns the file recording the channel of an export in ExportDir, which
+// ExportResource checks the caller may read. It is hidden, so it is never served itself.
+func exportChannelPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".channel")
+}
+
+// exportRange turns inclusive since/until dates into exclusive oldest/latest timestamps.
+func exportRange(since, until string) (oldest, latest string, err error) {
+	var from, to time.Time
+	if since != "" {
+		if from, _, err = parseFlexibleDate(since); err != nil {
+			return "", "", fmt.Errorf("invalid since date: %v", err)
+		}
+		// oldest is exclusive, step back to include messages sent exactly at midnight.
+		oldest = strconv.FormatInt(from.Unix()-1, 10) + ".999999"
+	}
+	if until != "" {
+		if to, _, err = parseFlexibleDate(until); err != nil {
+			return "", "", fmt.Errorf("invalid until date: %v", err)
+		}
+		to = to.AddDate(0, 0, 1)
+		latest = strconv.FormatInt(to.Unix(), 10) + ".000000"
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return "", "", errors.New("since must not be after until")
+	}
+	return oldest, latest, nil
+}
+
+func writeExportJSONL(w io.Writer, threads []exportThread) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, t := range threads {
+		if err := enc.Encode(t.Message); err != nil {
+			return err
+		}
+		for _, r := range t.Replies {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func (ch *ConversationsHandler) writeExportMarkdown(w io.Writer, channelID string, opts ExportOptions, threads []exportThread) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", ch.exportTitle(channelID, opts))
+	if desc := exportRangeDescription(opts); desc != "" {
+		fmt.Fprintf(bw, "_%s_\n\n", desc)
+	}
+
+	for _, t := range threads {
+		ch.writeMarkdownMessage(bw, t.Message, "")
+		for _, r := range t.Replies {
+			ch.writeMarkdownMessage(bw, r, "> ")
+		}
+	}
+	return bw.Flush()
+}
+
+func (ch *ConversationsHandler) writeMarkdownMessage(w io.Writer, msg slack.Message, indent string) {
+	author, when := ch.exportAuthor(msg), exportTime(msg.Timestamp)
+	fmt.Fprintf(w, "%s**%s** · %s\n%s\n", indent, author, when, indent)
+
+	var sb strings.Builder
+	for _, tok := range ch.slackTokens(msg.Text) {
+		switch {
+		case tok.url == "" || !isSafeLink(tok.url):
+			sb.WriteString(tok.label())
+		case tok.text == "" || tok.text == tok.url:
+			sb.WriteString("<" + tok.url + ">")
+		default:
+			sb.WriteString("[" + tok.text + "](" + tok.url + ")")
+		}
+	}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	for _, f := range msg.Files {
+		fmt.Fprintf(w, "%s\n%s_Attachment: %s_\n", indent, indent, f.Name)
+	}
+	fmt.Fprintln(w)
+}
+
+var exportHTMLTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;max-width:860px;margin:2rem auto;padding:0 1rem;color:#1d1c1d;line-height:1.45}
+h1{font-size:1.5rem;margin-bottom:.25rem}
+.range{color:#616061;margin-bottom:1.5rem}
+.msg{padding:.5rem 0;border-top:1px solid #eee}
+.author{font-weight:700}
+.time{color:#616061;font-size:.85rem;margin-left:.5rem}
+.text{white-space:pre-wrap;margin-top:.25rem}
+.file{color:#616061;font-style:italic}
+.replies{margin-left:1.5rem;padding-left:.75rem;border-left:3px solid #ddd}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Range}}<div class="range">{{.Range}}</div>{{end}}
+{{range .Messages}}<div class="msg">
+{{template "message" .}}{{if .Replies}}<div class="replies">
+{{range .Replies}}<div class="msg">{{template "message" .}}</div>
+{{end}}</div>{{end}}
+</div>
+{{end}}</body>
+</html>
+{{define "message"}}<span class="author">{{.Author}}</span><span class="time">{{.Time}}</span>
+<div class="text">{{.Body}}</div>{{range .Files}}
+<div class="file">Attachment: {{.}}</div>{{end}}
+{{end}}`))
+
+type htmlExportMessage struct {
+	Author  string
+	Time    string
+	Body    template.HTML
+	Files   []string
+	Replies []htmlExportMessage
+}
+
+func (ch *ConversationsHandler) writeExportHTML(w io.Writer, channelID string, opts ExportOptions, threads []exportThread) error {
+	data := struct {
+		Title    string
+		Range    string
+		Messages []htmlExportMessage
+	}{
+		Title: ch.exportTitle(channelID, opts),
+		Range: exportRangeDescription(opts),
+	}
+	for _, t := range threads {
+		m := ch.htmlMessage(t.Message)
+		for _, r := range t.Replies {
+			m.Replies = append(m.Replies, ch.htmlMessage(r))
+		}
+		data.Messages = append(data.Messages, m)
+	}
+	return exportHTMLTemplate.Execute(w, data)
+}
+
+func (ch *ConversationsHandler) htmlMessage(msg slack.Message) htmlExportMessage {
+	var sb strings.Builder
+	for _, tok := range ch.slackTokens(msg.Text) {
+		if tok.url == "" || !isSafeLink(tok.url) {
+			sb.WriteString(html.EscapeString(tok.label()))
+			continue
+		}
+		fmt.Fprintf(&sb, `<a href="%s">%s</a>`, html.EscapeString(tok.url), html.EscapeString(tok.label()))
+	}
+
+	var files []string
+	for _, f := range msg.Files {
+		files = append(files, f.Name)
+	}
+	return htmlExportMessage{
+		Author: ch.exportAuthor(msg),
+		Time:   exportTime(msg.Timestamp),
+		// Every part of the body is escaped above.
+		Body:  template.HTML(sb.String()),
+		Files: files,
+	}
+}
+
+func (ch *ConversationsHandler) exportTitle(channelID string, opts ExportOptions) string {
+	title := channelID
+	if c, ok := ch.apiProvider.ProvideChannelsMaps().Channels[channelID]; ok && c.Name != "" {
+		title = c.Name
+	}
+	if opts.ThreadTs != "" {
+		title += " — thread " + opts.ThreadTs
+	}
+	return title
+}
+
+func exportRangeDescription(opts ExportOptions) string {
+	switch {
+	case opts.Since != "" && opts.Until != "":
+		return fmt.Sprintf("From %s to %s", opts.Since, opts.Until)
+	case opts.Since != "":
+		return "Since " + opts.Since
+	case opts.Until != "":
+		return "Until " + opts.Until
+	}
+	return ""
+}
+
+func (ch *ConversationsHandler) exportAuthor(msg slack.Message) string {
+	users := ch.apiProvider.ProvideUsersMap().Users
+	userName, realName, ok := getUserInfo(msg.User, users)
+	if !ok && msg.SubType == "bot_message" {
+		userName, realName, ok = getBotInfo(msg.Username)
+	}
+	switch {
+	case ok && realName != "" && realName != userName:
+		return fmt.Sprintf("%s (@%s)", realName, userName)
+	case ok:
+		return "@" + userName
+	case msg.BotProfile != nil && msg.BotProfile.Name != "":
+		return msg.BotProfile.Name
+	case msg.User != "":
+		return msg.User
+	}
+	return "unknown"
+}
+
+func exportTime(ts string) string {
+	sec, _, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return ts
+	}
+	return time.Unix(s, 0).UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// slackToken is either plain text (url empty) or a link with an optional label.
+type slackToken struct {
+	text string
+	url  string
+}
+
+// label returns the text to show for the token, links without a label show their URL.
+func (t slackToken) label() string {
+	if t.text == "" {
+		return t.url
+	}
+	return t.text
+}
+
+// slackTokens splits Slack mrkdwn into text and links, resolving user, channel and
+// special mentions to names. Text is unescaped.
+func (ch *ConversationsHandler) slackTokens(s string) []slackToken {
+	users := ch.apiProvider.ProvideUsersMap().Users
+	channels := ch.apiProvider.ProvideChannelsMaps().Channels
+
+	var tokens []slackToken
+	last := 0
+	for _, loc := range slackTokenRe.FindAllStringSubmatchIndex(s, -1) {
+		if loc[0] > last {
+			tokens = append(tokens, slackToken{text: html.UnescapeString(s[last:loc[0]])})
+		}
+		last = loc[1]
+
+		target, label, _ := strings.Cut(s[loc[2]:loc[3]], "|")
+		label = html.UnescapeString(label)
+		switch {
+		case strings.HasPrefix(target, "@"):
+			name := label
+			if userName, _, ok := getUserInfo(target[1:], users); ok {
+				name = userName
+			}
+			if name == "" {
+				name = target[1:]
+			}
+			tokens = append(tokens, slackToken{text: "@" + strings.TrimPrefix(name, "@")})
+		case strings.HasPrefix(target, "#"):
+			name := label
+			if c, ok := channels[target[1:]]; ok && c.Name != "" {
+				name = c.Name
+			}
+			if name == "" {
+				name = target[1:]
+			}
+			tokens = append(tokens, slackToken{text: "#" + strings.TrimPrefix(name, "#")})
+		case strings.HasPrefix(target, "!"):
+			name := label
+			if name == "" {
+				name = "@" + strings.SplitN(target[1:], "^", 2)[0]
+			}
+			tokens = append(tokens, slackToken{text: name})
+		default:
+			tokens = append(tokens, slackToken{text: label, url: html.UnescapeString(target)})
+		}
+	}
+	if last < len(s) {
+		tokens = append(tokens, slackToken{text: html.UnescapeString(s[last:])})
+	}
+	return tokens
+}
+
+func isSafeLink(u string) bool {
+	return strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "mailto:")
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitExportRange(t *testing.T) {
+	oldest, latest, err := exportRange("2025-03-10", "2025-03-11")
+	require.NoError(t, err)
+	assert.Equal(t, "1741564799.999999", oldest)
+	assert.Equal(t, "1741737600.000000", latest)
+
+	oldest, latest, err = exportRange("", "")
+	require.NoError(t, err)
+	assert.Empty(t, oldest)
+	assert.Empty(t, latest)
+
+	_, _, err = exportRange("2025-03-12", "2025-03-10")
+	assert.Error(t, err)
+
+	_, _, err = exportRange("not-a-date", "")
+	assert.Error(t, err)
+}
+
+func TestUnitExportTime(t *testing.T) {
+	assert.Equal(t, "2025-03-10 12:00 UTC", exportTime("1741608000.123456"))
+	assert.Equal(t, "garbage", exportTime("garbage"))
+}
+
+func TestUnitWriteExportJSONL(t *testing.T) {
+	threads := []exportThread{
+		{Message: slack.Message{Msg: slack.Msg{Timestamp: "1.0", Text: "parent"}}, Replies: []slack.Message{
+			{Msg: slack.Msg{Timestamp: "2.0", ThreadTimestamp: "1.0", Text: "reply"}},
+		}},
+		{Message: slack.Message{Msg: slack.Msg{Timestamp: "3.0", Text: "plain"}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeExportJSONL(&buf, threads))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"text":"parent"`)
+	assert.Contains(t, lines[1], `"text":"reply"`)
+	assert.Contains(t, lines[2], `"text":"plain"`)
+}
+
+func TestUnitIsSafeLink(t *testing.T) {
+	assert.True(t, isSafeLink("https://example.com"))
+	assert.True(t, isSafeLink("mailto:someone@example.com"))
+	assert.False(t, isSafeLink("javascript:alert(1)"))
+	assert.False(t, isSafeLink("data:text/html,hi"))
+}
+
+func TestUnitExportResource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":"U1","name":"alice"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[{"id":"C1","name":"general"},{"id":"C2","name":"random"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(`{"keys":[
+		{"name":"reader","key":"reader-secret","read_channels":["#general"]}
+	]}`), 0600))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_POLICY_FILE", "")
+	t.Setenv("SLACK_MCP_READ_CHANNELS", "")
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", filepath.Join(dir, "keys.json"))
+
+	exports := filepath.Join(dir, "exports")
+	t.Setenv("SLACK_MCP_EXPORT_DIR", exports)
+	require.NoError(t, os.MkdirAll(exports, 0700))
+	for name, channelID := range map[string]string{"general.md": "C1", "random.md": "C2", "orphan.md": ""} {
+		path := filepath.Join(exports, name)
+		require.NoError(t, os.WriteFile(path, []byte("# "+name+"\n"), 0600))
+		if channelID != "" {
+			require.NoError(t, os.WriteFile(exportChannelPath(path), []byte(channelID), 0600))
+		}
+	}
+
+	p := provider.New("http", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+	ch := NewConversationsHandler(p, zap.NewNop())
+
+	read := func(token, uri string) ([]mcp.ResourceContents, error) {
+		r := httptest.NewRequest("POST", "/mcp", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		req := mcp.ReadResourceRequest{}
+		req.Params.URI = uri
+		return ch.ExportResource(auth.AuthFromRequest(zap.NewNop())(context.Background(), r), req)
+	}
+	uri := func(name string) string {
+		return "file://" + filepath.ToSlash(exports) + "/" + name
+	}
+
+	contents, err := read("reader-secret", uri("general.md"))
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	text := contents[0].(mcp.TextResourceContents)
+	assert.Equal(t, "text/markdown", text.MIMEType)
+	assert.Equal(t, "# general.md\n", text.Text)
+
+	_, err = read("", uri("general.md"))
+	assert.Error(t, err, "unauthenticated")
+	_, err = read("reader-secret", uri("random.md"))
+	assert.ErrorContains(t, err, `API key "reader" may not read channel "C2"`)
+
+	// Exports record their channel
+	res, err := ch.Export(context.Background(), ExportOptions{Channel: "#general", Format: ExportFormatJSONL})
+	require.NoError(t, err)
+	channelID, err := os.ReadFile(exportChannelPath(res.Path))
+	require.NoError(t, err)
+	assert.Equal(t, "C1", string(channelID))
+	_, err = read("reader-secret", res.URI)
+	assert.NoError(t, err)
+
+	for _, u := range []string{
+		uri("orphan.md"),
+		uri(".general.md.channel"),
+		uri("../secret"),
+		uri("sub/general.md"),
+		"file:///etc/passwd",
+	} {
+		_, err := read("reader-secret", u)
+		assert.Error(t, err, u)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultAutoPaginateMaxMessages bounds auto-pagination when the caller sets no budget.
+	defaultAutoPaginateMaxMessages = 1000
+	// autoPaginatePageSize is the page size requested from conversations.history/replies while auto-paginating.
+	autoPaginatePageSize = 200
+	// csvRowOverheadTokens approximates the tokens spent on the non-text columns of a CSV row.
+	csvRowOverheadTokens = 30
+)
+
+// pageBudget tracks how much an auto-paginating fetch has collected so far
+// and tells when to stop. Pages are never split, so a budget may be exceeded
+// by up to one page, keeping the continuation cursor exact.
+type pageBudget struct {
+	maxMessages int
+	maxTokens   int
+
+	messages int
+	tokens   int
+}
+
+// parsePageBudget returns nil when auto_paginate is not requested. fallbackMax is used as
+// max_messages when the caller sets neither max_messages nor max_tokens.
+func parsePageBudget(request mcp.CallToolRequest, fallbackMax int) (*pageBudget, error) {
+	if !request.GetBool("auto_paginate", false) {
+		return nil, nil
+	}
+
+	maxMessages := request.GetInt("max_messages", 0)
+	maxTokens := request.GetInt("max_tokens", 0)
+	if maxMessages < 0 || maxTokens < 0 {
+		return nil, errors.New("max_messages and max_tokens must not be negative")
+	}
+	if maxMessages == 0 && maxTokens == 0 {
+		maxMessages = fallbackMax
+		if maxMessages <= 0 {
+			maxMessages = defaultAutoPaginateMaxMessages
+		}
+	}
+
+	return &pageBudget{maxMessages: maxMessages, maxTokens: maxTokens}, nil
+}
+
+func (b *pageBudget) add(texts ...string) {
+	for _, text := range texts {
+		b.messages++
+		b.tokens += estimateTokens(text)
+	}
+}
+
+func (b *pageBudget) exhausted() bool {
+	return (b.maxMessages > 0 && b.messages >= b.maxMessages) ||
+		(b.maxTokens > 0 && b.tokens >= b.maxTokens)
+}
+
+// pageSize returns how many messages to request next, never more than max.
+func (b *pageBudget) pageSize(max int) int {
+	if b.maxMessages > 0 && b.maxMessages-b.messages < max {
+		return b.maxMessages - b.messages
+	}
+	return max
+}
+
+// estimateTokens is a rough estimate of ~4 bytes per token plus the CSV row overhead.
+func estimateTokens(text string) int {
+	return (len(text)+3)/4 + csvRowOverheadTokens
+}
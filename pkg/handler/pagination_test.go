@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolRequest(args map[string]any) mcp.CallToolRequest {
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	return req
+}
+
+func TestUnitParsePageBudget(t *testing.T) {
+	t.Run("disabled without auto_paginate", func(t *testing.T) {
+		b, err := parsePageBudget(toolRequest(map[string]any{"max_messages": 10}), 0)
+		require.NoError(t, err)
+		assert.Nil(t, b)
+	})
+
+	t.Run("falls back to numeric limit", func(t *testing.T) {
+		b, err := parsePageBudget(toolRequest(map[string]any{"auto_paginate": true}), 50)
+		require.NoError(t, err)
+		assert.Equal(t, 50, b.maxMessages)
+	})
+
+	t.Run("falls back to default cap", func(t *testing.T) {
+		b, err := parsePageBudget(toolRequest(map[string]any{"auto_paginate": true}), 0)
+		require.NoError(t, err)
+		assert.Equal(t, defaultAutoPaginateMaxMessages, b.maxMessages)
+	})
+
+	t.Run("token budget only", func(t *testing.T) {
+		b, err := parsePageBudget(toolRequest(map[string]any{"auto_paginate": true, "max_tokens": 2000}), 50)
+		require.NoError(t, err)
+		assert.Equal(t, 0, b.maxMessages)
+		assert.Equal(t, 2000, b.maxTokens)
+	})
+
+	t.Run("negative budget is rejected", func(t *testing.T) {
+		_, err := parsePageBudget(toolRequest(map[string]any{"auto_paginate": true, "max_messages": -1}), 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestUnitPageBudget(t *testing.T) {
+	b := &pageBudget{maxMessages: 250}
+	assert.Equal(t, 200, b.pageSize(autoPaginatePageSize))
+	b.add(make([]string, 200)...)
+	assert.False(t, b.exhausted())
+	assert.Equal(t, 50, b.pageSize(autoPaginatePageSize))
+	b.add(make([]string, 50)...)
+	assert.True(t, b.exhausted())
+
+	tokens := &pageBudget{maxTokens: 100}
+	tokens.add(strings.Repeat("x", 200))
+	assert.False(t, tokens.exhausted())
+	tokens.add(strings.Repeat("x", 200))
+	assert.True(t, tokens.exhausted())
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/slack-go/slack"
+)
+
+// checkToolPolicy returns an error if the tool policy disables tool or does not allow it in
+// channelID, see policy.Current. The users, schedule and rate of the policy are enforced by
+// its middleware.
+func checkToolPolicy(ap *provider.ApiProvider, tool, channelID string) error {
+	p, err := policy.Current()
+	if err != nil {
+		return err
+	}
+	if err := p.CheckEnabled(tool); err != nil {
+		return err
+	}
+	return p.CheckChannel(tool, policyChannel(ap, channelID))
+}
+
+// checkToolContent returns an error if the tool policy does not allow tool to send s to
+// Slack as its field, e.g. because it contains a credential or mentions @here.
+func checkToolContent(tool, field, s string) error {
+	p, err := policy.Current()
+	if err != nil {
+		return err
+	}
+	return p.CheckContent(tool, field, s)
+}
+
+// policyChannel describes channelID with its name and type from the channels cache.
+func policyChannel(ap *provider.ApiProvider, channelID string) policy.Channel {
+	c := policy.Channel{ID: channelID}
+	ch, ok := ap.ProvideChannelsMaps().Channels[channelID]
+	if !ok {
+		return c
+	}
+	c.Name = ch.Name
+	c.Shared = ch.IsExtShared
+	switch {
+	case ch.IsIM:
+		c.Type = "im"
+	case ch.IsMpIM:
+		c.Type = "mpim"
+	case ch.IsPrivate:
+		c.Type = provider.PrivateChanType
+	default:
+		c.Type = provider.PubChanType
+	}
+	return c
+}
+
+// readChannelFilter returns whether a channel ID may be read, under the read policy and by
+// the API key of the call. It returns nil if every channel may be read. An invalid policy
+// file hides every channel until it is fixed.
+func readChannelFilter(ctx context.Context, ap *provider.ApiProvider) func(channelID string) bool {
+	principal := principalChannelFilter(ctx, ap, false)
+	p, err := policy.Current()
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	read := p.ReadChannels()
+	if read.Empty() {
+		return principal
+	}
+	return func(channelID string) bool {
+		return read.Allows(policyChannel(ap, channelID)) && (principal == nil || principal(channelID))
+	}
+}
+
+// checkReadChannel returns an error if the read policy or the API key of the call do not
+// allow reading channelID.
+func checkReadChannel(ctx context.Context, ap *provider.ApiProvider, channelID string) error {
+	p, err := policy.Current()
+	if err != nil {
+		return err
+	}
+	if err := p.CheckRead(policyChannel(ap, channelID)); err != nil {
+		return err
+	}
+	return checkPrincipalChannel(ctx, ap, channelID, false)
+}
+
+// hidesExternalUsers reports whether users of other workspaces are left out of user lists,
+// as the read policy denies Slack Connect channels and DMs.
+func hidesExternalUsers() bool {
+	p, err := policy.Current()
+	return err != nil || p.ReadChannels().DeniesShared()
+}
+
+// isExternalUser reports whether user belongs to a workspace other than teamID, i.e. was
+// met in a Slack Connect channel or DM.
+func isExternalUser(user slack.User, teamID string) bool {
+	return user.IsStranger || (user.TeamID != "" && teamID != "" && user.TeamID != teamID)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitCheckToolPolicy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":"U1","name":"alice"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[{"id":"C1","name":"general"},{"id":"C2","name":"eng-backend"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte(`
+tools:
+  conversations_add_message:
+    channels: ["#eng-*"]
+`), 0600))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_POLICY_FILE", filepath.Join(dir, "policy.yaml"))
+
+	p := provider.New("stdio", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+
+	assert.Equal(t, policy.Channel{ID: "C2", Name: "#eng-backend", Type: provider.PubChanType}, policyChannel(p, "C2"))
+	assert.NoError(t, checkToolPolicy(p, "conversations_add_message", "C2"))
+	assert.ErrorIs(t, checkToolPolicy(p, "conversations_add_message", "C1"), policy.ErrDenied)
+	assert.ErrorContains(t, checkToolPolicy(p, "reactions_add", "C2"), "reactions_add is disabled by SLACK_MCP_POLICY_FILE")
+}
+
+func TestUnitReadChannelFilter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":"U1","name":"alice"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[
+		{"id":"C1","name":"general"},
+		{"id":"C2","name":"hr"},
+		{"id":"C3","name":"partners","is_ext_shared":true}
+	]`), 0644))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_POLICY_FILE", "")
+	t.Setenv("SLACK_MCP_READ_CHANNELS", "")
+
+	p := provider.New("stdio", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+	ctx := context.Background()
+
+	assert.Nil(t, readChannelFilter(ctx, p))
+	assert.False(t, hidesExternalUsers())
+
+	t.Setenv("SLACK_MCP_READ_CHANNELS", "!#hr,!type:shared")
+	allowed := readChannelFilter(ctx, p)
+	require.NotNil(t, allowed)
+	assert.True(t, allowed("C1"))
+	assert.False(t, allowed("C2"))
+	assert.False(t, allowed("C3"))
+	assert.NoError(t, checkReadChannel(ctx, p, "C1"))
+	assert.ErrorContains(t, checkReadChannel(ctx, p, "C3"), "channel #partners (C3) may not be read")
+	// Channels missing from the cache cannot be checked against names and types
+	assert.ErrorIs(t, checkReadChannel(ctx, p, "C9"), policy.ErrDenied)
+	assert.True(t, hidesExternalUsers())
+
+	assert.True(t, isExternalUser(slack.User{ID: "U2", TeamID: "T2"}, "T1"))
+	assert.True(t, isExternalUser(slack.User{ID: "U2", IsStranger: true}, "T1"))
+	assert.False(t, isExternalUser(slack.User{ID: "U1", TeamID: "T1"}, "T1"))
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+)
+
+// principalChannelFilter returns whether the API key of the call may read from, or with
+// write set write to, a channel ID. It returns nil if the key may use every channel, as do
+// calls without an API key, e.g. on stdio.
+func principalChannelFilter(ctx context.Context, ap *provider.ApiProvider, write bool) func(channelID string) bool {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	channels := principal.ReadChannels
+	if write {
+		channels = principal.WriteChannels
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	// The key file may name channels, the policy is applied to their IDs
+	inv := ap.ProvideChannelsMaps().ChannelsInv
+	items := make([]string, len(channels))
+	for i, item := range channels {
+		item = strings.TrimSpace(item)
+		negated := strings.HasPrefix(item, "!")
+		item = strings.TrimPrefix(item, "!")
+		if id, ok := inv[item]; ok {
+			item = id
+		}
+		if negated {
+			item = "!" + item
+		}
+		items[i] = item
+	}
+	config := strings.Join(items, ",")
+	return func(channelID string) bool {
+		return isChannelAllowedForConfig(channelID, config)
+	}
+}
+
+// principalAllowsChannel reports whether the API key of the call may read from, or with
+// write set write to, channelID.
+func principalAllowsChannel(ctx context.Context, ap *provider.ApiProvider, channelID string, write bool) bool {
+	allowed := principalChannelFilter(ctx, ap, write)
+	return allowed == nil || allowed(channelID)
+}
+
+// checkPrincipalChannel returns an error if the API key of the call may not read from, or
+// with write set write to, channelID.
+func checkPrincipalChannel(ctx context.Context, ap *provider.ApiProvider, channelID string, write bool) error {
+	if principalAllowsChannel(ctx, ap, channelID, write) {
+		return nil
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	access := "read"
+	if write {
+		access = "write to"
+	}
+	return fmt.Errorf("%w: API key %q may not %s channel %q", policy.ErrDenied, principal.Name, access, channelID)
+}
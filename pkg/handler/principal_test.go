@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitPrincipalChannelFilter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":"U1","name":"alice"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "channels.json"), []byte(`[{"id":"C1","name":"general"},{"id":"C2","name":"random"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(`{"keys":[
+		{"name":"reader","key":"reader-secret","read_channels":["#general"],"write_channels":["!C1"]}
+	]}`), 0600))
+	t.Setenv("SLACK_MCP_OFFLINE_SOURCE", dir)
+	t.Setenv("SLACK_MCP_USERS_CACHE", filepath.Join(dir, "users_cache.json"))
+	t.Setenv("SLACK_MCP_CHANNELS_CACHE", filepath.Join(dir, "channels_cache_v2.json"))
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", filepath.Join(dir, "keys.json"))
+
+	p := provider.New("http", zap.NewNop())
+	require.NoError(t, p.RefreshUsers(context.Background()))
+	require.NoError(t, p.RefreshChannels(context.Background()))
+
+	// Without an API key, e.g. on stdio, every channel is allowed
+	assert.Nil(t, principalChannelFilter(context.Background(), p, false))
+	assert.NoError(t, checkPrincipalChannel(context.Background(), p, "C2", true))
+
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	r.Header.Set("Authorization", "Bearer reader-secret")
+	ctx, err := auth.Authenticate(auth.AuthFromRequest(zap.NewNop())(context.Background(), r), "http", zap.NewNop())
+	require.NoError(t, err)
+
+	assert.True(t, principalAllowsChannel(ctx, p, "C1", false))
+	assert.False(t, principalAllowsChannel(ctx, p, "C2", false))
+	assert.False(t, principalAllowsChannel(ctx, p, "C1", true))
+	assert.True(t, principalAllowsChannel(ctx, p, "C2", true))
+	assert.ErrorContains(t, checkPrincipalChannel(ctx, p, "C2", false), `API key "reader" may not read channel "C2"`)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"channel_types": "public_channel"}
+	res, err := NewChannelsHandler(p, zap.NewNop()).ChannelsHandler(ctx, req)
+	require.NoError(t, err)
+	csv := res.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, csv, "general")
+	assert.NotContains(t, csv, "random")
+}
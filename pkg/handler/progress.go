@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// withRequestProgress attaches a provider.ProgressFunc to ctx that forwards progress
+// as MCP notifications/progress, if the client asked for it with a progress token.
+func withRequestProgress(ctx context.Context, request mcp.CallToolRequest, logger *zap.Logger) context.Context {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return ctx
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return ctx
+	}
+
+	token := request.Params.Meta.ProgressToken
+	return provider.WithProgress(ctx, func(progress, total float64, message string) {
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		if message != "" {
+			params["message"] = message
+		}
+		if err := srv.SendNotificationToClient(ctx, "notifications/progress", params); err != nil {
+			logger.Debug("Failed to send progress notification", zap.Error(err))
+		}
+	})
+}
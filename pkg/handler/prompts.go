@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// ChannelSummaryPrompt asks the model to summarize recent discussion in a channel.
+func (ch *ConversationsHandler) ChannelSummaryPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	ch.logger.Debug("ChannelSummaryPrompt called", zap.Any("params", request.Params))
+
+	channel := request.Params.Arguments["channel_id"]
+	if channel == "" {
+		return nil, errors.New("channel_id is required")
+	}
+	limit := request.Params.Arguments["limit"]
+	if limit == "" {
+		limit = defaultConversationsExpressionLimit
+	}
+
+	return mcp.NewGetPromptResult(
+		"Summarize a Slack channel",
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(fmt.Sprintf(
+				"Use the conversations_history tool with channel_id=%q and limit=%q, expand relevant threads with conversations_replies, "+
+					"then summarize the main topics, decisions and open questions.",
+				channel, limit,
+			))),
+		},
+	), nil
+}
+
+// UserMessagesPrompt asks the model to find what a user has been discussing, optionally in one channel.
+func (ch *ConversationsHandler) UserMessagesPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	ch.logger.Debug("UserMessagesPrompt called", zap.Any("params", request.Params))
+
+	user := request.Params.Arguments["filter_users_from"]
+	if user == "" {
+		return nil, errors.New("filter_users_from is required")
+	}
+
+	instruction := fmt.Sprintf("Use the conversations_search_messages tool with filter_users_from=%q", user)
+	if channel := request.Params.Arguments["filter_in_channel"]; channel != "" {
+		instruction += fmt.Sprintf(" and filter_in_channel=%q", channel)
+	}
+	instruction += " to find recent messages from this user, then summarize what they have been working on."
+
+	return mcp.NewGetPromptResult(
+		"Recent messages from a Slack user",
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instruction)),
+		},
+	), nil
+}
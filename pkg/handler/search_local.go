@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/search"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const defaultLocalSearchLimit = 20
+
+var (
+	yearRe      = regexp.MustCompile(`^\d{4}$`)
+	yearMonthRe = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+)
+
+var monthNames = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+type LocalSearchMessage struct {
+	MsgID       string `json:"msgID"`
+	UserID      string `json:"userID"`
+	UserName    string `json:"userUser"`
+	RealName    string `json:"realName"`
+	Channel     string `json:"channelID"`
+	ChannelName string `json:"channelName"`
+	ThreadTs    string `json:"ThreadTs"`
+	Time        string `json:"time"`
+	Score       string `json:"score"`
+	Snippet     string `json:"snippet"`
+	Text        string `json:"text"`
+	Cursor      string `json:"cursor"`
+}
+
+// LocalSearchHandler searches messages of the local archive with an in-memory inverted index.
+// It needs no Slack search API access, so it works with bot tokens and offline.
+type LocalSearchHandler struct {
+	apiProvider *provider.ApiProvider
+	logger      *zap.Logger
+	index       *search.Index
+}
+
+func NewLocalSearchHandler(apiProvider *provider.ApiProvider, logger *zap.Logger) *LocalSearchHandler {
+	h := &LocalSearchHandler{
+		apiProvider: apiProvider,
+		logger:      logger,
+		index:       search.NewIndex(),
+	}
+
+	archive := apiProvider.Archive()
+	if archive == nil {
+		return h
+	}
+	for _, channelID := range archive.ChannelIDs() {
+		h.onSync(channelID, archive.Messages(channelID))
+	}
+	archive.OnSync(h.onSync)
+	logger.Info("Built local search index", zap.Int("messages", h.index.Len()))
+	return h
+}
+
+// onSync keeps the index in line with the archive: deleted messages are dropped,
+// new and edited ones are (re)indexed.
+func (h *LocalSearchHandler) onSync(channelID string, changed []provider.ArchivedMessage) {
+	for _, m := range changed {
+		if m.Deleted {
+			h.index.Remove(channelID, m.Timestamp)
+			continue
+		}
+		h.index.Add(search.Document{
+			ChannelID: channelID,
+			Ts:        m.Timestamp,
+			ThreadTs:  m.ThreadTimestamp,
+			User:      m.User,
+			Text:      m.Text,
+		})
+	}
+}
+
+func (h *LocalSearchHandler) MessagesSearchLocalHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	h.logger.Debug("MessagesSearchLocalHandler called", zap.Any("params", request.Params))
+
+	rawQuery := strings.TrimSpace(request.GetString("search_query", ""))
+	if rawQuery == "" {
+		return nil, errors.New("search_query is required")
+	}
+	freeText, filters := splitQuery(rawQuery)
+
+	filter, err := h.buildFilter(filters)
+	if err != nil {
+		h.logger.Error("Invalid local search filters", zap.Error(err))
+		return nil, err
+	}
+	if allowed := readChannelFilter(ctx, h.apiProvider); allowed != nil {
+		matches := filter
+		filter = func(d *search.Document) bool {
+			return allowed(d.ChannelID) && (matches == nil || matches(d))
+		}
+	}
+
+	limit := request.GetInt("limit", defaultLocalSearchLimit)
+	if limit <= 0 || limit > 100 {
+		return nil, fmt.Errorf("limit must be an integer between 1 and 100, got %d", limit)
+	}
+	offset, err := decodeOffsetCursor(request.GetString("cursor", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	results := h.index.Search(search.Query{Terms: freeText, Filter: filter})
+	h.logger.Debug("Local search completed", zap.String("query", rawQuery), zap.Int("matches", len(results)))
+
+	if offset > len(results) {
+		offset = len(results)
+	}
+	page := results[offset:min(offset+limit, len(results))]
+
+	rows := h.toRows(page)
+	if len(rows) > 0 && offset+limit < len(results) {
+		rows[len(rows)-1].Cursor = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("offset:%d", offset+limit)))
+	}
+	csvBytes, err := gocsv.MarshalBytes(&rows)
+	if err != nil {
+		h.logger.Error("Failed to marshal local search results to CSV", zap.Error(err))
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(csvBytes)), nil
+}
+
+func (h *LocalSearchHandler) toRows(results []search.Result) []LocalSearchMessage {
+	users := h.apiProvider.ProvideUsersMap().Users
+	channels := h.apiProvider.ProvideChannelsMaps().Channels
+
+	rows := make([]LocalSearchMessage, 0, len(results))
+	for _, r := range results {
+		doc := r.Document
+		userName, realName, _ := getUserInfo(doc.User, users)
+		timestamp, err := text.TimestampToIsoRFC3339(doc.Ts)
+		if err != nil {
+			h.logger.Error("Failed to convert timestamp to RFC3339", zap.Error(err))
+			continue
+		}
+		rows = append(rows, LocalSearchMessage{
+			MsgID:       doc.Ts,
+			UserID:      doc.User,
+			UserName:    userName,
+			RealName:    realName,
+			Channel:     doc.ChannelID,
+			ChannelName: channels[doc.ChannelID].Name,
+			ThreadTs:    doc.ThreadTs,
+			Time:        timestamp,
+			Score:       strconv.FormatFloat(r.Score, 'f', 3, 64),
+			Snippet:     text.ProcessText(r.Snippet),
+			Text:        text.ProcessText(doc.Text),
+		})
+	}
+	return rows
+}
+
+// buildFilter translates the search.messages filter vocabulary into a predicate on indexed documents.
+func (h *LocalSearchHandler) buildFilter(filters map[string][]string) (func(*search.Document) bool, error) {
+	var preds []func(*search.Document) bool
+
+	if vals := filters["in"]; len(vals) > 0 {
+		channels := make(map[string]struct{}, len(vals))
+		for _, v := range vals {
+			id, err := resolveArchivedChannel(h.apiProvider, v)
+			if err != nil {
+				return nil, err
+			}
+			channels[id] = struct{}{}
+		}
+		preds = append(preds, func(d *search.Document) bool {
+			_, ok := channels[d.ChannelID]
+			return ok
+		})
+	}
+
+	if vals := filters["from"]; len(vals) > 0 {
+		users, err := h.resolveUsers(vals)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, func(d *search.Document) bool {
+			_, ok := users[d.User]
+			return ok
+		})
+	}
+
+	if vals := filters["with"]; len(vals) > 0 {
+		users, err := h.resolveUsers(vals)
+		if err != nil {
+			return nil, err
+		}
+		channels := h.apiProvider.ProvideChannelsMaps().Channels
+		preds = append(preds, func(d *search.Document) bool {
+			for u := range users {
+				if c := channels[d.ChannelID]; c.IsIM && c.User == u {
+					return true
+				}
+				if h.index.InThreadWith(d, u) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	for _, key := range []string{"before", "after", "on", "during"} {
+		for _, v := range filters[key] {
+			from, to, err := localDateRange(key, v)
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, func(d *search.Document) bool {
+				t := tsToTime(d.Ts)
+				return !t.Before(from) && (to.IsZero() || t.Before(to))
+			})
+		}
+	}
+
+	for _, v := range filters["is"] {
+		if !strings.EqualFold(v, "thread") {
+			return nil, fmt.Errorf("unsupported filter is:%s, only is:thread is supported", v)
+		}
+		preds = append(preds, func(d *search.Document) bool {
+			return d.ThreadTs != ""
+		})
+	}
+
+	if len(preds) == 0 {
+		return nil, nil
+	}
+	return func(d *search.Document) bool {
+		for _, p := range preds {
+			if !p(d) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// resolveArchivedChannel resolves a channel ID, #name or @user (for DMs) to a channel ID.
+func resolveArchivedChannel(ap *provider.ApiProvider, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	cms := ap.ProvideChannelsMaps()
+	switch {
+	case strings.HasPrefix(raw, "#"):
+		if id, ok := cms.ChannelsInv[raw]; ok {
+			return id, nil
+		}
+	case strings.HasPrefix(raw, "@"), strings.HasPrefix(raw, "<@"):
+		uid, err := resolveArchivedUser(ap, raw)
+		if err != nil {
+			return "", err
+		}
+		for id, c := range cms.Channels {
+			if c.IsIM && c.User == uid {
+				return id, nil
+			}
+		}
+	default:
+		if _, ok := cms.Channels[raw]; ok {
+			return raw, nil
+		}
+		// Channels may be archived without being in the channels cache, e.g. with bot tokens.
+		if strings.HasPrefix(raw, "C") || strings.HasPrefix(raw, "G") || strings.HasPrefix(raw, "D") {
+			return raw, nil
+		}
+	}
+	return "", fmt.Errorf("channel %q not found", raw)
+}
+
+func (h *LocalSearchHandler) resolveUsers(vals []string) (map[string]struct{}, error) {
+	users := make(map[string]struct{}, len(vals))
+	for _, v := range vals {
+		uid, err := resolveArchivedUser(h.apiProvider, v)
+		if err != nil {
+			return nil, err
+		}
+		users[uid] = struct{}{}
+	}
+	return users, nil
+}
+
+func resolveArchivedUser(ap *provider.ApiProvider, raw string) (string, error) {
+	raw = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "<@"), ">")
+	raw = strings.TrimPrefix(raw, "@")
+	users := ap.ProvideUsersMap()
+	if _, ok := users.Users[raw]; ok {
+		return raw, nil
+	}
+	if uid, ok := users.UsersInv[raw]; ok {
+		return uid, nil
+	}
+	if isSlackUserIDPrefix(raw) && strings.ToUpper(raw) == raw {
+		return raw, nil
+	}
+	return "", fmt.Errorf("user %q not found", raw)
+}
+
+// localDateRange returns the [from, to) range selected by a date filter, a zero bound is open.
+// Like Slack, before: and after: exclude the given day and during: accepts a year, a month or a day.
+func localDateRange(key, val string) (from, to time.Time, err error) {
+	if key == "during" {
+		if yearRe.MatchString(val) {
+			year, _ := strconv.Atoi(val)
+			from = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			return from, from.AddDate(1, 0, 0), nil
+		}
+		if m := yearMonthRe.FindStringSubmatch(val); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			from = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+			return from, from.AddDate(0, 1, 0), nil
+		}
+		if month, ok := monthNames[strings.ToLower(val)]; ok {
+			now := time.Now().UTC()
+			from = time.Date(now.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+			if from.After(now) {
+				from = from.AddDate(-1, 0, 0)
+			}
+			return from, from.AddDate(0, 1, 0), nil
+		}
+	}
+
+	day, _, err := parseFlexibleDate(val)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: date: %v", key, err)
+	}
+	switch key {
+	case "before":
+		return time.Time{}, day, nil
+	case "after":
+		return day.AddDate(0, 0, 1), time.Time{}, nil
+	default:
+		return day, day.AddDate(0, 0, 1), nil
+	}
+}
+
+func decodeOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %v", err)
+	}
+	v, ok := strings.CutPrefix(string(decoded), "offset:")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor: %v", cursor)
+	}
+	offset, err := strconv.Atoi(v)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor offset: %v", cursor)
+	}
+	return offset, nil
+}
+
+func tsToTime(ts string) time.Time {
+	sec, _, _ := strings.Cut(ts, ".")
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	return time.Unix(s, 0).UTC()
+}
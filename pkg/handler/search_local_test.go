@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitLocalDateRange(t *testing.T) {
+	day := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		key, val string
+		from, to time.Time
+	}{
+		{"before", "2025-03-10", time.Time{}, day},
+		{"after", "2025-03-10", day.AddDate(0, 0, 1), time.Time{}},
+		{"on", "2025-03-10", day, day.AddDate(0, 0, 1)},
+		{"during", "2025-03-10", day, day.AddDate(0, 0, 1)},
+		{"during", "2025", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"during", "2025-03", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+":"+tt.val, func(t *testing.T) {
+			from, to, err := localDateRange(tt.key, tt.val)
+			require.NoError(t, err)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+		})
+	}
+
+	t.Run("month name is never in the future", func(t *testing.T) {
+		from, to, err := localDateRange("during", "january")
+		require.NoError(t, err)
+		assert.Equal(t, time.January, from.Month())
+		assert.False(t, from.After(time.Now()))
+		assert.Equal(t, from.AddDate(0, 1, 0), to)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		_, _, err := localDateRange("on", "not-a-date")
+		assert.Error(t, err)
+	})
+}
+
+func TestUnitDecodeOffsetCursor(t *testing.T) {
+	offset, err := decodeOffsetCursor("")
+	require.NoError(t, err)
+	assert.Equal(t, 0, offset)
+
+	offset, err = decodeOffsetCursor(base64.StdEncoding.EncodeToString([]byte("offset:40")))
+	require.NoError(t, err)
+	assert.Equal(t, 40, offset)
+
+	for _, bad := range []string{"!!!", base64.StdEncoding.EncodeToString([]byte("page:2")), base64.StdEncoding.EncodeToString([]byte("offset:-1"))} {
+		_, err := decodeOffsetCursor(bad)
+		assert.Error(t, err, bad)
+	}
+}
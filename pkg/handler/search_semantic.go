@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocarina/gocsv"
+	"github.com/korotovsky/slack-mcp-server/pkg/embeddings"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/search"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSemanticSearchLimit = 10
+	maxSemanticSearchLimit     = 50
+
+	// threadDocSuffix marks index entries that embed a whole thread rather than a single message.
+	threadDocSuffix = "#thread"
+	// maxThreadDocChars bounds the text embedded for a thread, the start of a thread carries its topic.
+	maxThreadDocChars = 4000
+	// maxThreadTextOutput bounds the thread text returned in results.
+	maxThreadTextOutput = 500
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+type SemanticSearchMessage struct {
+	MsgID       string `json:"msgID"`
+	UserID      string `json:"userID"`
+	UserName    string `json:"userUser"`
+	RealName    string `json:"realName"`
+	Channel     string `json:"channelID"`
+	ChannelName string `json:"channelName"`
+	ThreadTs    string `json:"ThreadTs"`
+	Time        string `json:"time"`
+	Kind        string `json:"kind"`
+	Score       string `json:"score"`
+	Permalink   string `json:"permalink"`
+	Text        string `json:"text"`
+}
+
+type embeddingCacheEntry struct {
+	Hash   string
+	Vector []float32
+}
+
+// SemanticSearchHandler keeps a vector index of the local archive, one entry per message
+// and one per thread, and answers nearest-neighbour queries against it. Vectors are cached
+// on disk next to the archive so restarts only embed what changed.
+type SemanticSearchHandler struct {
+	apiProvider *provider.ApiProvider
+	logger      *zap.Logger
+	embedder    embeddings.Embedder
+	index       *search.VectorIndex
+	cachePath   string
+
+	// flushMu serializes calls to the embeddings API, mu guards pending and cache.
+	flushMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]search.Document
+	cache   map[string]embeddingCacheEntry
+}
+
+// NewSemanticSearchHandler starts indexing the archive in the background. The handler
+// is disabled, see Enabled, unless both the archive and an embeddings endpoint are configured.
+func NewSemanticSearchHandler(apiProvider *provider.ApiProvider, logger *zap.Logger) *SemanticSearchHandler {
+	h := &SemanticSearchHandler{
+		apiProvider: apiProvider,
+		logger:      logger,
+		index:       search.NewVectorIndex(),
+		pending:     make(map[string]search.Document),
+		cache:       make(map[string]embeddingCacheEntry),
+	}
+
+	archive := apiProvider.Archive()
+	embedder := embeddings.NewOpenAIFromEnv()
+	if archive == nil || embedder == nil {
+		return h
+	}
+	h.embedder = embedder
+	h.cachePath = filepath.Join(archive.Dir(), "embeddings-"+unsafeFileChars.ReplaceAllString(embedder.Model(), "_")+".gob")
+	h.loadCache()
+
+	seen := make(map[string]struct{})
+	for _, channelID := range archive.ChannelIDs() {
+		docs := archiveDocuments(channelID, archive.Messages(channelID), nil)
+		for id, doc := range docs {
+			seen[id] = struct{}{}
+			h.enqueue(id, doc)
+		}
+	}
+	h.mu.Lock()
+	for id := range h.cache {
+		if _, ok := seen[id]; !ok {
+			delete(h.cache, id)
+		}
+	}
+	h.mu.Unlock()
+
+	archive.OnSync(h.onSync)
+	go func() {
+		h.flush(context.Background())
+		logger.Info("Built semantic search index",
+			zap.String("model", embedder.Model()),
+			zap.Int("vectors", h.index.Len()))
+	}()
+	return h
+}
+
+// Enabled reports whether semantic search is configured.
+func (h *SemanticSearchHandler) Enabled() bool {
+	return h.embedder != nil
+}
+
+// onSync reindexes the changed messages and the threads they belong to.
+func (h *SemanticSearchHandler) onSync(channelID string, changed []provider.ArchivedMessage) {
+	if len(changed) == 0 {
+		return
+	}
+
+	threads := make(map[string]struct{})
+	for _, m := range changed {
+		id := channelID + "/" + m.Timestamp
+		if m.Deleted {
+			h.remove(id)
+		} else if doc, ok := messageDocument(channelID, m); ok {
+			h.enqueue(id, doc)
+		}
+		if m.ThreadTimestamp != "" {
+			threads[m.ThreadTimestamp] = struct{}{}
+		}
+	}
+
+	if len(threads) > 0 {
+		docs := archiveDocuments(channelID, h.apiProvider.Archive().Messages(channelID), threads)
+		for threadTs := range threads {
+			id := channelID + "/" + threadTs + threadDocSuffix
+			if doc, ok := docs[id]; ok {
+				h.enqueue(id, doc)
+			} else {
+				h.remove(id)
+			}
+		}
+	}
+
+	h.flush(context.Background())
+}
+
+// enqueue indexes doc right away when its vector is cached, otherwise it waits for the next flush.
+func (h *SemanticSearchHandler) enqueue(id string, doc search.Document) {
+	hash := textHash(doc.Text)
+	h.mu.Lock()
+	entry, cached := h.cache[id]
+	if cached && entry.Hash == hash {
+		delete(h.pending, id)
+		h.mu.Unlock()
+		h.index.Add(id, doc, entry.Vector)
+		return
+	}
+	h.pending[id] = doc
+	h.mu.Unlock()
+}
+
+func (h *SemanticSearchHandler) remove(id string) {
+	h.mu.Lock()
+	delete(h.pending, id)
+	delete(h.cache, id)
+	h.mu.Unlock()
+	h.index.Remove(id)
+}
+
+// flush embeds all pending documents. Documents that failed stay pending and are retried on the next sync.
+func (h *SemanticSearchHandler) flush(ctx context.Context) {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	h.mu.Lock()
+	ids := make([]string, 0, len(h.pending))
+	docs := make([]search.Document, 0, len(h.pending))
+	for id, doc := range h.pending {
+		ids = append(ids, id)
+		docs = append(docs, doc)
+	}
+	h.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Text
+	}
+	vectors, err := h.embedder.Embed(ctx, texts)
+	if err != nil {
+		h.logger.Error("Failed to embed messages, they will be retried on the next archive sync",
+			zap.Int("pending", len(ids)),
+			zap.Error(err))
+		return
+	}
+
+	h.mu.Lock()
+	for i, id := range ids {
+		hash := textHash(docs[i].Text)
+		// The document may have been edited or deleted while the request was in flight.
+		if current, ok := h.pending[id]; !ok || textHash(current.Text) != hash {
+			continue
+		}
+		delete(h.pending, id)
+		h.cache[id] = embeddingCacheEntry{Hash: hash, Vector: vectors[i]}
+		h.index.Add(id, docs[i], vectors[i])
+	}
+	h.mu.Unlock()
+
+	h.logger.Debug("Embedded archived messages", zap.Int("count", len(ids)))
+	h.saveCache()
+}
+
+func (h *SemanticSearchHandler) loadCache() {
+	data, err := provider.ReadCacheFile(h.cachePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			h.logger.Warn("Failed to open embeddings cache", zap.String("path", h.cachePath), zap.Error(err))
+		}
+		return
+	}
+
+	var cache map[string]embeddingCacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cache); err != nil {
+		h.logger.Warn("Failed to decode embeddings cache, messages will be re-embedded", zap.String("path", h.cachePath), zap.Error(err))
+		return
+	}
+	if cache == nil {
+		return
+	}
+	h.cache = cache
+	h.logger.Info("Loaded embeddings cache", zap.String("path", h.cachePath), zap.Int("vectors", len(cache)))
+}
+
+func (h *SemanticSearchHandler) saveCache() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(h.cache); err != nil {
+		h.logger.Error("Failed to encode embeddings cache", zap.String("path", h.cachePath), zap.Error(err))
+		return
+	}
+	if err := provider.WriteCacheFile(h.cachePath, buf.Bytes()); err != nil {
+		h.logger.Error("Failed to write embeddings cache", zap.String("path", h.cachePath), zap.Error(err))
+	}
+}
+
+func (h *SemanticSearchHandler) MessagesSemanticSearchHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	h.logger.Debug("MessagesSemanticSearchHandler called", zap.Any("params", request.Params))
+
+	query := strings.TrimSpace(request.GetString("query", ""))
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	limit := request.GetInt("limit", defaultSemanticSearchLimit)
+	if limit <= 0 || limit > maxSemanticSearchLimit {
+		return nil, fmt.Errorf("limit must be an integer between 1 and %d, got %d", maxSemanticSearchLimit, limit)
+	}
+
+	var filter func(string, *search.Document) bool
+	if allowed := readChannelFilter(ctx, h.apiProvider); allowed != nil {
+		filter = func(_ string, d *search.Document) bool {
+			return allowed(d.ChannelID)
+		}
+	}
+	if raw := request.GetString("channel_id", ""); raw != "" {
+		channelID, err := resolveArchivedChannel(h.apiProvider, raw)
+		if err != nil {
+			h.logger.Error("Failed to resolve channel", zap.String("channel", raw), zap.Error(err))
+			return nil, err
+		}
+		if err := checkReadChannel(ctx, h.apiProvider, channelID); err != nil {
+			return nil, err
+		}
+		filter = func(_ string, d *search.Document) bool {
+			return d.ChannelID == channelID
+		}
+	}
+
+	vectors, err := h.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		h.logger.Error("Failed to embed search query", zap.Error(err))
+		return nil, err
+	}
+	results := h.index.Nearest(vectors[0], limit, filter)
+	h.logger.Debug("Semantic search completed", zap.String("query", query), zap.Int("results", len(results)))
+
+	rows := h.toRows(results)
+	csvBytes, err := gocsv.MarshalBytes(&rows)
+	if err != nil {
+		h.logger.Error("Failed to marshal semantic search results to CSV", zap.Error(err))
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(csvBytes)), nil
+}
+
+func (h *SemanticSearchHandler) toRows(results []search.VectorResult) []SemanticSearchMessage {
+	users := h.apiProvider.ProvideUsersMap().Users
+	channels := h.apiProvider.ProvideChannelsMaps().Channels
+
+	teamURL := ""
+	if ar, err := h.apiProvider.Slack().AuthTest(); err == nil {
+		teamURL = ar.URL
+	} else {
+		h.logger.Warn("Failed to get workspace URL, permalinks are omitted", zap.Error(err))
+	}
+
+	rows := make([]SemanticSearchMessage, 0, len(results))
+	for _, r := range results {
+		doc := r.Document
+		userName, realName, _ := getUserInfo(doc.User, users)
+		timestamp, err := text.TimestampToIsoRFC3339(doc.Ts)
+		if err != nil {
+			h.logger.Error("Failed to convert timestamp to RFC3339", zap.Error(err))
+			continue
+		}
+
+		kind, msgText := "message", doc.Text
+		if strings.HasSuffix(r.ID, threadDocSuffix) {
+			kind = "thread"
+			if runes := []rune(msgText); len(runes) > maxThreadTextOutput {
+				msgText = string(runes[:maxThreadTextOutput]) + "…"
+			}
+		}
+
+		rows = append(rows, SemanticSearchMessage{
+			MsgID:       doc.Ts,
+			UserID:      doc.User,
+			UserName:    userName,
+			RealName:    realName,
+			Channel:     doc.ChannelID,
+			ChannelName: channels[doc.ChannelID].Name,
+			ThreadTs:    doc.ThreadTs,
+			Time:        timestamp,
+			Kind:        kind,
+			Score:       strconv.FormatFloat(r.Score, 'f', 3, 64),
+			Permalink:   permalink(teamURL, doc.ChannelID, doc.Ts, doc.ThreadTs),
+			Text:        text.ProcessText(msgText),
+		})
+	}
+	return rows
+}
+
+// archiveDocuments builds the index entries of a channel: one per live message with text
+// and one per thread with replies. With threads set, only those threads are built and
+// messages are skipped.
+func archiveDocuments(channelID string, msgs []provider.ArchivedMessage, threads map[string]struct{}) map[string]search.Document {
+	docs := make(map[string]search.Document)
+	byThread := make(map[string][]provider.ArchivedMessage)
+
+	for _, m := range msgs {
+		if m.Deleted {
+			continue
+		}
+		if threads == nil {
+			if doc, ok := messageDocument(channelID, m); ok {
+				docs[channelID+"/"+m.Timestamp] = doc
+			}
+		}
+		if m.ThreadTimestamp == "" {
+			continue
+		}
+		if _, ok := threads[m.ThreadTimestamp]; threads != nil && !ok {
+			continue
+		}
+		byThread[m.ThreadTimestamp] = append(byThread[m.ThreadTimestamp], m)
+	}
+
+	for threadTs, thread := range byThread {
+		if len(thread) < 2 {
+			continue
+		}
+		sort.Slice(thread, func(i, j int) bool {
+			return thread[i].Timestamp < thread[j].Timestamp
+		})
+
+		var sb strings.Builder
+		for _, m := range thread {
+			if sb.Len() >= maxThreadDocChars {
+				break
+			}
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(m.Text)
+		}
+		parent := thread[0]
+		docs[channelID+"/"+threadTs+threadDocSuffix] = search.Document{
+			ChannelID: channelID,
+			Ts:        threadTs,
+			ThreadTs:  threadTs,
+			User:      parent.User,
+			Text:      sb.String(),
+		}
+	}
+	return docs
+}
+
+func messageDocument(channelID string, m provider.ArchivedMessage) (search.Document, bool) {
+	if strings.TrimSpace(m.Text) == "" {
+		return search.Document{}, false
+	}
+	return search.Document{
+		ChannelID: channelID,
+		Ts:        m.Timestamp,
+		ThreadTs:  m.ThreadTimestamp,
+		User:      m.User,
+		Text:      m.Text,
+	}, true
+}
+
+// permalink builds the web URL of a message, replies link to their thread.
+func permalink(teamURL, channelID, ts, threadTs string) string {
+	if teamURL == "" {
+		return ""
+	}
+	link := strings.TrimSuffix(teamURL, "/") + "/archives/" + channelID + "/p" + strings.Replace(ts, ".", "", 1)
+	if threadTs != "" && threadTs != ts {
+		link += "?thread_ts=" + threadTs + "&cid=" + channelID
+	}
+	return link
+}
+
+func textHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func archivedMsg(ts, threadTs, user, text string, deleted bool) provider.ArchivedMessage {
+	m := provider.ArchivedMessage{Deleted: deleted}
+	m.Timestamp = ts
+	m.ThreadTimestamp = threadTs
+	m.User = user
+	m.Text = text
+	return m
+}
+
+func TestUnitArchiveDocuments(t *testing.T) {
+	msgs := []provider.ArchivedMessage{
+		archivedMsg("1.000002", "1.000001", "U2", "reply", false),
+		archivedMsg("1.000001", "1.000001", "U1", "parent", false),
+		archivedMsg("2.000001", "", "U1", "standalone", false),
+		archivedMsg("3.000001", "", "U1", "gone", true),
+		archivedMsg("4.000001", "", "U1", "  ", false),
+		archivedMsg("5.000001", "5.000001", "U3", "lonely parent", false),
+	}
+
+	docs := archiveDocuments("C1", msgs, nil)
+	assert.Len(t, docs, 5)
+	assert.Contains(t, docs, "C1/1.000001")
+	assert.Contains(t, docs, "C1/1.000002")
+	assert.Contains(t, docs, "C1/2.000001")
+	assert.Contains(t, docs, "C1/5.000001")
+	assert.NotContains(t, docs, "C1/5.000001"+threadDocSuffix, "threads without replies are not indexed separately")
+
+	thread, ok := docs["C1/1.000001"+threadDocSuffix]
+	require.True(t, ok)
+	assert.Equal(t, "parent\nreply", thread.Text)
+	assert.Equal(t, "U1", thread.User)
+	assert.Equal(t, "1.000001", thread.Ts)
+
+	docs = archiveDocuments("C1", msgs, map[string]struct{}{"1.000001": {}})
+	assert.Len(t, docs, 1)
+	assert.Contains(t, docs, "C1/1.000001"+threadDocSuffix)
+}
+
+func TestUnitPermalink(t *testing.T) {
+	assert.Equal(t, "", permalink("", "C1", "1700000001.000100", ""))
+	assert.Equal(t, "https://team.slack.com/archives/C1/p1700000001000100",
+		permalink("https://team.slack.com/", "C1", "1700000001.000100", ""))
+	assert.Equal(t, "https://team.slack.com/archives/C1/p1700000001000100",
+		permalink("https://team.slack.com/", "C1", "1700000001.000100", "1700000001.000100"))
+	assert.Equal(t, "https://team.slack.com/archives/C1/p1700000002000100?thread_ts=1700000001.000100&cid=C1",
+		permalink("https://team.slack.com/", "C1", "1700000002.000100", "1700000001.000100"))
+}
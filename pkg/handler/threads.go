@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	expandThreadsNone    = "none"
+	expandThreadsSummary = "summary"
+	expandThreadsAll     = "all"
+
+	defaultMaxThreadReplies = 20
+	// threadExpandConcurrency matches the burst of the Tier3 limiter used for conversations.replies.
+	threadExpandConcurrency = 4
+)
+
+type threadExpansion struct {
+	mode       string
+	maxReplies int
+}
+
+func parseThreadExpansion(request mcp.CallToolRequest) (*threadExpansion, error) {
+	mode := strings.ToLower(strings.TrimSpace(request.GetString("expand_threads", expandThreadsNone)))
+	switch mode {
+	case "", expandThreadsNone:
+		mode = expandThreadsNone
+	case expandThreadsSummary, expandThreadsAll:
+	default:
+		return nil, fmt.Errorf("invalid expand_threads value %q, expected one of: none, summary, all", mode)
+	}
+
+	maxReplies := request.GetInt("max_thread_replies", defaultMaxThreadReplies)
+	if maxReplies <= 0 {
+		return nil, fmt.Errorf("max_thread_replies must be a positive integer, got %d", maxReplies)
+	}
+
+	return &threadExpansion{mode: mode, maxReplies: maxReplies}, nil
+}
+
+// isThreadParent reports whether msg starts a thread that has replies.
+func isThreadParent(msg slack.Message) bool {
+	return msg.ReplyCount > 0 && msg.ThreadTimestamp == msg.Timestamp
+}
+
+// expandThreads inlines thread replies (or a one-row summary per thread) right after
+// their parent in messages. Inlined rows carry depth 1, parents keep depth 0.
+func (ch *ConversationsHandler) expandThreads(ctx context.Context, channel string, history []slack.Message, messages []Message, expansion *threadExpansion, includeActivity bool) ([]Message, error) {
+	if expansion == nil || expansion.mode == expandThreadsNone {
+		return messages, nil
+	}
+
+	parents := make(map[string]slack.Message)
+	for _, msg := range history {
+		if isThreadParent(msg) {
+			parents[msg.Timestamp] = msg
+		}
+	}
+	if len(parents) == 0 {
+		return messages, nil
+	}
+
+	var children map[string][]Message
+	switch expansion.mode {
+	case expandThreadsSummary:
+		children = ch.summarizeThreads(channel, parents)
+	case expandThreadsAll:
+		var err error
+		children, err = ch.fetchThreads(ctx, channel, parents, expansion.maxReplies, includeActivity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expanded := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		expanded = append(expanded, m)
+		expanded = append(expanded, children[m.MsgID]...)
+	}
+	return expanded, nil
+}
+
+// fetchThreads loads up to maxReplies replies of every parent, with bounded
+// parallelism and every request going through the history rate limiter.
+func (ch *ConversationsHandler) fetchThreads(ctx context.Context, channel string, parents map[string]slack.Message, maxReplies int, includeActivity bool) (map[string][]Message, error) {
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(threadExpandConcurrency)
+
+	threadTss := make([]string, 0, len(parents))
+	for ts := range parents {
+		threadTss = append(threadTss, ts)
+	}
+	results := make([][]slack.Message, len(threadTss))
+
+	for i, threadTs := range threadTss {
+		eg.Go(func() error {
+			if err := ch.historyLimiter.Wait(egCtx); err != nil {
+				return err
+			}
+			// The parent is returned as the first message, ask for one more to get maxReplies replies.
+			replies, _, _, err := ch.apiProvider.Slack().GetConversationRepliesContext(egCtx, &slack.GetConversationRepliesParameters{
+				ChannelID: channel,
+				Timestamp: threadTs,
+				Limit:     maxReplies + 1,
+			})
+			if err != nil {
+				ch.logger.Error("GetConversationRepliesContext failed", zap.String("thread_ts", threadTs), zap.Error(err))
+				return err
+			}
+			results[i] = replies
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]Message, len(threadTss))
+	for i, threadTs := range threadTss {
+		var replies []slack.Message
+		for _, r := range results[i] {
+			if r.Timestamp != threadTs {
+				replies = append(replies, r)
+			}
+		}
+		if len(replies) > maxReplies {
+			replies = replies[:maxReplies]
+		}
+
+		rows := ch.convertMessagesFromHistory(replies, channel, includeActivity)
+		for j := range rows {
+			rows[j].Depth = 1
+		}
+		children[threadTs] = rows
+	}
+	return children, nil
+}
+
+// summarizeThreads builds one synthetic row per thread from the parent metadata,
+// it costs no extra API calls.
+func (ch *ConversationsHandler) summarizeThreads(channel string, parents map[string]slack.Message) map[string][]Message {
+	usersMap := ch.apiProvider.ProvideUsersMap()
+	children := make(map[string][]Message, len(parents))
+
+	for ts, parent := range parents {
+		names := make([]string, 0, len(parent.ReplyUsers))
+		for _, id := range parent.ReplyUsers {
+			userName, _, _ := getUserInfo(id, usersMap.Users)
+			names = append(names, "@"+userName)
+		}
+
+		summary := fmt.Sprintf("%d replies", parent.ReplyCount)
+		if len(names) > 0 {
+			summary += " from " + strings.Join(names, ", ")
+		}
+
+		latest := ""
+		if parent.LatestReply != "" {
+			if t, err := text.TimestampToIsoRFC3339(parent.LatestReply); err == nil {
+				latest = t
+			}
+		}
+
+		children[ts] = []Message{{
+			MsgID:    parent.LatestReply,
+			Channel:  channel,
+			ThreadTs: ts,
+			Text:     summary,
+			Time:     latest,
+			Depth:    1,
+		}}
+	}
+	return children
+}
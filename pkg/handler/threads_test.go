@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitParseThreadExpansion(t *testing.T) {
+	e, err := parseThreadExpansion(toolRequest(map[string]any{}))
+	require.NoError(t, err)
+	assert.Equal(t, expandThreadsNone, e.mode)
+	assert.Equal(t, defaultMaxThreadReplies, e.maxReplies)
+
+	e, err = parseThreadExpansion(toolRequest(map[string]any{"expand_threads": "ALL", "max_thread_replies": 5}))
+	require.NoError(t, err)
+	assert.Equal(t, expandThreadsAll, e.mode)
+	assert.Equal(t, 5, e.maxReplies)
+
+	_, err = parseThreadExpansion(toolRequest(map[string]any{"expand_threads": "some"}))
+	assert.Error(t, err)
+
+	_, err = parseThreadExpansion(toolRequest(map[string]any{"expand_threads": "all", "max_thread_replies": 0}))
+	assert.Error(t, err)
+}
+
+func TestUnitIsThreadParent(t *testing.T) {
+	parent := slack.Message{Msg: slack.Msg{Timestamp: "1.1", ThreadTimestamp: "1.1", ReplyCount: 2}}
+	reply := slack.Message{Msg: slack.Msg{Timestamp: "1.2", ThreadTimestamp: "1.1"}}
+	plain := slack.Message{Msg: slack.Msg{Timestamp: "1.3"}}
+
+	assert.True(t, isThreadParent(parent))
+	assert.False(t, isThreadParent(reply))
+	assert.False(t, isThreadParent(plain))
+}
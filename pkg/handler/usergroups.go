@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"slices"
 	"strings"
 	"time"
 
@@ -104,31 +105,9 @@ func (h *UsergroupsHandler) UsergroupsCreateHandler(ctx context.Context, request
 		return nil, err
 	}
 
-	name := request.GetString("name", "")
-	if name == "" {
-		return nil, errors.New("name is required")
-	}
-
-	handle := request.GetString("handle", "")
-	description := request.GetString("description", "")
-	channelsStr := request.GetString("channels", "")
-
-	h.logger.Debug("Request parameters",
-		zap.String("name", name),
-		zap.String("handle", handle),
-		zap.String("description", description),
-		zap.String("channels", channelsStr),
-	)
-
-	userGroup := slack.UserGroup{
-		Name:        name,
-		Handle:      handle,
-		Description: description,
-	}
-
-	if channelsStr != "" {
-		channels := parseCommaSeparatedList(channelsStr)
-		userGroup.Prefs.Channels = channels
+	userGroup, err := h.parseParamsToolCreate(request)
+	if err != nil {
+		return nil, err
 	}
 
 	created, err := h.apiProvider.Slack().CreateUserGroupContext(ctx, userGroup)
@@ -168,6 +147,86 @@ func (h *UsergroupsHandler) UsergroupsUpdateHandler(ctx context.Context, request
 		return nil, err
 	}
 
+	params, err := h.parseParamsToolUpdate(request)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := h.apiProvider.Slack().UpdateUserGroupContext(ctx, params.usergroupID, params.options()...)
+	if err != nil {
+		h.logger.Error("UpdateUserGroupContext failed", zap.Error(err))
+		return nil, err
+	}
+
+	h.logger.Debug("Updated user group", zap.String("id", updated.ID), zap.String("name", updated.Name))
+
+	result := UserGroup{
+		ID:          updated.ID,
+		Name:        updated.Name,
+		Handle:      updated.Handle,
+		Description: updated.Description,
+		UserCount:   updated.UserCount,
+		IsExternal:  updated.IsExternal,
+		DateCreate:  formatJSONTime(updated.DateCreate),
+		DateUpdate:  formatJSONTime(updated.DateUpdate),
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		h.logger.Error("Failed to marshal updated user group to JSON", zap.Error(err))
+		return nil, err
+	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+func (h *UsergroupsHandler) parseParamsToolCreate(request mcp.CallToolRequest) (slack.UserGroup, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return slack.UserGroup{}, errors.New("name is required")
+	}
+
+	handle := request.GetString("handle", "")
+	description := request.GetString("description", "")
+	channelsStr := request.GetString("channels", "")
+
+	h.logger.Debug("Request parameters",
+		zap.String("name", name),
+		zap.String("handle", handle),
+		zap.String("description", description),
+		zap.String("channels", channelsStr),
+	)
+
+	if err := h.checkContent("usergroups_create", name, handle, description); err != nil {
+		return slack.UserGroup{}, err
+	}
+
+	userGroup := slack.UserGroup{
+		Name:        name,
+		Handle:      handle,
+		Description: description,
+	}
+
+	if channelsStr != "" {
+		channels := parseCommaSeparatedList(channelsStr)
+		if err := h.checkChannels("usergroups_create", channels); err != nil {
+			return slack.UserGroup{}, err
+		}
+		userGroup.Prefs.Channels = channels
+	}
+	return userGroup, nil
+}
+
+// usergroupUpdateParams are the fields usergroups_update changes, empty fields stay as they are.
+type usergroupUpdateParams struct {
+	usergroupID string
+	name        string
+	handle      string
+	description string
+	channels    []string
+}
+
+func (h *UsergroupsHandler) parseParamsToolUpdate(request mcp.CallToolRequest) (*usergroupUpdateParams, error) {
 	usergroupID := request.GetString("usergroup_id", "")
 	if usergroupID == "" {
 		return nil, errors.New("usergroup_id is required")
@@ -186,52 +245,91 @@ func (h *UsergroupsHandler) UsergroupsUpdateHandler(ctx context.Context, request
 		zap.String("channels", channelsStr),
 	)
 
-	var options []slack.UpdateUserGroupsOption
-
-	if name != "" {
-		options = append(options, slack.UpdateUserGroupsOptionName(name))
-	}
-	if handle != "" {
-		options = append(options, slack.UpdateUserGroupsOptionHandle(handle))
+	if err := h.checkContent("usergroups_update", name, handle, description); err != nil {
+		return nil, err
 	}
-	if description != "" {
-		options = append(options, slack.UpdateUserGroupsOptionDescription(&description))
+
+	params := &usergroupUpdateParams{
+		usergroupID: usergroupID,
+		name:        name,
+		handle:      handle,
+		description: description,
 	}
 	if channelsStr != "" {
-		channels := parseCommaSeparatedList(channelsStr)
-		options = append(options, slack.UpdateUserGroupsOptionChannels(channels))
+		params.channels = parseCommaSeparatedList(channelsStr)
+		if err := h.checkChannels("usergroups_update", params.channels); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(options) == 0 {
+	if len(params.options()) == 0 {
 		return nil, errors.New("at least one update field (name, handle, description, or channels) is required")
 	}
+	return params, nil
+}
 
-	updated, err := h.apiProvider.Slack().UpdateUserGroupContext(ctx, usergroupID, options...)
-	if err != nil {
-		h.logger.Error("UpdateUserGroupContext failed", zap.Error(err))
-		return nil, err
+func (p *usergroupUpdateParams) options() []slack.UpdateUserGroupsOption {
+	var options []slack.UpdateUserGroupsOption
+
+	if p.name != "" {
+		options = append(options, slack.UpdateUserGroupsOptionName(p.name))
 	}
+	if p.handle != "" {
+		options = append(options, slack.UpdateUserGroupsOptionHandle(p.handle))
+	}
+	if p.description != "" {
+		options = append(options, slack.UpdateUserGroupsOptionDescription(&p.description))
+	}
+	if p.channels != nil {
+		options = append(options, slack.UpdateUserGroupsOptionChannels(p.channels))
+	}
+	return options
+}
 
-	h.logger.Debug("Updated user group", zap.String("id", updated.ID), zap.String("name", updated.Name))
+func (h *UsergroupsHandler) parseParamsToolUsersUpdate(request mcp.CallToolRequest) (usergroupID, users string, err error) {
+	usergroupID = request.GetString("usergroup_id", "")
+	if usergroupID == "" {
+		return "", "", errors.New("usergroup_id is required")
+	}
 
-	result := UserGroup{
-		ID:          updated.ID,
-		Name:        updated.Name,
-		Handle:      updated.Handle,
-		Description: updated.Description,
-		UserCount:   updated.UserCount,
-		IsExternal:  updated.IsExternal,
-		DateCreate:  formatJSONTime(updated.DateCreate),
-		DateUpdate:  formatJSONTime(updated.DateUpdate),
+	users = request.GetString("users", "")
+	if users == "" {
+		return "", "", errors.New("users is required")
 	}
 
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		h.logger.Error("Failed to marshal updated user group to JSON", zap.Error(err))
-		return nil, err
+	h.logger.Debug("Request parameters",
+		zap.String("usergroup_id", usergroupID),
+		zap.String("users", users),
+	)
+	return usergroupID, users, nil
+}
+
+// checkContent returns an error if the tool policy does not allow tool to set the name,
+// handle or description of a user group to the given values.
+func (h *UsergroupsHandler) checkContent(tool, name, handle, description string) error {
+	for _, field := range []struct{ name, value string }{
+		{"name", name},
+		{"handle", handle},
+		{"description", description},
+	} {
+		if err := checkToolContent(tool, field.name, field.value); err != nil {
+			h.logger.Warn("User group field not allowed", zap.String("field", field.name), zap.Error(err))
+			return err
+		}
 	}
+	return nil
+}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+// checkChannels returns an error if the tool policy does not allow tool in one of the
+// default channels of a user group.
+func (h *UsergroupsHandler) checkChannels(tool string, channels []string) error {
+	for _, channelID := range channels {
+		if err := checkToolPolicy(h.apiProvider, tool, channelID); err != nil {
+			h.logger.Warn("User group channel not allowed", zap.String("channel", channelID), zap.Error(err))
+			return err
+		}
+	}
+	return nil
 }
 
 // UsergroupsUsersUpdateHandler updates the members of a user group
@@ -243,21 +341,11 @@ func (h *UsergroupsHandler) UsergroupsUsersUpdateHandler(ctx context.Context, re
 		return nil, err
 	}
 
-	usergroupID := request.GetString("usergroup_id", "")
-	if usergroupID == "" {
-		return nil, errors.New("usergroup_id is required")
-	}
-
-	usersStr := request.GetString("users", "")
-	if usersStr == "" {
-		return nil, errors.New("users is required")
+	usergroupID, usersStr, err := h.parseParamsToolUsersUpdate(request)
+	if err != nil {
+		return nil, err
 	}
 
-	h.logger.Debug("Request parameters",
-		zap.String("usergroup_id", usergroupID),
-		zap.String("users", usersStr),
-	)
-
 	// UpdateUserGroupMembersContext expects a comma-separated string of user IDs
 	updated, err := h.apiProvider.Slack().UpdateUserGroupMembersContext(ctx, usergroupID, usersStr)
 	if err != nil {
@@ -320,58 +408,17 @@ func (h *UsergroupsHandler) UsergroupsMeHandler(ctx context.Context, request mcp
 		return h.handleListMyGroups(ctx, currentUserID)
 	}
 
-	// For join/leave, usergroup_id is required
-	usergroupID := request.GetString("usergroup_id", "")
-	if usergroupID == "" {
-		return nil, errors.New("usergroup_id is required for join/leave actions")
-	}
-
-	h.logger.Debug("Request parameters",
-		zap.String("usergroup_id", usergroupID),
-		zap.String("action", action),
-	)
-
-	// Get current members of the group
-	members, err := h.apiProvider.Slack().GetUserGroupMembersContext(ctx, usergroupID)
+	change, err := h.membershipChange(ctx, request, action, currentUserID)
 	if err != nil {
-		h.logger.Error("GetUserGroupMembersContext failed", zap.Error(err))
 		return nil, err
 	}
-
-	h.logger.Debug("Current group members", zap.Int("count", len(members)), zap.Strings("members", members))
-
-	// Check current membership
-	isMember := false
-	memberIndex := -1
-	for i, uid := range members {
-		if uid == currentUserID {
-			isMember = true
-			memberIndex = i
-			break
-		}
-	}
-
-	var newMembers []string
-	var resultMessage string
-
-	if action == "join" {
-		if isMember {
-			return mcp.NewToolResultText("You are already a member of this user group."), nil
-		}
-		newMembers = append(members, currentUserID)
-		resultMessage = "Successfully joined the user group."
-	} else { // leave
-		if !isMember {
-			return mcp.NewToolResultText("You are not a member of this user group."), nil
-		}
-		// Remove current user from members
-		newMembers = append(members[:memberIndex], members[memberIndex+1:]...)
-		resultMessage = "Successfully left the user group."
+	if !change.changed {
+		return mcp.NewToolResultText(change.message), nil
 	}
 
 	// Update the group members
-	membersStr := strings.Join(newMembers, ",")
-	updated, err := h.apiProvider.Slack().UpdateUserGroupMembersContext(ctx, usergroupID, membersStr)
+	membersStr := strings.Join(change.members, ",")
+	updated, err := h.apiProvider.Slack().UpdateUserGroupMembersContext(ctx, change.usergroupID, membersStr)
 	if err != nil {
 		h.logger.Error("UpdateUserGroupMembersContext failed", zap.Error(err))
 		return nil, err
@@ -389,7 +436,7 @@ func (h *UsergroupsHandler) UsergroupsMeHandler(ctx context.Context, request mcp
 		GroupName string `json:"group_name"`
 		UserCount int    `json:"user_count"`
 	}{
-		Message:   resultMessage,
+		Message:   change.message,
 		GroupID:   updated.ID,
 		GroupName: updated.Name,
 		UserCount: updated.UserCount,
@@ -404,6 +451,57 @@ func (h *UsergroupsHandler) UsergroupsMeHandler(ctx context.Context, request mcp
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// membership is the change usergroups_me makes to the members of a group.
+type membership struct {
+	usergroupID string
+	// members of the group before and after the change
+	current []string
+	members []string
+	message string
+	// changed is false if the user already is, or is not, a member
+	changed bool
+}
+
+// membershipChange returns how joining or leaving the group of the request changes its
+// members.
+func (h *UsergroupsHandler) membershipChange(ctx context.Context, request mcp.CallToolRequest, action, currentUserID string) (*membership, error) {
+	// For join/leave, usergroup_id is required
+	usergroupID := request.GetString("usergroup_id", "")
+	if usergroupID == "" {
+		return nil, errors.New("usergroup_id is required for join/leave actions")
+	}
+
+	h.logger.Debug("Request parameters",
+		zap.String("usergroup_id", usergroupID),
+		zap.String("action", action),
+	)
+
+	// Get current members of the group
+	members, err := h.apiProvider.Slack().GetUserGroupMembersContext(ctx, usergroupID)
+	if err != nil {
+		h.logger.Error("GetUserGroupMembersContext failed", zap.Error(err))
+		return nil, err
+	}
+
+	h.logger.Debug("Current group members", zap.Int("count", len(members)), zap.Strings("members", members))
+
+	m := &membership{usergroupID: usergroupID, current: members, members: members}
+	i := slices.Index(members, currentUserID)
+	switch {
+	case action == "join" && i >= 0:
+		m.message = "You are already a member of this user group."
+	case action == "join":
+		m.members = append(slices.Clone(members), currentUserID)
+		m.message, m.changed = "Successfully joined the user group.", true
+	case i < 0:
+		m.message = "You are not a member of this user group."
+	default:
+		m.members = slices.Delete(slices.Clone(members), i, i+1)
+		m.message, m.changed = "Successfully left the user group.", true
+	}
+	return m, nil
+}
+
 // handleListMyGroups returns groups where the current user is a member
 func (h *UsergroupsHandler) handleListMyGroups(ctx context.Context, currentUserID string) (*mcp.CallToolResult, error) {
 	options := []slack.GetUserGroupsOption{
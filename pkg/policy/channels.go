@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Channel types as in provider.AllChanTypes, and "shared" for Slack Connect channels and DMs.
+var channelTypes = []string{"public_channel", "private_channel", "im", "mpim", "shared"}
+
+// channelTypeAliases are shorter names of channel types.
+var channelTypeAliases = map[string]string{
+	"public":  "public_channel",
+	"private": "private_channel",
+}
+
+// Channel is the channel a tool call targets.
+type Channel struct {
+	ID string
+	// #name, or @user for DMs, empty if the channel is not in the channels cache
+	Name string
+	// public_channel, private_channel, im or mpim, empty if the channel is not in the cache
+	Type string
+	// Shared is set for Slack Connect channels and DMs
+	Shared bool
+}
+
+// ChannelList is a list of channels by ID, #name or @user glob (e.g. "#eng-*"), type (e.g.
+// "type:im", "type:private" or "type:shared" for Slack Connect) or "*". Entries starting with "!" deny, other entries allow. A channel is allowed if no
+// deny entry matches it and, if there are allow entries, one of them does.
+type ChannelList struct {
+	allow []string
+	deny  []string
+}
+
+// ParseChannelList parses the entries of a channel list, see ChannelList.
+func ParseChannelList(items []string) (ChannelList, error) {
+	var l ChannelList
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, negated := strings.CutPrefix(item, "!")
+		if t, ok := strings.CutPrefix(pattern, "type:"); ok {
+			if alias, ok := channelTypeAliases[t]; ok {
+				pattern = "type:" + alias
+			}
+		}
+		if err := validChannelPattern(pattern); err != nil {
+			return ChannelList{}, err
+		}
+		if negated {
+			l.deny = append(l.deny, pattern)
+		} else {
+			l.allow = append(l.allow, pattern)
+		}
+	}
+	return l, nil
+}
+
+func validChannelPattern(pattern string) error {
+	if t, ok := strings.CutPrefix(pattern, "type:"); ok {
+		if !slices.Contains(channelTypes, t) {
+			return fmt.Errorf("unknown channel type %q, expected one of %s", t, strings.Join(channelTypes, ", "))
+		}
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid channel pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// Empty reports whether the list allows every channel.
+func (l ChannelList) Empty() bool {
+	return len(l.allow) == 0 && len(l.deny) == 0
+}
+
+// Allows reports whether ch is allowed. A channel whose name is unknown is denied if the
+// list denies channels by name or type, as they cannot be checked.
+func (l ChannelList) Allows(ch Channel) bool {
+	if ch.Name == "" && l.deniesByName() {
+		return false
+	}
+	for _, pattern := range l.deny {
+		if matchChannel(pattern, ch) {
+			return false
+		}
+	}
+	if len(l.allow) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(l.allow, func(pattern string) bool {
+		return matchChannel(pattern, ch)
+	})
+}
+
+func (l ChannelList) deniesByName() bool {
+	return slices.ContainsFunc(l.deny, needsName)
+}
+
+// DeniesShared reports whether the list denies Slack Connect channels and DMs as such.
+func (l ChannelList) DeniesShared() bool {
+	return slices.Contains(l.deny, "type:shared")
+}
+
+func needsName(pattern string) bool {
+	return strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "@") || strings.HasPrefix(pattern, "type:")
+}
+
+func matchChannel(pattern string, ch Channel) bool {
+	if t, ok := strings.CutPrefix(pattern, "type:"); ok {
+		if t == "shared" {
+			return ch.Shared
+		}
+		return ch.Type == t
+	}
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "@") {
+		ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(ch.Name))
+		return ok
+	}
+	ok, _ := path.Match(pattern, ch.ID)
+	return ok
+}
+
+// String returns the list in the format it was parsed from.
+func (l ChannelList) String() string {
+	items := slices.Clone(l.allow)
+	for _, pattern := range l.deny {
+		items = append(items, "!"+pattern)
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,205 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+)
+
+// DefaultMaxLength is the maximum length of text sent to Slack without max_length, the
+// length Slack truncates messages to.
+const DefaultMaxLength = 40000
+
+// broadcasts are the mentions that notify everyone in a channel.
+var broadcasts = []string{"channel", "here", "everyone"}
+
+// broadcastRe matches broadcasts in Slack's <!here> format and as plain @here, which Slack
+// may turn into one.
+var broadcastRe = regexp.MustCompile(`(?i)<!(channel|here|everyone)(?:\|[^>]*)?>|(?:^|[^\w@<])@(channel|here|everyone)\b`)
+
+// contentEntry is the content section of a rule of SLACK_MCP_POLICY_FILE, what the text a
+// tool sends to Slack may contain.
+type contentEntry struct {
+	MaxLength    *int     `yaml:"max_length"`
+	Broadcasts   []string `yaml:"broadcasts"`
+	Links        []string `yaml:"links"`
+	AllowSecrets *bool    `yaml:"allow_secrets"`
+}
+
+// content checks the text a tool sends to Slack. Unset fields are nil.
+type content struct {
+	maxLength *int
+	// broadcasts that are allowed, "*" for all
+	broadcasts []string
+	// domain globs that links may point to, "*" for all
+	links        []string
+	allowSecrets *bool
+}
+
+func (e *contentEntry) parse() (content, error) {
+	c := content{
+		maxLength:    e.MaxLength,
+		allowSecrets: e.AllowSecrets,
+	}
+	if c.maxLength != nil && *c.maxLength < 1 {
+		return c, fmt.Errorf("invalid content max_length %d, expected a positive number", *c.maxLength)
+	}
+	var err error
+	if e.Broadcasts != nil {
+		if c.broadcasts, err = parseBroadcasts(e.Broadcasts); err != nil {
+			return c, fmt.Errorf("invalid content broadcasts: %w", err)
+		}
+	}
+	if e.Links != nil {
+		if c.links, err = parseLinks(e.Links); err != nil {
+			return c, fmt.Errorf("invalid content links: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *content) inherit(def content) {
+	if c.maxLength == nil {
+		c.maxLength = def.maxLength
+	}
+	if c.broadcasts == nil {
+		c.broadcasts = def.broadcasts
+	}
+	if c.links == nil {
+		c.links = def.links
+	}
+	if c.allowSecrets == nil {
+		c.allowSecrets = def.allowSecrets
+	}
+}
+
+func parseBroadcasts(items []string) ([]string, error) {
+	allowed := []string{}
+	for _, b := range items {
+		b = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(b)), "@")
+		if b == "" {
+			continue
+		}
+		if b != "*" && !slices.Contains(broadcasts, b) {
+			return nil, fmt.Errorf("unknown broadcast %q, expected channel, here, everyone or *", b)
+		}
+		allowed = append(allowed, b)
+	}
+	return allowed, nil
+}
+
+// parseLinks parses domain globs, without "www." like the domains of text.LinkDomains.
+func parseLinks(items []string) ([]string, error) {
+	allowed := []string{}
+	for _, d := range items {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+		if d == "" {
+			continue
+		}
+		if _, err := path.Match(d, ""); err != nil {
+			return nil, fmt.Errorf("invalid domain pattern %q: %w", d, err)
+		}
+		allowed = append(allowed, d)
+	}
+	return allowed, nil
+}
+
+// contentFromEnv returns the content checks of the environment:
+//   - SLACK_MCP_MESSAGE_MAX_LENGTH is the maximum length of text.
+//   - SLACK_MCP_MESSAGE_BROADCASTS allows broadcasts, true for all or a list like "here".
+//   - SLACK_MCP_MESSAGE_LINKS lists the domains links may point to.
+//   - SLACK_MCP_MESSAGE_ALLOW_SECRETS set to true allows text that looks like secrets.
+func contentFromEnv() (content, error) {
+	var c content
+	if v := os.Getenv("SLACK_MCP_MESSAGE_MAX_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return c, fmt.Errorf("invalid SLACK_MCP_MESSAGE_MAX_LENGTH %q, expected a positive number", v)
+		}
+		c.maxLength = &n
+	}
+	if v := os.Getenv("SLACK_MCP_MESSAGE_BROADCASTS"); v != "" {
+		items := strings.Split(v, ",")
+		if v == "true" || v == "1" || v == "yes" {
+			items = []string{"*"}
+		}
+		var err error
+		if c.broadcasts, err = parseBroadcasts(items); err != nil {
+			return c, fmt.Errorf("error in SLACK_MCP_MESSAGE_BROADCASTS: %w", err)
+		}
+	}
+	if v := os.Getenv("SLACK_MCP_MESSAGE_LINKS"); v != "" {
+		var err error
+		if c.links, err = parseLinks(strings.Split(v, ",")); err != nil {
+			return c, fmt.Errorf("error in SLACK_MCP_MESSAGE_LINKS: %w", err)
+		}
+	}
+	if v := os.Getenv("SLACK_MCP_MESSAGE_ALLOW_SECRETS"); v != "" {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid SLACK_MCP_MESSAGE_ALLOW_SECRETS %q, expected true or false", v)
+		}
+		c.allowSecrets = &allow
+	}
+	return c, nil
+}
+
+// CheckContent returns an error if tool may not send s to Slack as its field, e.g. the text
+// of a message. The error explains what to change, for the LLM to try again: s may be at
+// most max_length characters long, may only link to the allowed domains, and may neither
+// broadcast to a channel nor contain secrets unless the rule allows it.
+func (p *Policy) CheckContent(tool, field, s string) error {
+	c := p.rule(tool).content
+
+	maxLength := DefaultMaxLength
+	if c.maxLength != nil {
+		maxLength = *c.maxLength
+	}
+	if n := utf8.RuneCountInString(s); n > maxLength {
+		return fmt.Errorf("%w: the %s of %s is %d characters long, %s allows at most %d, shorten it or split it into several calls",
+			ErrDenied, field, tool, n, p.source, maxLength)
+	}
+
+	if c.allowSecrets == nil || !*c.allowSecrets {
+		if secrets := text.FindSecrets(s); len(secrets) > 0 {
+			return fmt.Errorf("%w: the %s of %s contains what looks like a credential (%s), remove it, credentials must not be posted to Slack",
+				ErrDenied, field, tool, strings.Join(secrets, ", "))
+		}
+	}
+
+	if !slices.Contains(c.broadcasts, "*") {
+		for _, m := range broadcastRe.FindAllStringSubmatch(s, -1) {
+			b := strings.ToLower(m[1] + m[2])
+			if !slices.Contains(c.broadcasts, b) {
+				return fmt.Errorf("%w: the %s of %s mentions @%s, which notifies everyone in the channel and is not allowed by %s, remove the mention or mention people by name",
+					ErrDenied, field, tool, b, p.source)
+			}
+		}
+	}
+
+	if c.links != nil && !slices.Contains(c.links, "*") {
+		for _, d := range text.LinkDomains(s) {
+			if !matchesDomain(c.links, d) {
+				return fmt.Errorf("%w: the %s of %s links to %s, %s only allows links to %s, remove the link",
+					ErrDenied, field, tool, d, p.source, strings.Join(c.links, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func matchesDomain(patterns []string, domain string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, domain); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// FromEnv returns the policy of the environment variables that predate
+// SLACK_MCP_POLICY_FILE:
+//   - SLACK_MCP_ADD_MESSAGE_TOOL and SLACK_MCP_REACTION_TOOL enable their tools in every
+//     channel with true or 1, or in a list of channels, e.g. "C1234567890,D0987654321", or
+//     in all channels but a list of channels, e.g. "!C1234567890".
+//   - SLACK_MCP_ATTACHMENT_TOOL enables attachment_get_data with true, 1 or yes.
+//   - SLACK_MCP_ENABLED_TOOLS enables the tools it lists in every channel.
+//   - SLACK_MCP_ADD_MESSAGE_UNFURLING sets which links posted messages may unfurl.
+//   - SLACK_MCP_READ_CHANNELS is the channel list of channels that may be read.
+//   - SLACK_MCP_MESSAGE_* check the text every tool sends to Slack, see contentFromEnv.
+//
+// Tools that do not need to be enabled are always allowed, the server only offers those of
+// SLACK_MCP_ENABLED_TOOLS if it is set.
+func FromEnv() (*Policy, error) {
+	var enabledTools []string
+	for _, tool := range strings.Split(os.Getenv("SLACK_MCP_ENABLED_TOOLS"), ",") {
+		if tool = strings.TrimSpace(tool); tool != "" {
+			enabledTools = append(enabledTools, tool)
+		}
+	}
+
+	p := &Policy{
+		source: "environment",
+		rules:  map[string]*rule{},
+	}
+
+	addMessage, err := channelsFromEnv("SLACK_MCP_ADD_MESSAGE_TOOL", []string{"conversations_add_message"}, enabledTools,
+		"by default, the conversations_add_message tool is disabled to guard Slack workspaces against accidental spamming. "+
+			"To enable it, set the SLACK_MCP_ADD_MESSAGE_TOOL environment variable to true, 1, or comma separated list of channels "+
+			"to limit where the MCP can post messages, e.g. 'SLACK_MCP_ADD_MESSAGE_TOOL=C1234567890,D0987654321', 'SLACK_MCP_ADD_MESSAGE_TOOL=!C1234567890' "+
+			"to enable all except one or 'SLACK_MCP_ADD_MESSAGE_TOOL=true' for all channels and DMs",
+	)
+	if err != nil {
+		return nil, err
+	}
+	unfurl := os.Getenv("SLACK_MCP_ADD_MESSAGE_UNFURLING")
+	addMessage.unfurl = &unfurl
+	p.rules["conversations_add_message"] = addMessage
+
+	reactions, err := channelsFromEnv("SLACK_MCP_REACTION_TOOL", []string{"reactions_add", "reactions_remove"}, enabledTools,
+		"by default, the reactions tools are disabled to guard Slack workspaces against accidental spamming. "+
+			"To enable them, set the SLACK_MCP_REACTION_TOOL environment variable to true, 1, or comma separated list of channels "+
+			"to limit where the MCP can manage reactions, e.g. 'SLACK_MCP_REACTION_TOOL=C1234567890,D0987654321', 'SLACK_MCP_REACTION_TOOL=!C1234567890' "+
+			"to enable all except one or 'SLACK_MCP_REACTION_TOOL=true' for all channels and DMs",
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.rules["reactions_add"] = reactions
+	p.rules["reactions_remove"] = reactions
+
+	attachment := &rule{}
+	switch config := os.Getenv("SLACK_MCP_ATTACHMENT_TOOL"); {
+	case config == "true" || config == "1" || config == "yes":
+	case config == "" && slices.Contains(enabledTools, "attachment_get_data"):
+	case config == "":
+		attachment.enabled = new(bool)
+		attachment.disabled = "by default, the attachment_get_data tool is disabled. " +
+			"To enable it, set the SLACK_MCP_ATTACHMENT_TOOL environment variable to true or 1"
+	default:
+		attachment.enabled = new(bool)
+		attachment.disabled = "SLACK_MCP_ATTACHMENT_TOOL must be set to 'true', '1', or 'yes' to enable"
+	}
+	p.rules["attachment_get_data"] = attachment
+
+	if p.read, err = ParseChannelList(strings.Split(os.Getenv("SLACK_MCP_READ_CHANNELS"), ",")); err != nil {
+		return nil, fmt.Errorf("error in SLACK_MCP_READ_CHANNELS: %w", err)
+	}
+
+	content, err := contentFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	p.rules[DefaultRule] = &rule{}
+	for _, r := range p.rules {
+		r.content = content
+	}
+	return p, nil
+}
+
+// channelsFromEnv returns the rule of tools enabled by envVar.
+func channelsFromEnv(envVar string, tools, enabledTools []string, disabled string) (*rule, error) {
+	r := &rule{}
+	config := os.Getenv(envVar)
+	if config == "" {
+		if !slices.ContainsFunc(tools, func(tool string) bool { return slices.Contains(enabledTools, tool) }) {
+			r.enabled = new(bool)
+			r.disabled = disabled
+		}
+		return r, nil
+	}
+	if config == "true" || config == "1" {
+		return r, nil
+	}
+
+	items := strings.Split(config, ",")
+	hasNegated, hasPositive := false, false
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "!") {
+			hasNegated = true
+		} else {
+			hasPositive = true
+		}
+	}
+	if hasNegated && hasPositive {
+		return nil, fmt.Errorf("error in %s: cannot mix allowed and disallowed (! prefixed) channels", envVar)
+	}
+
+	var err error
+	if r.channels, err = ParseChannelList(items); err != nil {
+		return nil, fmt.Errorf("error in %s: %w", envVar, err)
+	}
+	return r, nil
+}
@@ -0,0 +1,195 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// scheduleEntry is the time window of a rule of SLACK_MCP_POLICY_FILE.
+type scheduleEntry struct {
+	// Days like "mon" or ranges like "mon-fri", every day if empty
+	Days []string `yaml:"days"`
+	// Hours like "09:00-18:00", the whole day if empty. Windows may span midnight.
+	Hours string `yaml:"hours"`
+	// IANA time zone like "Europe/Berlin", the server's local time if empty
+	Timezone string `yaml:"timezone"`
+}
+
+type schedule struct {
+	days     [7]bool
+	from, to int // minutes since midnight
+	location *time.Location
+	text     string
+}
+
+func (e *scheduleEntry) parse() (*schedule, error) {
+	s := &schedule{location: time.Local}
+	if e.Timezone != "" {
+		loc, err := time.LoadLocation(e.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule timezone: %w", err)
+		}
+		s.location = loc
+	}
+
+	if len(e.Days) == 0 {
+		s.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, d := range e.Days {
+		first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(d)), "-")
+		if !isRange {
+			last = first
+		}
+		i, j := weekday(first), weekday(last)
+		if i < 0 || j < 0 {
+			return nil, fmt.Errorf("invalid schedule day %q, expected e.g. mon or mon-fri", d)
+		}
+		for ; ; i = (i + 1) % 7 {
+			s.days[i] = true
+			if i == j {
+				break
+			}
+		}
+	}
+
+	s.from, s.to = 0, 24*60
+	if e.Hours != "" {
+		from, to, ok := strings.Cut(e.Hours, "-")
+		var err1, err2 error
+		s.from, err1 = parseClock(from)
+		s.to, err2 = parseClock(to)
+		if !ok || err1 != nil || err2 != nil || s.from == s.to {
+			return nil, fmt.Errorf("invalid schedule hours %q, expected e.g. 09:00-18:00", e.Hours)
+		}
+	}
+
+	days := "every day"
+	if len(e.Days) > 0 {
+		days = "on " + strings.Join(e.Days, ", ")
+	}
+	hours := ""
+	if e.Hours != "" {
+		hours = " " + strings.TrimSpace(e.Hours)
+	}
+	s.text = fmt.Sprintf("%s%s (%s)", days, hours, s.location)
+	return s, nil
+}
+
+func weekday(s string) int {
+	for i, d := range weekdays {
+		if s == d {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		if strings.TrimSpace(s) == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// allows reports whether t is within the schedule. A window spanning midnight belongs to
+// the day it starts on.
+func (s *schedule) allows(t time.Time) bool {
+	t = t.In(s.location)
+	minute := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+	if s.from < s.to {
+		return s.days[day] && minute >= s.from && minute < s.to
+	}
+	if minute >= s.from {
+		return s.days[day]
+	}
+	return minute < s.to && s.days[(day+6)%7]
+}
+
+func (s *schedule) String() string {
+	return s.text
+}
+
+// rate caps the calls of a tool per caller to count per window.
+type rate struct {
+	count  int
+	window time.Duration
+	text   string
+}
+
+// parseRate parses rates like "20/h", "100/d" or "5/10m".
+func parseRate(s string) (*rate, error) {
+	count, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid rate %q, expected e.g. 20/h", s)
+	}
+	r := &rate{count: n}
+	unit := window
+	switch window {
+	case "s":
+		r.window, unit = time.Second, "second"
+	case "m":
+		r.window, unit = time.Minute, "minute"
+	case "h":
+		r.window, unit = time.Hour, "hour"
+	case "d":
+		r.window, unit = 24*time.Hour, "day"
+	default:
+		if strings.HasSuffix(window, "d") {
+			days, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate %q, expected e.g. 20/h", s)
+			}
+			window = strconv.Itoa(days*24) + "h"
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid rate %q, expected e.g. 20/h", s)
+		}
+		r.window = d
+	}
+	r.text = fmt.Sprintf("%d times per %s", n, unit)
+	return r, nil
+}
+
+func (r *rate) String() string {
+	return r.text
+}
+
+// rateCounter counts calls in a sliding window. It outlives reloads of the policy file.
+type rateCounter struct {
+	mu    sync.Mutex
+	calls map[string][]time.Time
+}
+
+var rates = &rateCounter{calls: map[string][]time.Time{}}
+
+// take counts a call of key at now if r allows it, otherwise it returns how long until it
+// will.
+func (c *rateCounter) take(key string, r *rate, now time.Time) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := c.calls[key]
+	start := 0
+	for start < len(calls) && now.Sub(calls[start]) >= r.window {
+		start++
+	}
+	calls = calls[start:]
+	if len(calls) >= r.count {
+		c.calls[key] = calls
+		return r.window - now.Sub(calls[len(calls)-r.count]), false
+	}
+	c.calls[key] = append(calls, now)
+	return 0, true
+}
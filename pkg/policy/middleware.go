@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// BuildMiddleware enforces whether a tool is enabled and the users, schedule and rate of
+// the policy of every tool call. Channels are checked by the handlers once they resolved
+// the channel of the call. Calls isDryRun reports do not count towards the rate, they send
+// nothing to Slack. It must run after the authentication middleware, which sets the API
+// key of the call, and with token pass-through after the middleware setting the Slack
+// identity of callers sending their own token.
+func BuildMiddleware(isDryRun func(mcp.CallToolRequest) bool, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			p, err := Current()
+			if err != nil {
+				logger.Error("Failed to load tool policy", zap.String("tool", req.Params.Name), zap.Error(err))
+				return nil, err
+			}
+
+			caller := ""
+			if name, ok := auth.SlackCallerFromContext(ctx); ok {
+				caller = name
+			} else if principal, ok := auth.PrincipalFromContext(ctx); ok {
+				caller = principal.Name
+			}
+			err = p.CheckEnabled(req.Params.Name)
+			if err == nil {
+				if isDryRun != nil && isDryRun(req) {
+					err = p.CheckDryRun(req.Params.Name, caller)
+				} else {
+					err = p.CheckCall(req.Params.Name, caller)
+				}
+			}
+			if err != nil {
+				logger.Warn("Tool call denied by policy",
+					zap.String("tool", req.Params.Name),
+					zap.String("key", caller),
+					zap.Error(err),
+				)
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+// Package policy decides which tools may be called, by whom, when, how often and in which
+// channels, what they may send to Slack, and which channels may be read. The policy comes
+// from SLACK_MCP_POLICY_FILE, or without it from the SLACK_MCP_*_TOOL environment
+// variables it replaces.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrDenied is wrapped by every error returned for a call the policy does not allow.
+var ErrDenied = errors.New("denied by policy")
+
+// DefaultRule is the name of the rule applied to tools without a rule of their own.
+const DefaultRule = "*"
+
+// optInTools change Slack or download files, they are disabled unless a rule of their
+// own enables them.
+var optInTools = []string{"conversations_add_message", "reactions_add", "reactions_remove", "attachment_get_data"}
+
+// Policy is the tool policy the server enforces, see Current.
+type Policy struct {
+	// SLACK_MCP_POLICY_FILE, or "environment" for the compatibility layer
+	source   string
+	fromFile bool
+	rules    map[string]*rule
+	// read are the channels whose messages, files and details tools and resources may return
+	read ChannelList
+}
+
+// readEntry is the read section of SLACK_MCP_POLICY_FILE.
+type readEntry struct {
+	Channels []string `yaml:"channels"`
+}
+
+// ruleEntry is a rule of SLACK_MCP_POLICY_FILE.
+type ruleEntry struct {
+	Enabled  *bool          `yaml:"enabled"`
+	Channels []string       `yaml:"channels"`
+	Users    []string       `yaml:"users"`
+	Schedule *scheduleEntry `yaml:"schedule"`
+	Rate     string         `yaml:"rate"`
+	Unfurl   []string       `yaml:"unfurl"`
+	Content  *contentEntry  `yaml:"content"`
+}
+
+type rule struct {
+	enabled *bool
+	// disabled explains why the tool is disabled, with the compatibility layer the
+	// environment variable that enables it
+	disabled string
+	channels ChannelList
+	users    []string
+	schedule *schedule
+	rate     *rate
+	unfurl   *string
+	content  content
+}
+
+// Current returns the policy of SLACK_MCP_POLICY_FILE, reading the file again if it changed,
+// or the policy of the environment without it. A file that became invalid denies every
+// call until it is fixed, rather than keeping an outdated policy.
+func Current() (*Policy, error) {
+	path := os.Getenv("SLACK_MCP_POLICY_FILE")
+	if path == "" {
+		return FromEnv()
+	}
+	filesMu.Lock()
+	f, ok := files[path]
+	if !ok {
+		f = &policyFile{path: path}
+		files[path] = f
+	}
+	filesMu.Unlock()
+	return f.current()
+}
+
+// policyFile is SLACK_MCP_POLICY_FILE, reloaded when it changes.
+type policyFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	policy  *Policy
+	err     error
+}
+
+var (
+	filesMu sync.Mutex
+	files   = map[string]*policyFile{}
+)
+
+func (f *policyFile) current() (*Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLACK_MCP_POLICY_FILE: %w", err)
+	}
+	if f.policy != nil || f.err != nil {
+		if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+			return f.policy, f.err
+		}
+	}
+
+	f.modTime, f.size = info.ModTime(), info.Size()
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		f.policy, f.err = nil, fmt.Errorf("failed to read SLACK_MCP_POLICY_FILE: %w", err)
+		return nil, f.err
+	}
+	f.policy, f.err = Parse(data)
+	if f.err != nil {
+		f.err = fmt.Errorf("invalid SLACK_MCP_POLICY_FILE: %w", f.err)
+	}
+	return f.policy, f.err
+}
+
+// Parse parses a policy file, in YAML or JSON.
+func Parse(data []byte) (*Policy, error) {
+	var file struct {
+		Tools map[string]*ruleEntry `yaml:"tools"`
+		Read  *readEntry            `yaml:"read"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	if len(file.Tools) == 0 && file.Read == nil {
+		return nil, errors.New("no tools")
+	}
+
+	p := &Policy{
+		source:   "SLACK_MCP_POLICY_FILE",
+		fromFile: true,
+		rules:    make(map[string]*rule, len(file.Tools)),
+	}
+	if file.Read != nil {
+		var err error
+		if p.read, err = ParseChannelList(file.Read.Channels); err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+	}
+	for name, e := range file.Tools {
+		if e == nil {
+			e = &ruleEntry{}
+		}
+		r, err := e.parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		p.rules[name] = r
+	}
+
+	// Tool rules inherit what they leave unset from the default rule
+	if def, ok := p.rules[DefaultRule]; ok {
+		for name, r := range p.rules {
+			if name != DefaultRule {
+				r.inherit(def)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (e *ruleEntry) parse() (*rule, error) {
+	r := &rule{enabled: e.Enabled}
+	var err error
+	if r.channels, err = ParseChannelList(e.Channels); err != nil {
+		return nil, err
+	}
+	for _, u := range e.Users {
+		if _, err := path.Match(strings.TrimPrefix(u, "!"), ""); err != nil {
+			return nil, fmt.Errorf("invalid user pattern %q: %w", u, err)
+		}
+	}
+	r.users = e.Users
+	if e.Schedule != nil {
+		if r.schedule, err = e.Schedule.parse(); err != nil {
+			return nil, err
+		}
+	}
+	if e.Rate != "" {
+		if r.rate, err = parseRate(e.Rate); err != nil {
+			return nil, err
+		}
+	}
+	if e.Unfurl != nil {
+		unfurl := strings.Join(e.Unfurl, ",")
+		if slices.Contains(e.Unfurl, "*") {
+			unfurl = "true"
+		}
+		r.unfurl = &unfurl
+	}
+	if e.Content != nil {
+		if r.content, err = e.Content.parse(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *rule) inherit(def *rule) {
+	if r.channels.Empty() {
+		r.channels = def.channels
+	}
+	if len(r.users) == 0 {
+		r.users = def.users
+	}
+	if r.schedule == nil {
+		r.schedule = def.schedule
+	}
+	if r.rate == nil {
+		r.rate = def.rate
+	}
+	if r.unfurl == nil {
+		r.unfurl = def.unfurl
+	}
+	r.content.inherit(def.content)
+}
+
+// Source names where the policy comes from, for error messages.
+func (p *Policy) Source() string {
+	return p.source
+}
+
+// FromFile reports whether the policy comes from SLACK_MCP_POLICY_FILE.
+func (p *Policy) FromFile() bool {
+	return p.fromFile
+}
+
+// Tools returns the names of the tools with a rule of their own, sorted.
+func (p *Policy) Tools() []string {
+	var names []string
+	for name := range p.rules {
+		if name != DefaultRule {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rule returns the rule of tool, the default rule if it has none.
+func (p *Policy) rule(tool string) *rule {
+	if r, ok := p.rules[tool]; ok {
+		return r
+	}
+	if r, ok := p.rules[DefaultRule]; ok {
+		return r
+	}
+	return &rule{}
+}
+
+// Enabled reports whether tool may be offered. A tool with a rule of its own is enabled
+// unless the rule disables it, other tools follow the default rule. Tools that change Slack
+// or download files need a rule of their own.
+func (p *Policy) Enabled(tool string) bool {
+	if r, ok := p.rules[tool]; ok {
+		return r.enabled == nil || *r.enabled
+	}
+	if slices.Contains(optInTools, tool) {
+		return false
+	}
+	r := p.rule(tool)
+	return r.enabled == nil || *r.enabled
+}
+
+// CheckEnabled returns an error if tool is disabled.
+func (p *Policy) CheckEnabled(tool string) error {
+	if p.Enabled(tool) {
+		return nil
+	}
+	if r, ok := p.rules[tool]; ok && r.disabled != "" {
+		return fmt.Errorf("%w: %s", ErrDenied, r.disabled)
+	}
+	return fmt.Errorf("%w: %s is disabled by %s", ErrDenied, tool, p.source)
+}
+
+// CheckCall returns an error if caller may not call tool now: when the caller is not one
+// of the rule's users, outside the rule's schedule, or when the rule's rate is exceeded.
+// The caller is the Slack identity of a caller sending its own token with token
+// pass-through, like "slack:T0123:U0456", otherwise the name of the API key of the call,
+// empty without either.
+// Allowed calls count towards the rate.
+func (p *Policy) CheckCall(tool, caller string) error {
+	return p.checkCall(tool, caller, time.Now())
+}
+
+// CheckDryRun returns an error like CheckCall if caller may not call tool now, without
+// counting the call towards the rate, for dry runs that send nothing to Slack.
+func (p *Policy) CheckDryRun(tool, caller string) error {
+	return p.checkCaller(tool, caller, time.Now())
+}
+
+func (p *Policy) checkCall(tool, caller string, now time.Time) error {
+	if err := p.checkCaller(tool, caller, now); err != nil {
+		return err
+	}
+	if r := p.rule(tool); r.rate != nil {
+		if retry, ok := rates.take(tool+"\x00"+caller, r.rate, now); !ok {
+			return fmt.Errorf("%w: %s may be called at most %s, retry in %s", ErrDenied, tool, r.rate, retry.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// checkCaller checks the users and schedule of the rule of tool.
+func (p *Policy) checkCaller(tool, caller string, now time.Time) error {
+	r := p.rule(tool)
+	if !allowsUser(r.users, caller) {
+		if caller == "" {
+			return fmt.Errorf("%w: %s needs an API key allowed by %s", ErrDenied, tool, p.source)
+		}
+		if strings.HasPrefix(caller, "slack:") {
+			return fmt.Errorf("%w: Slack user %q may not call %s, see %s", ErrDenied, caller, tool, p.source)
+		}
+		return fmt.Errorf("%w: API key %q may not call %s, see %s", ErrDenied, caller, tool, p.source)
+	}
+	if r.schedule != nil && !r.schedule.allows(now) {
+		return fmt.Errorf("%w: %s may only be called %s", ErrDenied, tool, r.schedule)
+	}
+	return nil
+}
+
+// CheckChannel returns an error if tool may not be used in ch.
+func (p *Policy) CheckChannel(tool string, ch Channel) error {
+	r := p.rule(tool)
+	if r.channels.Allows(ch) {
+		return nil
+	}
+	name := ch.ID
+	if ch.Name != "" {
+		name = fmt.Sprintf("%s (%s)", ch.Name, ch.ID)
+	}
+	if ch.Name == "" && r.channels.deniesByName() {
+		return fmt.Errorf("%w: %s is not allowed in channel %s, it is not in the channels cache so %s cannot be applied to it", ErrDenied, tool, name, p.source)
+	}
+	return fmt.Errorf("%w: %s is not allowed in channel %s, applied policy: %s", ErrDenied, tool, name, r.channels)
+}
+
+// ReadChannels returns the channels that may be read.
+func (p *Policy) ReadChannels() ChannelList {
+	return p.read
+}
+
+// CheckRead returns an error if messages, files and details of ch may not be read.
+func (p *Policy) CheckRead(ch Channel) error {
+	if p.read.Allows(ch) {
+		return nil
+	}
+	name := ch.ID
+	if ch.Name != "" {
+		name = fmt.Sprintf("%s (%s)", ch.Name, ch.ID)
+	}
+	if ch.Name == "" && p.read.deniesByName() {
+		return fmt.Errorf("%w: channel %s may not be read, it is not in the channels cache so %s cannot be applied to it", ErrDenied, name, p.source)
+	}
+	return fmt.Errorf("%w: channel %s may not be read, applied policy: %s", ErrDenied, name, p.read)
+}
+
+// Unfurl returns which links messages posted by tool may unfurl, in the format of
+// SLACK_MCP_ADD_MESSAGE_UNFURLING.
+func (p *Policy) Unfurl(tool string) string {
+	if u := p.rule(tool).unfurl; u != nil {
+		return *u
+	}
+	return ""
+}
+
+// allowsUser reports whether caller matches the user list, which works like ChannelList
+// with API key names.
+func allowsUser(users []string, caller string) bool {
+	hasAllow, allowed := false, false
+	for _, u := range users {
+		pattern, negated := strings.CutPrefix(strings.TrimSpace(u), "!")
+		ok, _ := path.Match(pattern, caller)
+		ok = ok && caller != ""
+		if negated {
+			if ok {
+				return false
+			}
+			continue
+		}
+		hasAllow = true
+		allowed = allowed || ok
+	}
+	return !hasAllow || allowed
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -46,13 +47,18 @@ func getCacheDir() string {
 	}
 
 	dir := filepath.Join(cacheDir, "slack-mcp-server")
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		// Fallback to current directory if we can't create cache dir
 		return "."
 	}
 	return dir
 }
 
+// CacheDir returns the directory slack-mcp-server keeps its caches and other local data in.
+func CacheDir() string {
+	return getCacheDir()
+}
+
 // getCacheTTL returns the cache TTL from SLACK_MCP_CACHE_TTL env var or default (1 hour).
 // Supports formats: "1h", "30m", "3600" (seconds), "0" (disable TTL, cache forever)
 // Negative values are rejected and fall back to default.
@@ -113,7 +119,7 @@ func getMinRefreshInterval() time.Duration {
 // validateAuthAndGetTeamID performs auth validation on startup and returns the TeamID.
 // This ensures tokens are valid before proceeding and enables cache namespacing
 // to prevent cache contamination when using multiple Slack workspaces.
-// Returns an error if authentication fails - the server should not start with invalid credentials.
+// Returns an error if authentication fails, the caller then starts in degraded mode.
 func validateAuthAndGetTeamID(authProvider auth.Provider, logger *zap.Logger) (string, error) {
 	xoxpToken := os.Getenv("SLACK_MCP_XOXP_TOKEN")
 	xoxcToken := os.Getenv("SLACK_MCP_XOXC_TOKEN")
@@ -162,6 +168,14 @@ type ChannelsCache struct {
 	ChannelsInv map[string]string  `json:"channels_inv"`
 }
 
+type UsergroupsCache struct {
+	Usergroups map[string]slack.UserGroup `json:"usergroups"`
+}
+
+type EmojiCache struct {
+	Emoji map[string]string `json:"emoji"`
+}
+
 type Channel struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name"`
@@ -171,8 +185,9 @@ type Channel struct {
 	IsMpIM      bool     `json:"mpim"`
 	IsIM        bool     `json:"im"`
 	IsPrivate   bool     `json:"private"`
-	User        string   `json:"user,omitempty"`    // User ID for IM channels
-	Members     []string `json:"members,omitempty"` // Member IDs for the channel
+	User        string   `json:"user,omitempty"`       // User ID for IM channels
+	Members     []string `json:"members,omitempty"`    // Member IDs for the channel
+	IsExtShared bool     `json:"ext_shared,omitempty"` // Slack Connect channel or DM
 }
 
 type SlackAPI interface {
@@ -180,11 +195,12 @@ type SlackAPI interface {
 	AuthTest() (*slack.AuthTestResponse, error)
 	AuthTestContext(ctx context.Context) (*slack.AuthTestResponse, error)
 	GetUsersContext(ctx context.Context, options ...slack.GetUsersOption) ([]slack.User, error)
-	GetUsersInfo(users ...string) (*[]slack.User, error)
+	GetUsersInfoContext(ctx context.Context, users ...string) (*[]slack.User, error)
 	PostMessageContext(ctx context.Context, channel string, options ...slack.MsgOption) (string, string, error)
 	MarkConversationContext(ctx context.Context, channel, ts string) error
 	AddReactionContext(ctx context.Context, name string, item slack.ItemRef) error
 	RemoveReactionContext(ctx context.Context, name string, item slack.ItemRef) error
+	GetEmojiContext(ctx context.Context) (map[string]string, error)
 
 	// Used to get messages
 	GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error)
@@ -221,6 +237,10 @@ type MCPSlackClient struct {
 	isOAuth      bool
 	isBotToken   bool
 	teamEndpoint string
+
+	// generalID is the ID of the general channel, see GetMemberCountContext
+	generalMu sync.Mutex
+	generalID string
 }
 
 type ApiProvider struct {
@@ -228,10 +248,18 @@ type ApiProvider struct {
 	client    SlackAPI
 	logger    *zap.Logger
 
+	// Name in SLACK_MCP_WORKSPACES, empty when serving a single workspace
+	name string
+	// All workspaces served, set on the default one only, see Workspaces
+	workspaces []*ApiProvider
+
 	rateLimiter        *rate.Limiter
 	cacheTTL           time.Duration
 	minRefreshInterval time.Duration
 
+	// How often delta refreshes of the users and channels caches fall back to a full refresh
+	fullRefreshInterval time.Duration
+
 	// Users cache: atomic pointer to immutable snapshot (no copy on read)
 	usersSnapshot atomic.Pointer[UsersCache]
 	usersCachePath string
@@ -245,6 +273,16 @@ type ApiProvider struct {
 	channelsReady     bool
 	lastForcedChannelsRefresh time.Time
 	channelsMu                sync.RWMutex // protects channelsReady, lastForcedChannelsRefresh
+
+	// User groups and emoji caches: in-memory only
+	usergroupsSnapshot atomic.Pointer[UsergroupsCache]
+	emojiSnapshot      atomic.Pointer[EmojiCache]
+
+	// Optional local message archive, nil unless SLACK_MCP_ARCHIVE_CHANNELS is set
+	archive *Archive
+
+	// Outcome of the last refreshes of each cache, see CacheStatuses
+	refreshStatus refreshStatus
 }
 
 func NewMCPSlackClient(authProvider auth.Provider, logger *zap.Logger) (*MCPSlackClient, error) {
@@ -332,8 +370,8 @@ func (c *MCPSlackClient) GetUsersContext(ctx context.Context, options ...slack.G
 	return c.slackClient.GetUsersContext(ctx, options...)
 }
 
-func (c *MCPSlackClient) GetUsersInfo(users ...string) (*[]slack.User, error) {
-	return c.slackClient.GetUsersInfo(users...)
+func (c *MCPSlackClient) GetUsersInfoContext(ctx context.Context, users ...string) (*[]slack.User, error) {
+	return c.slackClient.GetUsersInfoContext(ctx, users...)
 }
 
 func (c *MCPSlackClient) MarkConversationContext(ctx context.Context, channel, ts string) error {
@@ -421,6 +459,10 @@ func (c *MCPSlackClient) RemoveReactionContext(ctx context.Context, name string,
 	return c.slackClient.RemoveReactionContext(ctx, name, item)
 }
 
+func (c *MCPSlackClient) GetEmojiContext(ctx context.Context) (map[string]string, error) {
+	return c.slackClient.GetEmojiContext(ctx)
+}
+
 func (c *MCPSlackClient) GetFileInfoContext(ctx context.Context, fileID string, count, page int) (*slack.File, []slack.Comment, *slack.Paging, error) {
 	return c.slackClient.GetFileInfoContext(ctx, fileID, count, page)
 }
@@ -487,21 +529,51 @@ func (c *MCPSlackClient) Raw() struct {
 }
 
 func New(transport string, logger *zap.Logger) *ApiProvider {
+	// Caches are read and written from the start, a bad key must not surface as failed writes later
+	if err := ValidateCacheKey(); err != nil {
+		logger.Fatal("Invalid cache encryption key", zap.Error(err))
+	}
+
+	workspaces, err := getWorkspaces()
+	if err != nil {
+		logger.Fatal("Invalid SLACK_MCP_WORKSPACES", zap.String("context", "console"), zap.Error(err))
+	}
+	if len(workspaces) > 0 && os.Getenv("SLACK_MCP_OFFLINE_SOURCE") != "" {
+		logger.Warn("SLACK_MCP_OFFLINE_SOURCE is set, SLACK_MCP_WORKSPACES is ignored",
+			zap.String("context", "console"),
+		)
+		workspaces = nil
+	}
+	if len(workspaces) > 0 {
+		return newWorkspaces(transport, workspaces, logger)
+	}
+
+	ap := newProvider(transport, singleWorkspace, logger)
+	ap.migrateCaches()
+	return ap
+}
+
+func newProvider(transport string, ws workspaceConfig, logger *zap.Logger) *ApiProvider {
 	var (
 		authProvider auth.ValueAuth
 		err          error
 	)
 
+	// An offline data source replaces the Slack API entirely, no token is needed
+	if offlineSource := os.Getenv("SLACK_MCP_OFFLINE_SOURCE"); offlineSource != "" {
+		return newOffline(transport, offlineSource, logger)
+	}
+
 	// Read all environment variables
-	xoxpToken := os.Getenv("SLACK_MCP_XOXP_TOKEN")
-	xoxbToken := os.Getenv("SLACK_MCP_XOXB_TOKEN")
-	xoxcToken := os.Getenv("SLACK_MCP_XOXC_TOKEN")
-	xoxdToken := os.Getenv("SLACK_MCP_XOXD_TOKEN")
+	xoxpToken := ws.getenv("XOXP_TOKEN")
+	xoxbToken := ws.getenv("XOXB_TOKEN")
+	xoxcToken := ws.getenv("XOXC_TOKEN")
+	xoxdToken := ws.getenv("XOXD_TOKEN")
 
 	// Warn if both user and bot tokens are set
 	if xoxpToken != "" && xoxbToken != "" {
 		logger.Warn(
-			"Both SLACK_MCP_XOXP_TOKEN and SLACK_MCP_XOXB_TOKEN are set. "+
+			"Both "+ws.env("XOXP_TOKEN")+" and "+ws.env("XOXB_TOKEN")+" are set. "+
 				"Using User token (xoxp) for full features. "+
 				"Bot token will be ignored.",
 			zap.String("context", "console"),
@@ -515,7 +587,7 @@ func New(transport string, logger *zap.Logger) *ApiProvider {
 			logger.Fatal("Failed to create auth provider with XOXP token", zap.Error(err))
 		}
 
-		return newWithXOXP(transport, authProvider, logger)
+		return newWithXOXP(transport, ws, authProvider, logger)
 	}
 
 	// Priority 2: XOXB token (Bot)
@@ -530,12 +602,12 @@ func New(transport string, logger *zap.Logger) *ApiProvider {
 			zap.String("token_type", "xoxb"),
 		)
 
-		return newWithXOXB(transport, authProvider, logger)
+		return newWithXOXB(transport, ws, authProvider, logger)
 	}
 
 	// Priority 3: XOXC/XOXD tokens (session-based)
 	if xoxcToken == "" || xoxdToken == "" {
-		logger.Fatal("Authentication required: Either SLACK_MCP_XOXP_TOKEN, SLACK_MCP_XOXB_TOKEN, or both SLACK_MCP_XOXC_TOKEN and SLACK_MCP_XOXD_TOKEN must be provided")
+		logger.Fatal("Authentication required: Either " + ws.env("XOXP_TOKEN") + ", " + ws.env("XOXB_TOKEN") + ", or both " + ws.env("XOXC_TOKEN") + " and " + ws.env("XOXD_TOKEN") + " must be provided")
 	}
 
 	authProvider, err = auth.NewValueAuth(xoxcToken, xoxdToken)
@@ -543,47 +615,115 @@ func New(transport string, logger *zap.Logger) *ApiProvider {
 		logger.Fatal("Failed to create auth provider with XOXC/XOXD tokens", zap.Error(err))
 	}
 
-	return newWithXOXC(transport, authProvider, logger)
+	return newWithXOXC(transport, ws, authProvider, logger)
 }
 
-func newWithXOXP(transport string, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
+func newWithXOXP(transport string, ws workspaceConfig, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
 	var (
 		client *MCPSlackClient
 		err    error
 	)
 
-	teamID, err := validateAuthAndGetTeamID(authProvider, logger)
-	if err != nil {
-		logger.Fatal("Authentication failed - check your Slack tokens", zap.Error(err))
+	// An unreachable Slack is not fatal: cached data is served and the client reconnects later
+	teamID, authErr := validateAuthAndGetTeamID(authProvider, logger)
+	if authErr != nil {
+		if known := knownWorkspace(authProvider.SlackToken()); known != nil {
+			teamID = known.TeamID
+		}
+		logger.Error("Slack is unreachable, starting in degraded mode",
+			zap.String("context", "console"),
+			zap.String("team_id", teamID),
+			zap.Error(authErr))
 	}
 
-	usersCache := os.Getenv("SLACK_MCP_USERS_CACHE")
+	usersCache := ws.getenv("USERS_CACHE")
 	if usersCache == "" {
-		usersCache = getCachePathWithTeamID(teamID, "users_cache.json")
+		usersCache = ws.cachePath(teamID, "users_cache.json")
 	}
 
-	channelsCache := os.Getenv("SLACK_MCP_CHANNELS_CACHE")
+	channelsCache := ws.getenv("CHANNELS_CACHE")
 	if channelsCache == "" {
-		channelsCache = getCachePathWithTeamID(teamID, "channels_cache_v2.json")
+		channelsCache = ws.cachePath(teamID, "channels_cache_v2.json")
 	}
 
 	if os.Getenv("SLACK_MCP_XOXP_TOKEN") == "demo" || (os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo") {
 		logger.Info("Demo credentials are set, skip.")
-	} else {
+	} else if authErr == nil {
 		client, err = NewMCPSlackClient(authProvider, logger)
 		if err != nil {
-			logger.Fatal("Failed to create MCP Slack client", zap.Error(err))
+			authErr = err
+			logger.Error("Failed to create MCP Slack client, starting in degraded mode", zap.Error(err))
+		} else {
+			rememberWorkspace(authProvider.SlackToken(), client.authResponse)
 		}
 	}
 
+	var api SlackAPI = client
+	if authErr != nil {
+		api = newReconnectingClient(authProvider, authErr, logger)
+	}
+
+	ap := &ApiProvider{
+		transport: transport,
+		client:    api,
+		logger:    logger,
+		name:      ws.name,
+
+		rateLimiter:         limiter.Tier2.Limiter(),
+		cacheTTL:            getCacheTTL(),
+		minRefreshInterval:  getMinRefreshInterval(),
+		fullRefreshInterval: getFullRefreshInterval(),
+
+		usersCachePath:    usersCache,
+		channelsCachePath: channelsCache,
+	}
+	// Initialize with empty snapshots
+	ap.usersSnapshot.Store(&UsersCache{
+		Users:    make(map[string]slack.User),
+		UsersInv: make(map[string]string),
+	})
+	ap.channelsSnapshot.Store(&ChannelsCache{
+		Channels:    make(map[string]Channel),
+		ChannelsInv: make(map[string]string),
+	})
+	ap.usergroupsSnapshot.Store(&UsergroupsCache{
+		Usergroups: make(map[string]slack.UserGroup),
+	})
+	ap.emojiSnapshot.Store(&EmojiCache{
+		Emoji: make(map[string]string),
+	})
+	if client != nil || authErr != nil {
+		ap.archive = newArchiveFromEnv(ws, teamID, api, ap.resolveArchiveChannel, logger)
+	}
+	return ap
+}
+
+func newOffline(transport string, path string, logger *zap.Logger) *ApiProvider {
+	client, err := LoadOffline(context.Background(), path, logger)
+	if err != nil {
+		logger.Fatal("Failed to load offline data source", zap.Error(err))
+	}
+	teamID := client.teamID(path)
+
+	usersCache := os.Getenv("SLACK_MCP_USERS_CACHE")
+	if usersCache == "" {
+		usersCache = getCachePathWithTeamID(teamID, "users_cache.json")
+	}
+
+	channelsCache := os.Getenv("SLACK_MCP_CHANNELS_CACHE")
+	if channelsCache == "" {
+		channelsCache = getCachePathWithTeamID(teamID, "channels_cache_v2.json")
+	}
+
 	ap := &ApiProvider{
 		transport: transport,
 		client:    client,
 		logger:    logger,
 
-		rateLimiter:        limiter.Tier2.Limiter(),
-		cacheTTL:           getCacheTTL(),
-		minRefreshInterval: getMinRefreshInterval(),
+		rateLimiter:         limiter.Tier2.Limiter(),
+		cacheTTL:            getCacheTTL(),
+		minRefreshInterval:  getMinRefreshInterval(),
+		fullRefreshInterval: getFullRefreshInterval(),
 
 		usersCachePath:    usersCache,
 		channelsCachePath: channelsCache,
@@ -597,53 +737,77 @@ func newWithXOXP(transport string, authProvider auth.ValueAuth, logger *zap.Logg
 		Channels:    make(map[string]Channel),
 		ChannelsInv: make(map[string]string),
 	})
+	ap.usergroupsSnapshot.Store(&UsergroupsCache{
+		Usergroups: make(map[string]slack.UserGroup),
+	})
+	ap.emojiSnapshot.Store(&EmojiCache{
+		Emoji: make(map[string]string),
+	})
+	ap.archive = newOfflineArchive(teamID, client, logger)
 	return ap
 }
 
-func newWithXOXB(transport string, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
+func newWithXOXB(transport string, ws workspaceConfig, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
 	// Bot tokens do not support demo mode, but otherwise share the same
 	// initialization logic as user OAuth tokens.
-	return newWithXOXP(transport, authProvider, logger)
+	return newWithXOXP(transport, ws, authProvider, logger)
 }
 
-func newWithXOXC(transport string, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
+func newWithXOXC(transport string, ws workspaceConfig, authProvider auth.ValueAuth, logger *zap.Logger) *ApiProvider {
 	var (
 		client *MCPSlackClient
 		err    error
 	)
 
-	teamID, err := validateAuthAndGetTeamID(authProvider, logger)
-	if err != nil {
-		logger.Fatal("Authentication failed - check your Slack tokens", zap.Error(err))
+	// An unreachable Slack is not fatal: cached data is served and the client reconnects later
+	teamID, authErr := validateAuthAndGetTeamID(authProvider, logger)
+	if authErr != nil {
+		if known := knownWorkspace(authProvider.SlackToken()); known != nil {
+			teamID = known.TeamID
+		}
+		logger.Error("Slack is unreachable, starting in degraded mode",
+			zap.String("context", "console"),
+			zap.String("team_id", teamID),
+			zap.Error(authErr))
 	}
 
-	usersCache := os.Getenv("SLACK_MCP_USERS_CACHE")
+	usersCache := ws.getenv("USERS_CACHE")
 	if usersCache == "" {
-		usersCache = getCachePathWithTeamID(teamID, "users_cache.json")
+		usersCache = ws.cachePath(teamID, "users_cache.json")
 	}
 
-	channelsCache := os.Getenv("SLACK_MCP_CHANNELS_CACHE")
+	channelsCache := ws.getenv("CHANNELS_CACHE")
 	if channelsCache == "" {
-		channelsCache = getCachePathWithTeamID(teamID, "channels_cache_v2.json")
+		channelsCache = ws.cachePath(teamID, "channels_cache_v2.json")
 	}
 
 	if os.Getenv("SLACK_MCP_XOXP_TOKEN") == "demo" || (os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo") {
 		logger.Info("Demo credentials are set, skip.")
-	} else {
+	} else if authErr == nil {
 		client, err = NewMCPSlackClient(authProvider, logger)
 		if err != nil {
-			logger.Fatal("Failed to create MCP Slack client", zap.Error(err))
+			authErr = err
+			logger.Error("Failed to create MCP Slack client, starting in degraded mode", zap.Error(err))
+		} else {
+			rememberWorkspace(authProvider.SlackToken(), client.authResponse)
 		}
 	}
 
+	var api SlackAPI = client
+	if authErr != nil {
+		api = newReconnectingClient(authProvider, authErr, logger)
+	}
+
 	ap := &ApiProvider{
 		transport: transport,
-		client:    client,
+		client:    api,
 		logger:    logger,
+		name:      ws.name,
 
-		rateLimiter:        limiter.Tier2.Limiter(),
-		cacheTTL:           getCacheTTL(),
-		minRefreshInterval: getMinRefreshInterval(),
+		rateLimiter:         limiter.Tier2.Limiter(),
+		cacheTTL:            getCacheTTL(),
+		minRefreshInterval:  getMinRefreshInterval(),
+		fullRefreshInterval: getFullRefreshInterval(),
 
 		usersCachePath:    usersCache,
 		channelsCachePath: channelsCache,
@@ -657,11 +821,22 @@ func newWithXOXC(transport string, authProvider auth.ValueAuth, logger *zap.Logg
 		Channels:    make(map[string]Channel),
 		ChannelsInv: make(map[string]string),
 	})
+	ap.usergroupsSnapshot.Store(&UsergroupsCache{
+		Usergroups: make(map[string]slack.UserGroup),
+	})
+	ap.emojiSnapshot.Store(&EmojiCache{
+		Emoji: make(map[string]string),
+	})
+	if client != nil || authErr != nil {
+		ap.archive = newArchiveFromEnv(ws, teamID, api, ap.resolveArchiveChannel, logger)
+	}
 	return ap
 }
 
 func (ap *ApiProvider) RefreshUsers(ctx context.Context) error {
-	return ap.refreshUsersInternal(ctx, false)
+	err := ap.refreshUsersInternal(ctx, false)
+	ap.recordRefresh(CacheUsers, err)
+	return err
 }
 
 // ForceRefreshUsers bypasses the cache and fetches fresh user data from Slack API.
@@ -685,21 +860,41 @@ func (ap *ApiProvider) ForceRefreshUsers(ctx context.Context) error {
 	}
 
 	ap.logger.Info("Force refreshing users cache")
-	return ap.refreshUsersInternal(ctx, true)
+	err := ap.refreshUsersInternal(ctx, true)
+	ap.recordRefresh(CacheUsers, err)
+	return err
 }
 
 func (ap *ApiProvider) refreshUsersInternal(ctx context.Context, force bool) error {
 	ap.usersMu.Lock()
 	defer ap.usersMu.Unlock()
 
+	// Other server processes on the same workspace share the cache file, only one refreshes it
+	requested := time.Now()
+	unlock, err := ap.lockCache(ctx, ap.usersCachePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// A peer that refreshed the cache while we waited for the lock saves us the fetch
+	if force && modifiedSince(ap.usersCachePath, requested) {
+		ap.logger.Info("Users cache was just refreshed by another process, reusing it",
+			zap.String("cache_file", ap.usersCachePath))
+		force = false
+	}
+
 	var (
 		list        []slack.User
 		optionLimit = slack.GetUsersOptionLimit(1000)
 	)
 
+	// An expired cache is still a good base for a delta refresh
+	var stale []slack.User
+
 	// Check if we should use cache (not forced, cache exists, and within TTL)
 	if !force {
-		if data, err := os.ReadFile(ap.usersCachePath); err == nil {
+		if data, err := ReadCacheFile(ap.usersCachePath); err == nil {
 			var cachedUsers []slack.User
 			if err := json.Unmarshal(data, &cachedUsers); err != nil {
 				ap.logger.Warn("Failed to unmarshal users cache, will refetch",
@@ -722,32 +917,45 @@ func (ap *ApiProvider) refreshUsersInternal(ctx context.Context, force bool) err
 				}
 
 				if cacheValid {
-					// Build new snapshot from cache
-					newSnapshot := &UsersCache{
-						Users:    make(map[string]slack.User, len(cachedUsers)),
-						UsersInv: make(map[string]string, len(cachedUsers)),
-					}
-					for _, u := range cachedUsers {
-						newSnapshot.Users[u.ID] = u
-						newSnapshot.UsersInv[u.Name] = u.ID
-					}
-					ap.usersSnapshot.Store(newSnapshot)
+					ap.usersSnapshot.Store(newUsersSnapshot(cachedUsers))
 					ap.logger.Info("Loaded users from cache",
 						zap.Int("count", len(cachedUsers)),
 						zap.String("cache_file", ap.usersCachePath))
 					ap.usersReady = true
 					return nil
 				}
+				stale = cachedUsers
+			}
+		}
+	}
+
+	// Fetch only what changed since the last sync, if the client supports it
+	if _, ok := ap.deltaSince(ap.usersCachePath); ok {
+		base := stale
+		if base == nil && ap.usersReady {
+			for _, u := range ap.ProvideUsersMap().Users {
+				base = append(base, u)
+			}
+		}
+		if base != nil {
+			err := ap.deltaRefreshUsers(ctx, base)
+			if err == nil || ctx.Err() != nil {
+				return err
 			}
+			ap.logger.Warn("Delta refresh of users failed, falling back to full refresh", zap.Error(err))
 		}
 	}
 
 	// Fetch fresh data from Slack API
+	started := time.Now()
 	users, err := ap.client.GetUsersContext(ctx,
 		optionLimit,
 	)
 	if err != nil {
 		ap.logger.Error("Failed to fetch users", zap.Error(err))
+		if stale != nil {
+			ap.serveStaleUsers(stale, err)
+		}
 		return err
 	}
 	list = append(list, users...)
@@ -790,19 +998,9 @@ func (ap *ApiProvider) refreshUsersInternal(ctx context.Context, force bool) err
 		ap.usersSnapshot.Store(finalSnapshot)
 	}
 
-	if data, err := json.MarshalIndent(list, "", "  "); err != nil {
-		ap.logger.Error("Failed to marshal users for cache", zap.Error(err))
-	} else {
-		if err := os.WriteFile(ap.usersCachePath, data, 0644); err != nil {
-			ap.logger.Error("Failed to write cache file",
-				zap.String("cache_file", ap.usersCachePath),
-				zap.Error(err))
-		} else {
-			ap.logger.Info("Wrote users to cache",
-				zap.Int("count", len(list)),
-				zap.String("cache_file", ap.usersCachePath))
-		}
-	}
+	ap.writeCache(ap.usersCachePath, list, len(list), "users")
+	ap.saveSyncState(ap.usersCachePath, started, true)
+	ap.saveMemberCount(ctx)
 
 	ap.usersReady = true
 
@@ -810,7 +1008,9 @@ func (ap *ApiProvider) refreshUsersInternal(ctx context.Context, force bool) err
 }
 
 func (ap *ApiProvider) RefreshChannels(ctx context.Context) error {
-	return ap.refreshChannelsInternal(ctx, false)
+	err := ap.refreshChannelsInternal(ctx, false)
+	ap.recordRefresh(CacheChannels, err)
+	return err
 }
 
 // ForceRefreshChannels bypasses the cache and fetches fresh channel data from Slack API.
@@ -835,16 +1035,36 @@ func (ap *ApiProvider) ForceRefreshChannels(ctx context.Context) error {
 	}
 
 	ap.logger.Info("Force refreshing channels cache")
-	return ap.refreshChannelsInternal(ctx, true)
+	err := ap.refreshChannelsInternal(ctx, true)
+	ap.recordRefresh(CacheChannels, err)
+	return err
 }
 
 func (ap *ApiProvider) refreshChannelsInternal(ctx context.Context, force bool) error {
 	ap.channelsMu.Lock()
 	defer ap.channelsMu.Unlock()
 
+	// Other server processes on the same workspace share the cache file, only one refreshes it
+	requested := time.Now()
+	unlock, err := ap.lockCache(ctx, ap.channelsCachePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// A peer that refreshed the cache while we waited for the lock saves us the fetch
+	if force && modifiedSince(ap.channelsCachePath, requested) {
+		ap.logger.Info("Channels cache was just refreshed by another process, reusing it",
+			zap.String("cache_file", ap.channelsCachePath))
+		force = false
+	}
+
+	// An expired cache is still a good base for a delta refresh
+	var stale []Channel
+
 	// Check if we should use cache (not forced, cache exists, and within TTL)
 	if !force {
-		if data, err := os.ReadFile(ap.channelsCachePath); err == nil {
+		if data, err := ReadCacheFile(ap.channelsCachePath); err == nil {
 			var cachedChannels []Channel
 			if err := json.Unmarshal(data, &cachedChannels); err != nil {
 				ap.logger.Warn("Failed to unmarshal channels cache, will refetch",
@@ -868,61 +1088,95 @@ func (ap *ApiProvider) refreshChannelsInternal(ctx context.Context, force bool)
 
 				if cacheValid {
 					// Re-map channels with current users cache to ensure DM names are populated
-					usersMap := ap.ProvideUsersMap().Users
-					newSnapshot := &ChannelsCache{
-						Channels:    make(map[string]Channel, len(cachedChannels)),
-						ChannelsInv: make(map[string]string, len(cachedChannels)),
-					}
-					for _, c := range cachedChannels {
-						// For IM channels, re-generate the name and purpose using current users cache
-						if c.IsIM {
-							// Re-map the channel to get updated user name if available
-							remappedChannel := mapChannel(
-								c.ID, "", "", c.Topic, c.Purpose,
-								c.User, c.Members, c.MemberCount,
-								c.IsIM, c.IsMpIM, c.IsPrivate,
-								usersMap,
-							)
-							newSnapshot.Channels[c.ID] = remappedChannel
-							newSnapshot.ChannelsInv[remappedChannel.Name] = c.ID
-						} else {
-							newSnapshot.Channels[c.ID] = c
-							newSnapshot.ChannelsInv[c.Name] = c.ID
-						}
-					}
-					ap.channelsSnapshot.Store(newSnapshot)
+					ap.channelsSnapshot.Store(newChannelsSnapshot(cachedChannels, ap.ProvideUsersMap().Users))
 					ap.logger.Info("Loaded channels from cache and re-mapped DM names",
 						zap.Int("count", len(cachedChannels)),
 						zap.String("cache_file", ap.channelsCachePath))
 					ap.channelsReady = true
 					return nil
 				}
+				stale = cachedChannels
 			}
 		}
 	}
 
-	// Fetch fresh data from Slack API
-	channels := ap.GetChannels(ctx, AllChanTypes)
+	// Fetch only what changed since the last sync, if the client supports it
+	if since, ok := ap.deltaSince(ap.channelsCachePath); ok {
+		base := stale
+		if base == nil && ap.channelsReady {
+			for _, c := range ap.ProvideChannelsMaps().Channels {
+				base = append(base, c)
+			}
+		}
+		if base != nil {
+			err := ap.deltaRefreshChannels(ctx, base, since)
+			if err == nil || ctx.Err() != nil {
+				return err
+			}
+			ap.logger.Warn("Delta refresh of channels failed, falling back to full refresh", zap.Error(err))
+		}
+	}
 
-	if data, err := json.MarshalIndent(channels, "", "  "); err != nil {
-		ap.logger.Error("Failed to marshal channels for cache", zap.Error(err))
-	} else {
-		if err := os.WriteFile(ap.channelsCachePath, data, 0644); err != nil {
-			ap.logger.Error("Failed to write cache file",
-				zap.String("cache_file", ap.channelsCachePath),
-				zap.Error(err))
-		} else {
-			ap.logger.Info("Wrote channels to cache",
-				zap.Int("count", len(channels)),
-				zap.String("cache_file", ap.channelsCachePath))
+	// Fetch fresh data from Slack API
+	started := time.Now()
+	channels, err := ap.getChannels(ctx, AllChanTypes)
+	if err != nil {
+		if stale != nil && ctx.Err() == nil {
+			ap.serveStaleChannels(stale, err)
 		}
+		return err
 	}
 
+	ap.writeCache(ap.channelsCachePath, channels, len(channels), "channels")
+	ap.saveSyncState(ap.channelsCachePath, started, true)
+
 	ap.channelsReady = true
 
 	return nil
 }
 
+// RefreshUsergroups fetches enabled user groups and stores them as a new snapshot.
+// User groups are not persisted to disk, they are only used for argument completion.
+func (ap *ApiProvider) RefreshUsergroups(ctx context.Context) (err error) {
+	defer func() { ap.recordRefresh(CacheUsergroups, err) }()
+
+	groups, err := ap.client.GetUserGroupsContext(ctx,
+		slack.GetUserGroupsOptionIncludeCount(true),
+		slack.GetUserGroupsOptionIncludeDisabled(false),
+	)
+	if err != nil {
+		ap.logger.Error("Failed to fetch user groups", zap.Error(err))
+		return err
+	}
+
+	newSnapshot := &UsergroupsCache{
+		Usergroups: make(map[string]slack.UserGroup, len(groups)),
+	}
+	for _, g := range groups {
+		newSnapshot.Usergroups[g.ID] = g
+	}
+	ap.usergroupsSnapshot.Store(newSnapshot)
+
+	ap.logger.Info("Cached user groups", zap.Int("count", len(groups)))
+	return nil
+}
+
+// RefreshEmoji fetches the workspace custom emoji list and stores it as a new snapshot.
+func (ap *ApiProvider) RefreshEmoji(ctx context.Context) (err error) {
+	defer func() { ap.recordRefresh(CacheEmoji, err) }()
+
+	emoji, err := ap.client.GetEmojiContext(ctx)
+	if err != nil {
+		ap.logger.Error("Failed to fetch emoji", zap.Error(err))
+		return err
+	}
+
+	ap.emojiSnapshot.Store(&EmojiCache{Emoji: emoji})
+
+	ap.logger.Info("Cached emoji", zap.Int("count", len(emoji)))
+	return nil
+}
+
 func (ap *ApiProvider) GetSlackConnect(ctx context.Context) ([]slack.User, error) {
 	boot, err := ap.client.ClientUserBoot(ctx)
 	if err != nil {
@@ -945,7 +1199,7 @@ func (ap *ApiProvider) GetSlackConnect(ctx context.Context) ([]slack.User, error
 
 	res := make([]slack.User, 0, len(collectedIDs))
 	if len(collectedIDs) > 0 {
-		usersInfo, err := ap.client.GetUsersInfo(strings.Join(collectedIDs, ","))
+		usersInfo, err := ap.client.GetUsersInfoContext(ctx, strings.Join(collectedIDs, ","))
 		if err != nil {
 			ap.logger.Error("Failed to fetch users info for shared IMs", zap.Error(err))
 			return nil, err
@@ -960,6 +1214,12 @@ func (ap *ApiProvider) GetSlackConnect(ctx context.Context) ([]slack.User, error
 }
 
 func (ap *ApiProvider) GetChannelsType(ctx context.Context, channelType string) []Channel {
+	chans, _ := ap.getChannelsType(ctx, channelType)
+	return chans
+}
+
+// getChannelsType returns the channels of one type fetched before the first error, and that error.
+func (ap *ApiProvider) getChannelsType(ctx context.Context, channelType string) ([]Channel, error) {
 	params := &slack.GetConversationsParameters{
 		Types:           []string{channelType},
 		Limit:           999,
@@ -977,7 +1237,7 @@ func (ap *ApiProvider) GetChannelsType(ctx context.Context, channelType string)
 	for {
 		if err := ap.rateLimiter.Wait(ctx); err != nil {
 			ap.logger.Error("Rate limiter wait failed", zap.Error(err))
-			return nil
+			return nil, err
 		}
 
 		channels, nextcur, err = ap.client.GetConversationsContext(ctx, params)
@@ -987,7 +1247,7 @@ func (ap *ApiProvider) GetChannelsType(ctx context.Context, channelType string)
 		)
 		if err != nil {
 			ap.logger.Error("Failed to fetch channels", zap.Error(err))
-			break
+			return chans, err
 		}
 
 		for _, channel := range channels {
@@ -1005,29 +1265,63 @@ func (ap *ApiProvider) GetChannelsType(ctx context.Context, channelType string)
 				channel.IsPrivate,
 				ap.ProvideUsersMap().Users,
 			)
+			ch.IsExtShared = channel.IsExtShared
 			chans = append(chans, ch)
 		}
 
+		ReportProgress(ctx, float64(len(chans)), 0, fmt.Sprintf("Fetched %d %s conversations", len(chans), channelType))
+
 		if nextcur == "" {
 			break
 		}
 
 		params.Cursor = nextcur
 	}
-	return chans
+	return chans, nil
 }
 
 func (ap *ApiProvider) GetChannels(ctx context.Context, channelTypes []string) []Channel {
+	chans, _ := ap.getChannels(ctx, channelTypes)
+	return chans
+}
+
+// getChannels fetches all channels into a new snapshot and returns those of channelTypes.
+// Types the token can't list are skipped, it only fails when no type could be fetched,
+// e.g. because Slack is unreachable, and then keeps the previous snapshot intact.
+func (ap *ApiProvider) getChannels(ctx context.Context, channelTypes []string) ([]Channel, error) {
 	if len(channelTypes) == 0 {
 		channelTypes = AllChanTypes
 	}
 
-	var chans []Channel
+	var (
+		chans    []Channel
+		failures int
+		lastErr  error
+	)
 	for _, t := range AllChanTypes {
-		var typeChannels = ap.GetChannelsType(ctx, t)
+		// Offset per-type progress by what was fetched so far, so it keeps increasing across types.
+		fetched := float64(len(chans))
+		typeCtx := WithProgress(ctx, func(progress, total float64, message string) {
+			ReportProgress(ctx, fetched+progress, 0, message)
+		})
+		typeChannels, err := ap.getChannelsType(typeCtx, t)
+		if err != nil {
+			failures++
+			lastErr = err
+		}
 		chans = append(chans, typeChannels...)
 	}
 
+	// A cancelled request leaves us with a partial list, keep the previous snapshot intact.
+	if err := ctx.Err(); err != nil {
+		ap.logger.Warn("Channels fetch cancelled, keeping previous snapshot", zap.Error(err))
+		return nil, err
+	}
+	if failures == len(AllChanTypes) {
+		ap.logger.Warn("No channels could be fetched, keeping previous snapshot", zap.Error(lastErr))
+		return nil, lastErr
+	}
+
 	// Build new snapshot with all fetched channels
 	newSnapshot := &ChannelsCache{
 		Channels:    make(map[string]Channel, len(chans)),
@@ -1058,7 +1352,21 @@ func (ap *ApiProvider) GetChannels(ctx context.Context, channelTypes []string) [
 		}
 	}
 
-	return res
+	return res, nil
+}
+
+// Archive returns the local message archive, or nil if archiving is not enabled.
+func (ap *ApiProvider) Archive() *Archive {
+	return ap.archive
+}
+
+// resolveArchiveChannel resolves a channel from SLACK_MCP_ARCHIVE_CHANNELS, given by ID or #name.
+func (ap *ApiProvider) resolveArchiveChannel(channel string) (string, bool) {
+	if !strings.HasPrefix(channel, "#") {
+		return channel, true
+	}
+	id, ok := ap.ProvideChannelsMaps().ChannelsInv[channel]
+	return id, ok
 }
 
 func (ap *ApiProvider) ProvideUsersMap() *UsersCache {
@@ -1071,6 +1379,16 @@ func (ap *ApiProvider) ProvideChannelsMaps() *ChannelsCache {
 	return ap.channelsSnapshot.Load()
 }
 
+func (ap *ApiProvider) ProvideUsergroups() *UsergroupsCache {
+	// Atomic load - no lock needed, snapshot is immutable
+	return ap.usergroupsSnapshot.Load()
+}
+
+func (ap *ApiProvider) ProvideEmoji() *EmojiCache {
+	// Atomic load - no lock needed, snapshot is immutable
+	return ap.emojiSnapshot.Load()
+}
+
 func (ap *ApiProvider) IsReady() (bool, error) {
 	if !ap.usersReady {
 		return false, ErrUsersNotReady
@@ -1090,13 +1408,29 @@ func (ap *ApiProvider) Slack() SlackAPI {
 }
 
 func (ap *ApiProvider) IsBotToken() bool {
-	client, ok := ap.client.(*MCPSlackClient)
-	return ok && client != nil && client.IsBotToken()
+	switch client := ap.client.(type) {
+	case *MCPSlackClient:
+		return client != nil && client.IsBotToken()
+	case *reconnectingClient:
+		return client.IsBotToken()
+	}
+	return false
 }
 
 func (ap *ApiProvider) IsOAuth() bool {
-	client, ok := ap.client.(*MCPSlackClient)
-	return ok && client != nil && client.IsOAuth()
+	switch client := ap.client.(type) {
+	case *MCPSlackClient:
+		return client != nil && client.IsOAuth()
+	case *reconnectingClient:
+		return client.IsOAuth()
+	}
+	return false
+}
+
+// IsOffline reports whether data is served from an export or slackdump archive instead of the Slack API.
+func (ap *ApiProvider) IsOffline() bool {
+	_, ok := ap.client.(*OfflineClient)
+	return ok
 }
 
 // SearchUsers searches for users by name, email, or display name.
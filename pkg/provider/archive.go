@@ -0,0 +1,571 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/limiter"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultArchiveSyncInterval = 5 * time.Minute
+	defaultArchiveBackfill     = 30 * 24 * time.Hour
+	defaultArchiveLookback     = 24 * time.Hour
+	archivePageSize            = 200
+)
+
+// ArchivedMessage is a message kept in the local archive together with its edit history.
+// Deleted messages are kept as tombstones so deletions are visible to consumers of the archive.
+type ArchivedMessage struct {
+	slack.Message
+	Edits     []MessageEdit `json:"edits,omitempty"`
+	Deleted   bool          `json:"deleted,omitempty"`
+	DeletedAt string        `json:"deleted_at,omitempty"`
+}
+
+// MessageEdit is a previous version of an archived message.
+type MessageEdit struct {
+	Text     string `json:"text"`
+	EditedTs string `json:"edited_ts,omitempty"`
+}
+
+type threadArchive struct {
+	// SyncedAt is the channel sync (its Latest) during which the thread was last fetched in full.
+	SyncedAt string            `json:"synced_at"`
+	Replies  []ArchivedMessage `json:"replies"`
+}
+
+type channelArchive struct {
+	ChannelID string `json:"channel_id"`
+	// Oldest and Latest bound the time range the archive holds completely, Latest is
+	// when the last sync started (for offline sources the newest message).
+	Oldest   string                    `json:"oldest"`
+	Latest   string                    `json:"latest"`
+	Messages []ArchivedMessage         `json:"messages"`
+	Threads  map[string]*threadArchive `json:"threads"`
+}
+
+// SyncListener is notified after a channel has been synced with the messages
+// (top-level and replies) that were added, edited or deleted.
+type SyncListener func(channelID string, changed []ArchivedMessage)
+
+// Archive is an opt-in on-disk message store that incrementally syncs configured channels.
+// Each channel is kept in its own JSON file under dir.
+type Archive struct {
+	dir      string
+	client   SlackAPI
+	logger   *zap.Logger
+	limiter  *rate.Limiter
+	channels []string
+	resolve  func(channel string) (string, bool)
+
+	interval time.Duration
+	backfill time.Duration
+	lookback time.Duration
+
+	mu        sync.RWMutex
+	data      map[string]*channelArchive
+	listeners []SyncListener
+}
+
+// newArchiveFromEnv returns nil unless SLACK_MCP_ARCHIVE_CHANNELS, or SLACK_MCP_<WORKSPACE>_ARCHIVE_CHANNELS
+// for a workspace of SLACK_MCP_WORKSPACES, is set.
+func newArchiveFromEnv(ws workspaceConfig, teamID string, client SlackAPI, resolve func(string) (string, bool), logger *zap.Logger) *Archive {
+	channelsEnv := ws.getenv("ARCHIVE_CHANNELS")
+	if channelsEnv == "" {
+		return nil
+	}
+
+	var channels []string
+	for _, c := range strings.Split(channelsEnv, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			channels = append(channels, c)
+		}
+	}
+
+	dir := ws.getenv("ARCHIVE_DIR")
+	if dir == "" {
+		dir = ws.cachePath(teamID, "archive")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		logger.Error("Failed to create archive directory, archive is disabled",
+			zap.String("dir", dir),
+			zap.Error(err))
+		return nil
+	}
+
+	a := &Archive{
+		dir:      dir,
+		client:   client,
+		logger:   logger,
+		limiter:  limiter.Tier3.Limiter(),
+		channels: channels,
+		resolve:  resolve,
+		interval: getArchiveDuration("SLACK_MCP_ARCHIVE_SYNC_INTERVAL", defaultArchiveSyncInterval),
+		backfill: getArchiveDuration("SLACK_MCP_ARCHIVE_BACKFILL", defaultArchiveBackfill),
+		lookback: getArchiveDuration("SLACK_MCP_ARCHIVE_LOOKBACK", defaultArchiveLookback),
+		data:     make(map[string]*channelArchive),
+	}
+	a.load()
+	return a
+}
+
+// getArchiveDuration parses durations like getCacheTTL does, additionally accepting days (e.g. "30d").
+func getArchiveDuration(env string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(env))
+	if v == "" {
+		return def
+	}
+	if strings.HasSuffix(v, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(v, "d")); err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return def
+}
+
+func (a *Archive) load() {
+	migrateCacheDir(a.logger, a.dir)
+
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		a.logger.Warn("Failed to read archive directory", zap.String("dir", a.dir), zap.Error(err))
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := ReadCacheFile(filepath.Join(a.dir, e.Name()))
+		if err != nil {
+			a.logger.Warn("Failed to read archive file", zap.String("file", e.Name()), zap.Error(err))
+			continue
+		}
+		var ca channelArchive
+		if err := json.Unmarshal(data, &ca); err != nil {
+			a.logger.Warn("Failed to unmarshal archive file, it will be resynced", zap.String("file", e.Name()), zap.Error(err))
+			continue
+		}
+		if ca.Threads == nil {
+			ca.Threads = make(map[string]*threadArchive)
+		}
+		a.data[ca.ChannelID] = &ca
+	}
+	a.logger.Info("Loaded message archive",
+		zap.String("dir", a.dir),
+		zap.Int("channels", len(a.data)))
+}
+
+func (a *Archive) save(ca *channelArchive) error {
+	data, err := json.Marshal(ca)
+	if err != nil {
+		return err
+	}
+	return WriteCacheFile(filepath.Join(a.dir, ca.ChannelID+".json"), data)
+}
+
+// Dir returns the directory the archive is stored in.
+func (a *Archive) Dir() string {
+	return a.dir
+}
+
+// Interval returns how often Sync should run.
+func (a *Archive) Interval() time.Duration {
+	return a.interval
+}
+
+// OnSync registers a listener that is called after every channel sync.
+func (a *Archive) OnSync(l SyncListener) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.listeners = append(a.listeners, l)
+}
+
+// Sync incrementally syncs all configured channels. A failing channel does not stop the others.
+func (a *Archive) Sync(ctx context.Context) error {
+	var errs []string
+	for _, c := range a.channels {
+		channelID, ok := a.resolve(c)
+		if !ok {
+			a.logger.Warn("Archive channel not found, skipping", zap.String("channel", c))
+			continue
+		}
+		if err := a.syncChannel(ctx, channelID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			a.logger.Error("Failed to sync archive channel", zap.String("channel", channelID), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %v", channelID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("archive sync failed for %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// syncChannel fetches everything from the end of the previous sync minus the lookback
+// window up to now. Re-reading the lookback window records edits and deletions of
+// recent messages, threads whose parent falls into the window are refetched in full.
+func (a *Archive) syncChannel(ctx context.Context, channelID string) error {
+	now := time.Now()
+	nowTs := timeToTs(now)
+
+	a.mu.RLock()
+	prev := a.data[channelID]
+	a.mu.RUnlock()
+
+	ca := &channelArchive{ChannelID: channelID, Threads: make(map[string]*threadArchive)}
+	from := timeToTs(now.Add(-a.backfill))
+	if prev != nil && prev.Latest != "" {
+		ca = prev.clone()
+		from = timeToTs(tsToTime(prev.Latest).Add(-a.lookback))
+		if compareTs(from, prev.Oldest) < 0 {
+			from = prev.Oldest
+		}
+	}
+
+	fetched, err := a.fetchHistory(ctx, channelID, from, nowTs)
+	if err != nil {
+		return err
+	}
+
+	var changed []ArchivedMessage
+	ca.Messages, changed = mergeMessages(ca.Messages, fetched, from, nowTs, nowTs)
+
+	for _, msg := range fetched {
+		if msg.ReplyCount == 0 || msg.ThreadTimestamp != msg.Timestamp {
+			continue
+		}
+		replies, err := a.fetchReplies(ctx, channelID, msg.Timestamp)
+		if err != nil {
+			return err
+		}
+		thread, ok := ca.Threads[msg.Timestamp]
+		if !ok {
+			thread = &threadArchive{}
+			ca.Threads[msg.Timestamp] = thread
+		}
+		var threadChanged []ArchivedMessage
+		thread.Replies, threadChanged = mergeMessages(thread.Replies, replies, "", nowTs, nowTs)
+		thread.SyncedAt = nowTs
+		changed = append(changed, threadChanged...)
+	}
+
+	if ca.Oldest == "" {
+		ca.Oldest = from
+	}
+	ca.Latest = nowTs
+
+	if err := a.save(ca); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.data[channelID] = ca
+	listeners := append([]SyncListener(nil), a.listeners...)
+	a.mu.Unlock()
+
+	a.logger.Debug("Synced archive channel",
+		zap.String("channel", channelID),
+		zap.Int("fetched", len(fetched)),
+		zap.Int("changed", len(changed)))
+
+	for _, l := range listeners {
+		l(channelID, changed)
+	}
+	return nil
+}
+
+func (a *Archive) fetchHistory(ctx context.Context, channelID, oldest, latest string) ([]slack.Message, error) {
+	var (
+		res    []slack.Message
+		cursor string
+	)
+	for {
+		if err := a.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		history, err := a.client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: channelID,
+			Oldest:    oldest,
+			Latest:    latest,
+			Limit:     archivePageSize,
+			Cursor:    cursor,
+			Inclusive: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, history.Messages...)
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			return res, nil
+		}
+		cursor = history.ResponseMetaData.NextCursor
+	}
+}
+
+// fetchReplies returns all replies of a thread, without the parent message.
+func (a *Archive) fetchReplies(ctx context.Context, channelID, threadTs string) ([]slack.Message, error) {
+	var (
+		res    []slack.Message
+		cursor string
+	)
+	for {
+		if err := a.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		replies, hasMore, nextCursor, err := a.client.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: channelID,
+			Timestamp: threadTs,
+			Limit:     archivePageSize,
+			Cursor:    cursor,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range replies {
+			if r.Timestamp != threadTs {
+				res = append(res, r)
+			}
+		}
+		if !hasMore || nextCursor == "" {
+			return res, nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// mergeMessages merges fetched into stored, which are both top-level messages of a channel
+// or replies of a thread. Stored messages in (from, to] that were not fetched again are
+// marked deleted. It returns the merged messages sorted by ts and the ones that changed.
+func mergeMessages(stored []ArchivedMessage, fetched []slack.Message, from, to, now string) ([]ArchivedMessage, []ArchivedMessage) {
+	byTs := make(map[string]*ArchivedMessage, len(stored)+len(fetched))
+	for i := range stored {
+		byTs[stored[i].Timestamp] = &stored[i]
+	}
+
+	var changed []ArchivedMessage
+	seen := make(map[string]struct{}, len(fetched))
+	for _, msg := range fetched {
+		seen[msg.Timestamp] = struct{}{}
+		existing, ok := byTs[msg.Timestamp]
+		if !ok {
+			am := &ArchivedMessage{Message: msg}
+			if msg.SubType == "tombstone" {
+				am.Deleted, am.DeletedAt = true, now
+			}
+			byTs[msg.Timestamp] = am
+			changed = append(changed, *am)
+			continue
+		}
+		if msg.SubType == "tombstone" {
+			if !existing.Deleted {
+				existing.Deleted, existing.DeletedAt = true, now
+				changed = append(changed, *existing)
+			}
+			continue
+		}
+		if existing.Text != msg.Text {
+			edit := MessageEdit{Text: existing.Text}
+			if existing.Edited != nil {
+				edit.EditedTs = existing.Edited.Timestamp
+			}
+			existing.Edits = append(existing.Edits, edit)
+			existing.Message = msg
+			changed = append(changed, *existing)
+			continue
+		}
+		// Keep reply counters, reactions and other metadata current.
+		existing.Message = msg
+	}
+
+	for ts, am := range byTs {
+		if _, ok := seen[ts]; ok || am.Deleted {
+			continue
+		}
+		if (from == "" || compareTs(ts, from) >= 0) && compareTs(ts, to) <= 0 {
+			am.Deleted, am.DeletedAt = true, now
+			changed = append(changed, *am)
+		}
+	}
+
+	merged := make([]ArchivedMessage, 0, len(byTs))
+	for _, am := range byTs {
+		merged = append(merged, *am)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return compareTs(merged[i].Timestamp, merged[j].Timestamp) < 0
+	})
+	return merged, changed
+}
+
+// History answers a conversations.history request from the archive. Messages are
+// returned newest first like Slack does. ok is false when the archive does not
+// cover the requested range, in which case the caller should ask Slack. When the
+// range reaches past the last sync, newer is the ts after which messages are not
+// archived yet, the caller has to fetch (newer, latest) from Slack and put them in
+// front. hasMore only tells about archived messages.
+func (a *Archive) History(channelID, oldest, latest string, limit int) (msgs []slack.Message, hasMore bool, newer string, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	ca, found := a.data[channelID]
+	if !found || ca.Latest == "" {
+		return nil, false, "", false
+	}
+	if oldest != "" && compareTs(oldest, ca.Oldest) < 0 {
+		return nil, false, "", false
+	}
+	// The last sync fetched up to and including ca.Latest
+	upper, inclusive := latest, false
+	if latest == "" || compareTs(latest, ca.Latest) > 0 {
+		upper, inclusive = ca.Latest, true
+		newer = ca.Latest
+		if oldest != "" && compareTs(oldest, newer) > 0 {
+			newer = oldest
+		}
+	}
+
+	for i := len(ca.Messages) - 1; i >= 0; i-- {
+		m := ca.Messages[i]
+		if m.Deleted {
+			continue
+		}
+		if c := compareTs(m.Timestamp, upper); c > 0 || (c == 0 && !inclusive) {
+			continue
+		}
+		if oldest != "" && compareTs(m.Timestamp, oldest) <= 0 {
+			break
+		}
+		if limit > 0 && len(msgs) == limit {
+			return msgs, true, newer, true
+		}
+		msgs = append(msgs, m.Message)
+	}
+
+	// Without an oldest bound the store only answers if it was able to fill the page,
+	// otherwise older messages may exist before the archived range.
+	if oldest == "" && limit > 0 && len(msgs) < limit {
+		return nil, false, "", false
+	}
+	return msgs, false, newer, true
+}
+
+// Replies answers a conversations.replies request from the archive. Only threads
+// refetched during the latest sync are considered covered, replies posted after
+// newer, the time of that sync, have to be fetched from Slack.
+func (a *Archive) Replies(channelID, threadTs string) (msgs []slack.Message, newer string, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	ca, found := a.data[channelID]
+	if !found || ca.Latest == "" {
+		return nil, "", false
+	}
+	thread, found := ca.Threads[threadTs]
+	if !found || thread.SyncedAt != ca.Latest {
+		return nil, "", false
+	}
+
+	for _, m := range ca.Messages {
+		if m.Timestamp == threadTs && !m.Deleted {
+			msgs = append(msgs, m.Message)
+			break
+		}
+	}
+	for _, r := range thread.Replies {
+		if !r.Deleted {
+			msgs = append(msgs, r.Message)
+		}
+	}
+	return msgs, ca.Latest, true
+}
+
+// ChannelIDs returns the IDs of all archived channels.
+func (a *Archive) ChannelIDs() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	ids := make([]string, 0, len(a.data))
+	for id := range a.data {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Messages returns all archived messages of a channel, top-level messages and replies,
+// including deleted ones.
+func (a *Archive) Messages(channelID string) []ArchivedMessage {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	ca, ok := a.data[channelID]
+	if !ok {
+		return nil
+	}
+	res := append([]ArchivedMessage(nil), ca.Messages...)
+	for _, t := range ca.Threads {
+		res = append(res, t.Replies...)
+	}
+	return res
+}
+
+func (ca *channelArchive) clone() *channelArchive {
+	c := &channelArchive{
+		ChannelID: ca.ChannelID,
+		Oldest:    ca.Oldest,
+		Latest:    ca.Latest,
+		Messages:  append([]ArchivedMessage(nil), ca.Messages...),
+		Threads:   make(map[string]*threadArchive, len(ca.Threads)),
+	}
+	for ts, t := range ca.Threads {
+		c.Threads[ts] = &threadArchive{
+			SyncedAt: t.SyncedAt,
+			Replies:  append([]ArchivedMessage(nil), t.Replies...),
+		}
+	}
+	return c
+}
+
+func timeToTs(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
+
+func tsToTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	us, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+	return time.Unix(s, us*1000)
+}
+
+// compareTs compares two Slack timestamps without going through float64,
+// which cannot represent microsecond precision of current timestamps exactly.
+func compareTs(a, b string) int {
+	ta, tb := tsToTime(a), tsToTime(b)
+	switch {
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func msg(ts, text string) slack.Message {
+	return slack.Message{Msg: slack.Msg{Timestamp: ts, Text: text}}
+}
+
+func archived(msgs ...slack.Message) []ArchivedMessage {
+	res := make([]ArchivedMessage, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, ArchivedMessage{Message: m})
+	}
+	return res
+}
+
+func TestCompareTs(t *testing.T) {
+	assert.Equal(t, -1, compareTs("1700000000.000001", "1700000000.000002"))
+	assert.Equal(t, 1, compareTs("1700000001.000000", "1700000000.999999"))
+	assert.Equal(t, 0, compareTs("1700000000.5", "1700000000.500000"))
+	assert.Equal(t, -1, compareTs("999999999.000000", "1700000000.000000"))
+}
+
+func TestMergeMessages(t *testing.T) {
+	stored := archived(
+		msg("100.000000", "old, outside window"),
+		msg("200.000000", "will be edited"),
+		msg("300.000000", "will be deleted"),
+	)
+	fetched := []slack.Message{
+		msg("200.000000", "edited"),
+		msg("400.000000", "new"),
+	}
+
+	merged, changed := mergeMessages(stored, fetched, "150.000000", "500.000000", "500.000000")
+
+	require.Len(t, merged, 4)
+	assert.Equal(t, []string{"100.000000", "200.000000", "300.000000", "400.000000"},
+		[]string{merged[0].Timestamp, merged[1].Timestamp, merged[2].Timestamp, merged[3].Timestamp})
+
+	assert.False(t, merged[0].Deleted, "messages outside the window are not re-validated")
+	assert.Equal(t, "edited", merged[1].Text)
+	require.Len(t, merged[1].Edits, 1)
+	assert.Equal(t, "will be edited", merged[1].Edits[0].Text)
+	assert.True(t, merged[2].Deleted)
+	assert.Equal(t, "500.000000", merged[2].DeletedAt)
+	assert.Len(t, changed, 3)
+}
+
+func TestArchiveHistory(t *testing.T) {
+	a := &Archive{
+		logger:   zap.NewNop(),
+		interval: time.Minute,
+		data: map[string]*channelArchive{
+			"C1": {
+				ChannelID: "C1",
+				Oldest:    "100.000000",
+				Latest:    "500.000000",
+				Messages: []ArchivedMessage{
+					{Message: msg("200.000000", "a")},
+					{Message: msg("300.000000", "b"), Deleted: true},
+					{Message: msg("400.000000", "c")},
+					{Message: msg("500.000000", "d")},
+				},
+			},
+		},
+	}
+
+	msgs, hasMore, newer, ok := a.History("C1", "150.000000", "", 2)
+	require.True(t, ok)
+	assert.True(t, hasMore)
+	assert.Equal(t, "500.000000", newer, "messages after the last sync come from Slack")
+	assert.Equal(t, []string{"d", "c"}, []string{msgs[0].Text, msgs[1].Text})
+
+	msgs, hasMore, newer, ok = a.History("C1", "150.000000", "400.000000", 10)
+	require.True(t, ok)
+	assert.False(t, hasMore)
+	assert.Empty(t, newer)
+	assert.Equal(t, []string{"a"}, []string{msgs[0].Text})
+
+	msgs, _, newer, ok = a.History("C1", "450.000000", "900.000000", 10)
+	require.True(t, ok)
+	assert.Equal(t, "500.000000", newer)
+	assert.Equal(t, []string{"d"}, []string{msgs[0].Text})
+
+	msgs, _, newer, ok = a.History("C1", "600.000000", "", 10)
+	require.True(t, ok)
+	assert.Equal(t, "600.000000", newer, "range after the last sync")
+	assert.Empty(t, msgs)
+
+	_, _, _, ok = a.History("C1", "50.000000", "", 10)
+	assert.False(t, ok, "range starts before the archive")
+
+	_, _, _, ok = a.History("C1", "", "", 10)
+	assert.False(t, ok, "page cannot be filled without an oldest bound")
+
+	_, _, _, ok = a.History("C2", "150.000000", "", 10)
+	assert.False(t, ok, "channel is not archived")
+}
+
+func TestArchiveReplies(t *testing.T) {
+	a := &Archive{
+		logger: zap.NewNop(),
+		data: map[string]*channelArchive{
+			"C1": {
+				ChannelID: "C1",
+				Oldest:    "100.000000",
+				Latest:    "500.000000",
+				Messages:  []ArchivedMessage{{Message: msg("200.000000", "parent")}, {Message: msg("300.000000", "old parent")}},
+				Threads: map[string]*threadArchive{
+					"200.000000": {SyncedAt: "500.000000", Replies: archived(msg("210.000000", "reply"))},
+					"300.000000": {SyncedAt: "400.000000", Replies: archived(msg("310.000000", "reply"))},
+				},
+			},
+		},
+	}
+
+	msgs, newer, ok := a.Replies("C1", "200.000000")
+	require.True(t, ok)
+	assert.Equal(t, "500.000000", newer)
+	assert.Equal(t, []string{"parent", "reply"}, []string{msgs[0].Text, msgs[1].Text})
+
+	_, _, ok = a.Replies("C1", "300.000000")
+	assert.False(t, ok, "thread was not refetched during the last sync")
+}
+
+func TestGetArchiveDuration(t *testing.T) {
+	t.Setenv("SLACK_MCP_ARCHIVE_BACKFILL", "7d")
+	assert.Equal(t, 7*24*time.Hour, getArchiveDuration("SLACK_MCP_ARCHIVE_BACKFILL", time.Hour))
+	t.Setenv("SLACK_MCP_ARCHIVE_BACKFILL", "90m")
+	assert.Equal(t, 90*time.Minute, getArchiveDuration("SLACK_MCP_ARCHIVE_BACKFILL", time.Hour))
+	t.Setenv("SLACK_MCP_ARCHIVE_BACKFILL", "-1d")
+	assert.Equal(t, time.Hour, getArchiveDuration("SLACK_MCP_ARCHIVE_BACKFILL", time.Hour))
+}
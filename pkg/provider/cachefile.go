@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lockPollInterval is how often a process waiting for a cache lock held by a peer retries.
+const lockPollInterval = 100 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("file is locked by another process")
+
+// WriteFileAtomic writes data to path so that readers, also in other processes, see either
+// the old or the new content and a crash never leaves a truncated file: data is written to
+// a temporary file in the same directory, synced to disk and renamed over path.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself, not supported on every platform
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// lockCacheFile takes an advisory lock on path shared with other server processes, waiting
+// until ctx is done for a peer to release it. The lock lives in a separate path+".lock"
+// file, so the cache file itself can be replaced atomically while it is held, and is
+// released by the OS if the process dies.
+func lockCacheFile(ctx context.Context, path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// lockCache takes the cross-process lock of a cache file for a refresh. Failing to lock,
+// e.g. on a read-only file system, only means peers may refetch too, so the refresh goes
+// ahead unlocked unless ctx is done.
+func (ap *ApiProvider) lockCache(ctx context.Context, path string) (unlock func(), err error) {
+	unlock, err = lockCacheFile(ctx, path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		ap.logger.Warn("Failed to lock cache file, refreshing without it",
+			zap.String("cache_file", path),
+			zap.Error(err))
+		return func() {}, nil
+	}
+	return unlock, nil
+}
+
+// modifiedSince reports whether the file at path was written after t.
+func modifiedSince(path string, t time.Time) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.ModTime().After(t)
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users_cache.json")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0600))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+
+	assert.Error(t, WriteFileAtomic(filepath.Join(dir, "missing", "cache.json"), []byte("x"), 0644))
+}
+
+func TestLockCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users_cache.json")
+
+	unlock, err := lockCacheFile(context.Background(), path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	_, err = lockCacheFile(ctx, path)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "a held lock blocks other holders")
+
+	unlock()
+	unlock, err = lockCacheFile(context.Background(), path)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestForceRefreshReusesPeerCache(t *testing.T) {
+	ctx := context.Background()
+	offline, err := LoadOffline(ctx, newTestExport(t), zap.NewNop())
+	require.NoError(t, err)
+	// Any fetch fails, so the refresh can only succeed by reusing the peer's cache
+	ap := newDeltaTestProvider(t, &unreachableClient{OfflineClient: offline})
+	ap.cacheTTL = time.Hour
+
+	peerUnlock, err := lockCacheFile(ctx, ap.usersCachePath)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- ap.ForceRefreshUsers(ctx) }()
+
+	// The peer finishes its refresh while ours waits for the lock
+	time.Sleep(2 * lockPollInterval)
+	data, err := json.Marshal([]slack.User{{ID: "U9", Name: "peer"}})
+	require.NoError(t, err)
+	require.NoError(t, WriteFileAtomic(ap.usersCachePath, data, 0644))
+	peerUnlock()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh did not finish after the lock was released")
+	}
+	assert.Equal(t, "U9", ap.ProvideUsersMap().UsersInv["peer"])
+}
@@ -0,0 +1,456 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider/edge"
+	"github.com/rusq/slackdump/v3/auth"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// minReconnectInterval limits how often a disconnected client retries authentication.
+const minReconnectInterval = 10 * time.Second
+
+// ErrSlackUnavailable is returned by Slack API calls while Slack could not be reached.
+// Cached users and channels are still served.
+var ErrSlackUnavailable = errors.New("Slack is unreachable, serving cached data only")
+
+// reconnectingClient stands in for MCPSlackClient when Slack was unreachable at startup.
+// Every call first tries to authenticate, at most once per minReconnectInterval, and
+// fails with ErrSlackUnavailable until that succeeds. Afterwards calls go to the
+// connected client.
+type reconnectingClient struct {
+	authProvider auth.Provider
+	logger       *zap.Logger
+	// dial authenticates with Slack, NewMCPSlackClient unless replaced by tests
+	dial func() (*MCPSlackClient, error)
+
+	// Identity of the workspace from the last successful start, if any
+	known *slack.AuthTestResponse
+
+	// mu is never held across calls to Slack
+	mu          sync.Mutex
+	client      *MCPSlackClient
+	connecting  bool
+	lastAttempt time.Time
+	lastErr     error
+	onConnect   []func(*MCPSlackClient)
+}
+
+func newReconnectingClient(authProvider auth.Provider, err error, logger *zap.Logger) *reconnectingClient {
+	return &reconnectingClient{
+		authProvider: authProvider,
+		logger:       logger,
+		dial: func() (*MCPSlackClient, error) {
+			return NewMCPSlackClient(authProvider, logger)
+		},
+		known:       knownWorkspace(authProvider.SlackToken()),
+		lastAttempt: time.Now(),
+		lastErr:     err,
+	}
+}
+
+// get returns the connected client, connecting first if needed. Only one attempt runs at
+// a time, other calls fail with the last error meanwhile. The caller that started it
+// stops waiting when ctx is done, the attempt still completes in the background.
+func (c *reconnectingClient) get(ctx context.Context) (*MCPSlackClient, error) {
+	c.mu.Lock()
+	if client := c.client; client != nil {
+		c.mu.Unlock()
+		return client, nil
+	}
+	if c.connecting || time.Since(c.lastAttempt) < minReconnectInterval {
+		lastErr := c.lastErr
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %v", ErrSlackUnavailable, lastErr)
+	}
+	c.connecting = true
+	c.lastAttempt = time.Now()
+	c.mu.Unlock()
+
+	type result struct {
+		client *MCPSlackClient
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		client, err := c.connect()
+		done <- result{client, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSlackUnavailable, res.err)
+		}
+		return res.client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// connect makes one attempt to authenticate and runs the onConnect callbacks on success.
+func (c *reconnectingClient) connect() (*MCPSlackClient, error) {
+	client, err := c.dial()
+
+	c.mu.Lock()
+	c.connecting = false
+	if err != nil {
+		c.lastErr = err
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.client = client
+	c.lastErr = nil
+	onConnect := c.onConnect
+	c.onConnect = nil
+	c.mu.Unlock()
+
+	c.logger.Info("Reconnected to Slack",
+		zap.String("context", "console"),
+		zap.String("team", client.authResponse.Team),
+	)
+	rememberWorkspace(c.authProvider.SlackToken(), client.authResponse)
+	for _, fn := range onConnect {
+		fn(client)
+	}
+	return client, nil
+}
+
+// connErr returns why the client is not connected, or nil once it is. It does not wait
+// for a running attempt.
+func (c *reconnectingClient) connErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrSlackUnavailable, c.lastErr)
+}
+
+// whenConnected runs fn once the client is connected, right away if it already is.
+func (c *reconnectingClient) whenConnected(fn func(*MCPSlackClient)) {
+	c.mu.Lock()
+	client := c.client
+	if client == nil {
+		c.onConnect = append(c.onConnect, fn)
+	}
+	c.mu.Unlock()
+	if client != nil {
+		fn(client)
+	}
+}
+
+func (c *reconnectingClient) IsOAuth() bool {
+	token := c.authProvider.SlackToken()
+	return strings.HasPrefix(token, "xoxp-") || strings.HasPrefix(token, "xoxb-")
+}
+
+func (c *reconnectingClient) IsBotToken() bool {
+	return strings.HasPrefix(c.authProvider.SlackToken(), "xoxb-")
+}
+
+// AuthTest returns the identity remembered from the last successful start while Slack is
+// unreachable, like MCPSlackClient it doesn't call Slack again once connected.
+func (c *reconnectingClient) AuthTest() (*slack.AuthTestResponse, error) {
+	client, err := c.get(context.Background())
+	if err != nil {
+		if c.known != nil {
+			return c.known, nil
+		}
+		return nil, err
+	}
+	return client.AuthTest()
+}
+
+func (c *reconnectingClient) AuthTestContext(ctx context.Context) (*slack.AuthTestResponse, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.AuthTestContext(ctx)
+}
+
+func (c *reconnectingClient) GetUsersContext(ctx context.Context, options ...slack.GetUsersOption) ([]slack.User, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetUsersContext(ctx, options...)
+}
+
+func (c *reconnectingClient) GetUsersInfoContext(ctx context.Context, users ...string) (*[]slack.User, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetUsersInfoContext(ctx, users...)
+}
+
+func (c *reconnectingClient) PostMessageContext(ctx context.Context, channel string, options ...slack.MsgOption) (string, string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return client.PostMessageContext(ctx, channel, options...)
+}
+
+func (c *reconnectingClient) MarkConversationContext(ctx context.Context, channel, ts string) error {
+	client, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return client.MarkConversationContext(ctx, channel, ts)
+}
+
+func (c *reconnectingClient) AddReactionContext(ctx context.Context, name string, item slack.ItemRef) error {
+	client, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return client.AddReactionContext(ctx, name, item)
+}
+
+func (c *reconnectingClient) RemoveReactionContext(ctx context.Context, name string, item slack.ItemRef) error {
+	client, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return client.RemoveReactionContext(ctx, name, item)
+}
+
+func (c *reconnectingClient) GetEmojiContext(ctx context.Context) (map[string]string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetEmojiContext(ctx)
+}
+
+func (c *reconnectingClient) GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetConversationHistoryContext(ctx, params)
+}
+
+func (c *reconnectingClient) GetConversationRepliesContext(ctx context.Context, params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, false, "", err
+	}
+	return client.GetConversationRepliesContext(ctx, params)
+}
+
+func (c *reconnectingClient) SearchContext(ctx context.Context, query string, params slack.SearchParameters) (*slack.SearchMessages, *slack.SearchFiles, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.SearchContext(ctx, query, params)
+}
+
+func (c *reconnectingClient) GetFileInfoContext(ctx context.Context, fileID string, count, page int) (*slack.File, []slack.Comment, *slack.Paging, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return client.GetFileInfoContext(ctx, fileID, count, page)
+}
+
+func (c *reconnectingClient) GetFileContext(ctx context.Context, downloadURL string, writer io.Writer) error {
+	client, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return client.GetFileContext(ctx, downloadURL, writer)
+}
+
+func (c *reconnectingClient) GetConversationsContext(ctx context.Context, params *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return client.GetConversationsContext(ctx, params)
+}
+
+func (c *reconnectingClient) ClientUserBoot(ctx context.Context) (*edge.ClientUserBootResponse, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.ClientUserBoot(ctx)
+}
+
+func (c *reconnectingClient) UsersSearch(ctx context.Context, query string, count int) ([]slack.User, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.UsersSearch(ctx, query, count)
+}
+
+func (c *reconnectingClient) GetUserGroupsContext(ctx context.Context, options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetUserGroupsContext(ctx, options...)
+}
+
+func (c *reconnectingClient) GetUserGroupMembersContext(ctx context.Context, userGroup string, options ...slack.GetUserGroupMembersOption) ([]string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetUserGroupMembersContext(ctx, userGroup, options...)
+}
+
+func (c *reconnectingClient) CreateUserGroupContext(ctx context.Context, userGroup slack.UserGroup, options ...slack.CreateUserGroupOption) (slack.UserGroup, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return slack.UserGroup{}, err
+	}
+	return client.CreateUserGroupContext(ctx, userGroup, options...)
+}
+
+func (c *reconnectingClient) UpdateUserGroupContext(ctx context.Context, userGroupID string, options ...slack.UpdateUserGroupsOption) (slack.UserGroup, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return slack.UserGroup{}, err
+	}
+	return client.UpdateUserGroupContext(ctx, userGroupID, options...)
+}
+
+func (c *reconnectingClient) UpdateUserGroupMembersContext(ctx context.Context, userGroup string, members string, options ...slack.UpdateUserGroupMembersOption) (slack.UserGroup, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return slack.UserGroup{}, err
+	}
+	return client.UpdateUserGroupMembersContext(ctx, userGroup, members, options...)
+}
+
+// SupportsDelta is decided by the token type, the connection is established by the delta calls.
+func (c *reconnectingClient) SupportsDelta() bool {
+	return !c.IsOAuth()
+}
+
+func (c *reconnectingClient) GetUsersChangedContext(ctx context.Context, known map[string]int64) ([]slack.User, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetUsersChangedContext(ctx, known)
+}
+
+func (c *reconnectingClient) GetMemberCountContext(ctx context.Context) (int, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return client.GetMemberCountContext(ctx)
+}
+
+func (c *reconnectingClient) GetChannelsChangedContext(ctx context.Context, since time.Time) ([]slack.Channel, []string, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.GetChannelsChangedContext(ctx, since)
+}
+
+// workspacesPath is where the identities of workspaces reached before are remembered, keyed
+// by a hash of the token, so their caches can be found when Slack is unreachable at startup.
+func workspacesPath() string {
+	return getCachePathWithTeamID("", "workspaces.json")
+}
+
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func loadWorkspaces() map[string]*slack.AuthTestResponse {
+	workspaces := make(map[string]*slack.AuthTestResponse)
+	if data, err := ReadCacheFile(workspacesPath()); err == nil {
+		_ = json.Unmarshal(data, &workspaces)
+	}
+	return workspaces
+}
+
+// knownWorkspace returns the identity last seen for token, or nil.
+func knownWorkspace(token string) *slack.AuthTestResponse {
+	return loadWorkspaces()[tokenFingerprint(token)]
+}
+
+// rememberWorkspace records the identity of the workspace token belongs to. Failures only
+// mean a later start without Slack can't find the caches, so they are ignored.
+func rememberWorkspace(token string, ar *slack.AuthTestResponse) {
+	if ar == nil {
+		return
+	}
+	workspaces := loadWorkspaces()
+	key := tokenFingerprint(token)
+	if known, ok := workspaces[key]; ok && *known == *ar {
+		return
+	}
+	workspaces[key] = ar
+	if data, err := json.MarshalIndent(workspaces, "", "  "); err == nil {
+		_ = WriteCacheFile(workspacesPath(), data)
+	}
+}
+
+// ConnectionError returns why Slack can't be reached, or nil when it can or was never needed.
+func (ap *ApiProvider) ConnectionError() error {
+	if c, ok := ap.client.(*reconnectingClient); ok {
+		return c.connErr()
+	}
+	return nil
+}
+
+// WhenConnected runs fn once Slack can be reached. It runs right away unless the server
+// started while Slack was unreachable and hasn't reconnected yet.
+func (ap *ApiProvider) WhenConnected(fn func()) {
+	if c, ok := ap.client.(*reconnectingClient); ok {
+		c.whenConnected(func(*MCPSlackClient) { fn() })
+		return
+	}
+	fn()
+}
+
+// serveStaleUsers serves an expired users cache that couldn't be refreshed, so tools keep
+// working while Slack is unreachable. It must be called with usersMu held.
+func (ap *ApiProvider) serveStaleUsers(users []slack.User, err error) {
+	if ap.usersReady {
+		return
+	}
+	ap.usersSnapshot.Store(newUsersSnapshot(users))
+	ap.usersReady = true
+	ap.logger.Warn("Serving expired users cache, refresh failed",
+		zap.Int("count", len(users)),
+		zap.String("cache_file", ap.usersCachePath),
+		zap.Error(err))
+}
+
+// serveStaleChannels is serveStaleUsers for channels. It must be called with channelsMu held.
+func (ap *ApiProvider) serveStaleChannels(channels []Channel, err error) {
+	if ap.channelsReady {
+		return
+	}
+	ap.channelsSnapshot.Store(newChannelsSnapshot(channels, ap.ProvideUsersMap().Users))
+	ap.channelsReady = true
+	ap.logger.Warn("Serving expired channels cache, refresh failed",
+		zap.Int("count", len(channels)),
+		zap.String("cache_file", ap.channelsCachePath),
+		zap.Error(err))
+}
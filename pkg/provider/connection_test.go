@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/slackdump/v3/auth"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var errUnreachable = errors.New("dial tcp: lookup slack.com: no such host")
+
+// unreachableClient serves nothing, as if Slack could not be reached.
+type unreachableClient struct {
+	*OfflineClient
+}
+
+func (c *unreachableClient) GetUsersContext(context.Context, ...slack.GetUsersOption) ([]slack.User, error) {
+	return nil, errUnreachable
+}
+
+func (c *unreachableClient) GetConversationsContext(context.Context, *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	return nil, "", errUnreachable
+}
+
+func TestWorkspaceMemory(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	assert.Nil(t, knownWorkspace("xoxp-1"))
+
+	ar := &slack.AuthTestResponse{URL: "https://acme.slack.com/", Team: "Acme", TeamID: "T1", UserID: "U1"}
+	rememberWorkspace("xoxp-1", ar)
+	assert.Equal(t, ar, knownWorkspace("xoxp-1"))
+	assert.Nil(t, knownWorkspace("xoxp-2"))
+
+	info, err := os.Stat(workspacesPath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(workspacesPath())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "xoxp-1", "tokens must not be stored")
+}
+
+func TestReconnectingClient(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	known := &slack.AuthTestResponse{URL: "https://acme.slack.com/", TeamID: "T1"}
+	rememberWorkspace("xoxc-1", known)
+
+	authProvider, err := auth.NewValueAuth("xoxc-1", "xoxd-1")
+	require.NoError(t, err)
+	c := newReconnectingClient(authProvider, errUnreachable, zap.NewNop())
+	ap := &ApiProvider{client: c, logger: zap.NewNop()}
+
+	// Right after the failed startup no new attempt is made
+	_, err = c.GetUsersContext(context.Background())
+	assert.ErrorIs(t, err, ErrSlackUnavailable)
+	assert.ErrorContains(t, err, "no such host")
+	assert.ErrorIs(t, ap.ConnectionError(), ErrSlackUnavailable)
+
+	ar, err := c.AuthTest()
+	require.NoError(t, err)
+	assert.Equal(t, "T1", ar.TeamID, "the identity from an earlier run is served while disconnected")
+
+	assert.False(t, ap.IsOAuth())
+	assert.False(t, ap.IsBotToken())
+	assert.True(t, c.SupportsDelta())
+
+	called := false
+	ap.WhenConnected(func() { called = true })
+	assert.False(t, called)
+	require.Len(t, c.onConnect, 1)
+}
+
+func TestReconnectingClientDialInProgress(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	authProvider, err := auth.NewValueAuth("xoxc-1", "xoxd-1")
+	require.NoError(t, err)
+	c := newReconnectingClient(authProvider, errUnreachable, zap.NewNop())
+	c.lastAttempt = time.Time{}
+
+	release := make(chan struct{})
+	dialed := make(chan struct{})
+	c.dial = func() (*MCPSlackClient, error) {
+		close(dialed)
+		<-release
+		return nil, errors.New("timeout")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetUsersContext(ctx)
+		done <- err
+	}()
+	<-dialed
+
+	// Neither the health check nor other calls wait for the dial
+	assert.ErrorContains(t, c.connErr(), "no such host")
+	_, err = c.GetUsersContext(context.Background())
+	assert.ErrorIs(t, err, ErrSlackUnavailable)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("the call did not return when its context was cancelled")
+	}
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		err := c.connErr()
+		return errors.Is(err, ErrSlackUnavailable) && strings.HasSuffix(err.Error(), "timeout")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServeStaleCache(t *testing.T) {
+	ctx := context.Background()
+	offline, err := LoadOffline(ctx, newTestExport(t), zap.NewNop())
+	require.NoError(t, err)
+	ap := newDeltaTestProvider(t, &unreachableClient{OfflineClient: offline})
+	ap.cacheTTL = time.Hour
+
+	expired := time.Now().Add(-2 * time.Hour)
+	users := []slack.User{{ID: "U1", Name: "alice"}}
+	channels := []Channel{{ID: "C1", Name: "#general"}}
+	for path, v := range map[string]any{ap.usersCachePath: users, ap.channelsCachePath: channels} {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0644))
+		require.NoError(t, os.Chtimes(path, expired, expired))
+	}
+
+	assert.ErrorIs(t, ap.RefreshUsers(ctx), errUnreachable)
+	assert.ErrorIs(t, ap.RefreshChannels(ctx), errUnreachable)
+
+	ready, err := ap.IsReady()
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, "U1", ap.ProvideUsersMap().UsersInv["alice"])
+	assert.Equal(t, "C1", ap.ProvideChannelsMaps().ChannelsInv["#general"])
+
+	// The cache files are kept for the next start
+	data, err := os.ReadFile(ap.channelsCachePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "#general")
+
+	assert.Equal(t, 1, ap.refreshStatus.get(CacheChannels).ConsecutiveFailures)
+}
+
+func TestRefreshChannelsWithoutCacheFails(t *testing.T) {
+	ctx := context.Background()
+	offline, err := LoadOffline(ctx, newTestExport(t), zap.NewNop())
+	require.NoError(t, err)
+	ap := newDeltaTestProvider(t, &unreachableClient{OfflineClient: offline})
+
+	assert.Error(t, ap.RefreshChannels(ctx))
+	assert.False(t, ap.channelsReady)
+	_, err = os.Stat(ap.channelsCachePath)
+	assert.True(t, os.IsNotExist(err), "an empty channel list must not be cached")
+}
@@ -0,0 +1,513 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider/edge"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const defaultFullRefreshInterval = 24 * time.Hour
+
+// errMembersChanged makes a delta refresh of users fall back to a full refresh, which is the
+// only way to learn about users that joined the workspace.
+var errMembersChanged = errors.New("workspace member count changed")
+
+// deltaClient is implemented by clients that can fetch only the users and channels that
+// changed since the last sync. The Web API has no such filters, so OAuth tokens always
+// refetch everything.
+type deltaClient interface {
+	SupportsDelta() bool
+	// GetUsersChangedContext returns users updated after the given "updated" timestamps, keyed by user ID.
+	GetUsersChangedContext(ctx context.Context, known map[string]int64) ([]slack.User, error)
+	// GetMemberCountContext returns the number of members of the workspace. Users that
+	// joined are not returned by GetUsersChangedContext, a different count tells they exist.
+	GetMemberCountContext(ctx context.Context) (int, error)
+	// GetChannelsChangedContext returns conversations of the user created or changed after
+	// since, and the IDs of its other conversations, nil if unknown.
+	GetChannelsChangedContext(ctx context.Context, since time.Time) (changed []slack.Channel, unchanged []string, err error)
+}
+
+// syncState is stored next to a cache file and records when the cache was last synced.
+type syncState struct {
+	LastFull time.Time `json:"last_full"`
+	LastSync time.Time `json:"last_sync"`
+	// Members is the member count of the workspace at the last full refresh of users
+	Members int `json:"members,omitempty"`
+}
+
+// getFullRefreshInterval returns how often delta refreshes fall back to a full refresh from
+// SLACK_MCP_FULL_REFRESH_INTERVAL env var or default (24h).
+// Supports formats: "24h", "90m", "86400" (seconds), "0" (disable delta refresh)
+// Negative values are rejected and fall back to default.
+func getFullRefreshInterval() time.Duration {
+	intervalStr := os.Getenv("SLACK_MCP_FULL_REFRESH_INTERVAL")
+	if intervalStr == "" {
+		return defaultFullRefreshInterval
+	}
+
+	if d, err := time.ParseDuration(intervalStr); err == nil {
+		if d < 0 {
+			return defaultFullRefreshInterval
+		}
+		return d
+	}
+
+	if secs, err := strconv.ParseInt(intervalStr, 10, 64); err == nil {
+		if secs < 0 {
+			return defaultFullRefreshInterval
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	return defaultFullRefreshInterval
+}
+
+func syncStatePath(cachePath string) string {
+	return strings.TrimSuffix(cachePath, ".json") + "_sync.json"
+}
+
+func loadSyncState(cachePath string) syncState {
+	var state syncState
+	if data, err := ReadCacheFile(syncStatePath(cachePath)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveSyncState records a successful sync that started at syncedAt.
+func (ap *ApiProvider) saveSyncState(cachePath string, syncedAt time.Time, full bool) {
+	state := loadSyncState(cachePath)
+	state.LastSync = syncedAt
+	if full {
+		state.LastFull = syncedAt
+		state.Members = 0
+	}
+	ap.writeSyncState(cachePath, state)
+}
+
+func (ap *ApiProvider) writeSyncState(cachePath string, state syncState) {
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = WriteCacheFile(syncStatePath(cachePath), data)
+	}
+	if err != nil {
+		ap.logger.Warn("Failed to write cache sync state",
+			zap.String("cache_file", cachePath),
+			zap.Error(err))
+	}
+}
+
+// saveMemberCount records the member count of the workspace after a full refresh of users,
+// for the next delta refresh to tell whether users joined. Without it the next refresh is
+// a full refresh again.
+func (ap *ApiProvider) saveMemberCount(ctx context.Context) {
+	if _, ok := ap.deltaSince(ap.usersCachePath); !ok {
+		return
+	}
+	members, err := ap.client.(deltaClient).GetMemberCountContext(ctx)
+	if err != nil {
+		ap.logger.Warn("Failed to fetch workspace member count", zap.Error(err))
+		return
+	}
+	state := loadSyncState(ap.usersCachePath)
+	state.Members = members
+	ap.writeSyncState(ap.usersCachePath, state)
+}
+
+// deltaSince returns when the cache at cachePath was last synced, if a delta refresh can be
+// used for it: the client supports it and the last full refresh is recent enough.
+func (ap *ApiProvider) deltaSince(cachePath string) (time.Time, bool) {
+	dc, ok := ap.client.(deltaClient)
+	if !ok || !dc.SupportsDelta() || ap.fullRefreshInterval <= 0 {
+		return time.Time{}, false
+	}
+	state := loadSyncState(cachePath)
+	if state.LastFull.IsZero() || state.LastSync.IsZero() || time.Since(state.LastFull) > ap.fullRefreshInterval {
+		return time.Time{}, false
+	}
+	return state.LastSync, true
+}
+
+// writeCache marshals v into the cache file at path.
+func (ap *ApiProvider) writeCache(path string, v any, count int, kind string) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		ap.logger.Error("Failed to marshal "+kind+" for cache", zap.Error(err))
+		return
+	}
+	if err := WriteCacheFile(path, data); err != nil {
+		ap.logger.Error("Failed to write cache file",
+			zap.String("cache_file", path),
+			zap.Error(err))
+		return
+	}
+	ap.logger.Info("Wrote "+kind+" to cache",
+		zap.Int("count", count),
+		zap.String("cache_file", path))
+}
+
+// deltaRefreshUsers fetches only users updated since they were cached and merges them with
+// base into a new snapshot. It fails with errMembersChanged if users may have joined since
+// the last full refresh: the member count of #general changed, or a cached conversation
+// has a member that is not a known user. A join offset by a deactivation in the same
+// interval, or a guest outside #general, is only seen through the conversations of the
+// user, otherwise it shows up at the next full refresh. It must be called with usersMu held.
+func (ap *ApiProvider) deltaRefreshUsers(ctx context.Context, base []slack.User) error {
+	started := time.Now()
+
+	dc := ap.client.(deltaClient)
+	members, err := dc.GetMemberCountContext(ctx)
+	if err != nil {
+		return err
+	}
+	if last := loadSyncState(ap.usersCachePath).Members; members != last {
+		return fmt.Errorf("%w from %d to %d", errMembersChanged, last, members)
+	}
+
+	known := make(map[string]int64, len(base))
+	for _, u := range base {
+		known[u.ID] = int64(u.Updated)
+	}
+	if id := ap.unknownMember(known); id != "" {
+		return fmt.Errorf("%w: %s is not a known user", errMembersChanged, id)
+	}
+	changed, err := dc.GetUsersChangedContext(ctx, known)
+	if err != nil {
+		return err
+	}
+
+	list := mergeUsers(base, changed)
+	ap.usersSnapshot.Store(newUsersSnapshot(list))
+
+	// Slack Connect users are not part of the users list, look up the ones we don't know yet.
+	connectUsers, err := ap.GetSlackConnect(ctx)
+	if err != nil {
+		return err
+	}
+	if len(connectUsers) > 0 {
+		list = mergeUsers(list, connectUsers)
+		ap.usersSnapshot.Store(newUsersSnapshot(list))
+	}
+
+	ap.writeCache(ap.usersCachePath, list, len(list), "users")
+	ap.saveSyncState(ap.usersCachePath, started, false)
+	ap.usersReady = true
+
+	ap.logger.Info("Delta refreshed users cache",
+		zap.Int("changed", len(changed)+len(connectUsers)),
+		zap.Int("count", len(list)))
+	return nil
+}
+
+// unknownMember returns a member of a cached conversation that is not in known, if any.
+// Slack Connect conversations are skipped, their external members are not workspace users.
+func (ap *ApiProvider) unknownMember(known map[string]int64) string {
+	for _, c := range ap.ProvideChannelsMaps().Channels {
+		if c.IsExtShared {
+			continue
+		}
+		if c.User != "" && c.User != "USLACKBOT" {
+			if _, ok := known[c.User]; !ok {
+				return c.User
+			}
+		}
+		for _, id := range c.Members {
+			if _, ok := known[id]; !ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// deltaRefreshChannels fetches only conversations changed since the last sync and merges
+// them with base into a new snapshot. Private conversations the user can no longer see are
+// dropped, public channels stay until the next full refresh, as the user may read them
+// without being a member. It must be called with channelsMu held.
+func (ap *ApiProvider) deltaRefreshChannels(ctx context.Context, base []Channel, since time.Time) error {
+	started := time.Now()
+
+	changed, unchanged, err := ap.client.(deltaClient).GetChannelsChangedContext(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	baseByID := make(map[string]Channel, len(base))
+	for _, c := range base {
+		baseByID[c.ID] = c
+	}
+	usersMap := ap.ProvideUsersMap().Users
+	updates := make([]Channel, 0, len(changed))
+	removed := make(map[string]bool)
+	for _, c := range changed {
+		if c.IsArchived {
+			removed[c.ID] = true
+			continue
+		}
+		members, numMembers := c.Members, c.NumMembers
+		// Changed channels may come without their members, keep the ones we know
+		if b, ok := baseByID[c.ID]; ok && len(members) == 0 {
+			members, numMembers = b.Members, b.MemberCount
+		}
+		ch := mapChannel(
+			c.ID, c.Name, c.NameNormalized, c.Topic.Value, c.Purpose.Value,
+			c.User, members, numMembers,
+			c.IsIM, c.IsMpIM, c.IsPrivate,
+			usersMap,
+		)
+		ch.IsExtShared = c.IsExtShared
+		updates = append(updates, ch)
+	}
+	archived := len(removed)
+
+	if unchanged != nil {
+		listed := make(map[string]bool, len(changed)+len(unchanged))
+		for _, c := range changed {
+			listed[c.ID] = true
+		}
+		for _, id := range unchanged {
+			listed[id] = true
+		}
+		for _, c := range base {
+			if !listed[c.ID] && (c.IsPrivate || c.IsMpIM || c.IsIM) {
+				removed[c.ID] = true
+			}
+		}
+	}
+
+	list := mergeChannels(base, updates, removed)
+	ap.channelsSnapshot.Store(newChannelsSnapshot(list, usersMap))
+
+	ap.writeCache(ap.channelsCachePath, list, len(list), "channels")
+	ap.saveSyncState(ap.channelsCachePath, started, false)
+	ap.channelsReady = true
+
+	ap.logger.Info("Delta refreshed channels cache",
+		zap.Int("changed", len(updates)),
+		zap.Int("archived", archived),
+		zap.Int("left", len(removed)-archived),
+		zap.Int("count", len(list)))
+	return nil
+}
+
+// mergeUsers returns base with users replaced or added from changed, in a stable order.
+func mergeUsers(base, changed []slack.User) []slack.User {
+	idx := make(map[string]int, len(base))
+	merged := make([]slack.User, len(base), len(base)+len(changed))
+	copy(merged, base)
+	for i, u := range merged {
+		idx[u.ID] = i
+	}
+	for _, u := range changed {
+		if i, ok := idx[u.ID]; ok {
+			merged[i] = u
+			continue
+		}
+		idx[u.ID] = len(merged)
+		merged = append(merged, u)
+	}
+	return merged
+}
+
+// mergeChannels returns base with channels replaced or added from changed and without the
+// removed ones, in a stable order.
+func mergeChannels(base, changed []Channel, removed map[string]bool) []Channel {
+	idx := make(map[string]int, len(base))
+	merged := make([]Channel, 0, len(base)+len(changed))
+	for _, c := range base {
+		if removed[c.ID] {
+			continue
+		}
+		idx[c.ID] = len(merged)
+		merged = append(merged, c)
+	}
+	for _, c := range changed {
+		if i, ok := idx[c.ID]; ok {
+			merged[i] = c
+			continue
+		}
+		idx[c.ID] = len(merged)
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func newUsersSnapshot(users []slack.User) *UsersCache {
+	snapshot := &UsersCache{
+		Users:    make(map[string]slack.User, len(users)),
+		UsersInv: make(map[string]string, len(users)),
+	}
+	for _, u := range users {
+		snapshot.Users[u.ID] = u
+		snapshot.UsersInv[u.Name] = u.ID
+	}
+	return snapshot
+}
+
+// newChannelsSnapshot builds a snapshot from cached channels, re-mapping DM names with the
+// current users cache.
+func newChannelsSnapshot(channels []Channel, usersMap map[string]slack.User) *ChannelsCache {
+	snapshot := &ChannelsCache{
+		Channels:    make(map[string]Channel, len(channels)),
+		ChannelsInv: make(map[string]string, len(channels)),
+	}
+	for _, c := range channels {
+		if c.IsIM {
+			shared := c.IsExtShared
+			c = mapChannel(
+				c.ID, "", "", c.Topic, c.Purpose,
+				c.User, c.Members, c.MemberCount,
+				c.IsIM, c.IsMpIM, c.IsPrivate,
+				usersMap,
+			)
+			c.IsExtShared = shared
+		}
+		snapshot.Channels[c.ID] = c
+		snapshot.ChannelsInv[c.Name] = c.ID
+	}
+	return snapshot
+}
+
+func (c *MCPSlackClient) SupportsDelta() bool {
+	return !c.isOAuth && c.edgeClient != nil
+}
+
+func (c *MCPSlackClient) GetUsersChangedContext(ctx context.Context, known map[string]int64) ([]slack.User, error) {
+	infos, err := c.edgeClient.GetUsersChanged(ctx, known)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]slack.User, 0, len(infos))
+	for _, u := range infos {
+		users = append(users, userFromEdge(u))
+	}
+	return users, nil
+}
+
+func (c *MCPSlackClient) GetMemberCountContext(ctx context.Context) (int, error) {
+	channelID, err := c.generalChannelID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	info, err := c.slackClient.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{
+		ChannelID:         channelID,
+		IncludeNumMembers: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return info.NumMembers, nil
+}
+
+// generalChannelID returns the ID of the workspace's general channel, which every member
+// belongs to, looked up once.
+func (c *MCPSlackClient) generalChannelID(ctx context.Context) (string, error) {
+	c.generalMu.Lock()
+	defer c.generalMu.Unlock()
+	if c.generalID != "" {
+		return c.generalID, nil
+	}
+	boot, err := c.edgeClient.ClientUserBoot(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, bc := range boot.Channels {
+		if bc.IsGeneral {
+			c.generalID = bc.ID
+			return c.generalID, nil
+		}
+	}
+	return "", errors.New("the general channel of the workspace was not found")
+}
+
+func (c *MCPSlackClient) GetChannelsChangedContext(ctx context.Context, since time.Time) ([]slack.Channel, []string, error) {
+	boot, err := c.edgeClient.ClientUserBootSince(ctx, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unchanged []string
+	if boot.UnchangedChannelIDS != nil {
+		unchanged = make([]string, 0, len(boot.UnchangedChannelIDS))
+		for _, id := range boot.UnchangedChannelIDS {
+			if s, ok := id.(string); ok {
+				unchanged = append(unchanged, s)
+			}
+		}
+	}
+	channels := make([]slack.Channel, 0, len(boot.Channels)+len(boot.IMs))
+	for _, bc := range boot.Channels {
+		channels = append(channels, slack.Channel{
+			GroupConversation: slack.GroupConversation{
+				Conversation: slack.Conversation{
+					ID:             bc.ID,
+					Created:        slack.JSONTime(bc.Created),
+					IsIM:           bc.IsIM,
+					IsMpIM:         bc.IsMpim,
+					IsPrivate:      bc.IsPrivate,
+					NameNormalized: bc.NameNormalized,
+					NumMembers:     len(bc.Members),
+					IsExtShared:    bc.IsEXTShared,
+				},
+				Name:       bc.Name,
+				IsArchived: bc.IsArchived,
+				Members:    bc.Members,
+				Topic:      slack.Topic{Value: bc.Topic.Value},
+				Purpose:    slack.Purpose{Value: bc.Purpose.Value},
+			},
+		})
+	}
+	// DMs are always listed in full, there are few of them and they carry no update time.
+	for _, im := range boot.IMs {
+		channels = append(channels, slack.Channel{
+			GroupConversation: slack.GroupConversation{
+				Conversation: slack.Conversation{
+					ID:          im.ID,
+					IsIM:        true,
+					User:        im.User,
+					IsExtShared: im.IsExtShared,
+				},
+				IsArchived: im.IsArchived,
+				Members:    []string{im.User},
+			},
+		})
+	}
+	return channels, unchanged, nil
+}
+
+func userFromEdge(u edge.UserInfo) slack.User {
+	return slack.User{
+		ID:        u.ID,
+		TeamID:    u.TeamID,
+		Name:      u.Name,
+		Deleted:   u.Deleted,
+		Color:     u.Color,
+		RealName:  u.Profile.RealName,
+		IsBot:     u.IsBot,
+		IsAppUser: u.IsAppUser,
+		Updated:   slack.JSONTime(u.Updated),
+		Profile: slack.UserProfile{
+			RealName:              u.Profile.RealName,
+			RealNameNormalized:    u.Profile.RealNameNormalized,
+			DisplayName:           u.Profile.DisplayName,
+			DisplayNameNormalized: u.Profile.DisplayNameNormalized,
+			Email:                 u.Profile.Email,
+			Title:                 u.Profile.Title,
+			Phone:                 u.Profile.Phone,
+			Skype:                 u.Profile.Skype,
+			StatusText:            u.Profile.StatusText,
+			StatusEmoji:           u.Profile.StatusEmoji,
+			StatusExpiration:      int(u.Profile.StatusExpiration),
+			AvatarHash:            u.Profile.AvatarHash,
+			Team:                  u.Profile.Team,
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// deltaTestClient serves full refreshes from an offline export and records delta calls.
+type deltaTestClient struct {
+	*OfflineClient
+
+	changedUsers      []slack.User
+	members           int
+	changedChannels   []slack.Channel
+	unchangedChannels []string
+
+	fullUserFetches int
+	knownUsers      map[string]int64
+	channelsSince   time.Time
+}
+
+func (c *deltaTestClient) SupportsDelta() bool { return true }
+
+func (c *deltaTestClient) GetUsersContext(ctx context.Context, options ...slack.GetUsersOption) ([]slack.User, error) {
+	c.fullUserFetches++
+	return c.OfflineClient.GetUsersContext(ctx, options...)
+}
+
+func (c *deltaTestClient) GetUsersChangedContext(_ context.Context, known map[string]int64) ([]slack.User, error) {
+	c.knownUsers = known
+	return c.changedUsers, nil
+}
+
+func (c *deltaTestClient) GetMemberCountContext(context.Context) (int, error) {
+	return c.members, nil
+}
+
+func (c *deltaTestClient) GetChannelsChangedContext(_ context.Context, since time.Time) ([]slack.Channel, []string, error) {
+	c.channelsSince = since
+	return c.changedChannels, c.unchangedChannels, nil
+}
+
+func newDeltaTestProvider(t *testing.T, client SlackAPI) *ApiProvider {
+	dir := t.TempDir()
+	ap := &ApiProvider{
+		client:              client,
+		logger:              zap.NewNop(),
+		rateLimiter:         rate.NewLimiter(rate.Inf, 1),
+		fullRefreshInterval: time.Hour,
+		usersCachePath:      filepath.Join(dir, "users_cache.json"),
+		channelsCachePath:   filepath.Join(dir, "channels_cache_v2.json"),
+	}
+	ap.usersSnapshot.Store(&UsersCache{Users: map[string]slack.User{}, UsersInv: map[string]string{}})
+	ap.channelsSnapshot.Store(&ChannelsCache{Channels: map[string]Channel{}, ChannelsInv: map[string]string{}})
+	return ap
+}
+
+func TestGetFullRefreshInterval(t *testing.T) {
+	tests := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{"", defaultFullRefreshInterval},
+		{"6h", 6 * time.Hour},
+		{"3600", time.Hour},
+		{"0", 0},
+		{"-1h", defaultFullRefreshInterval},
+		{"invalid", defaultFullRefreshInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.envValue, func(t *testing.T) {
+			t.Setenv("SLACK_MCP_FULL_REFRESH_INTERVAL", tt.envValue)
+			assert.Equal(t, tt.expected, getFullRefreshInterval())
+		})
+	}
+}
+
+func TestMergeUsers(t *testing.T) {
+	base := []slack.User{{ID: "U1", Name: "alice"}, {ID: "U2", Name: "bob"}}
+	merged := mergeUsers(base, []slack.User{{ID: "U2", Name: "robert"}, {ID: "U3", Name: "carol"}})
+
+	require.Len(t, merged, 3)
+	assert.Equal(t, "alice", merged[0].Name)
+	assert.Equal(t, "robert", merged[1].Name)
+	assert.Equal(t, "carol", merged[2].Name)
+	assert.Equal(t, "bob", base[1].Name, "base must not be modified")
+}
+
+func TestMergeChannels(t *testing.T) {
+	base := []Channel{{ID: "C1", Name: "#general"}, {ID: "C2", Name: "#old"}, {ID: "C3", Name: "#random"}}
+	merged := mergeChannels(base,
+		[]Channel{{ID: "C3", Name: "#renamed"}, {ID: "C4", Name: "#new"}},
+		map[string]bool{"C2": true},
+	)
+
+	var names []string
+	for _, c := range merged {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"#general", "#renamed", "#new"}, names)
+}
+
+func TestDeltaRefresh(t *testing.T) {
+	ctx := context.Background()
+	offline, err := LoadOffline(ctx, newTestExport(t), zap.NewNop())
+	require.NoError(t, err)
+	client := &deltaTestClient{OfflineClient: offline, members: 2}
+	ap := newDeltaTestProvider(t, client)
+
+	t.Run("first refresh is a full refresh", func(t *testing.T) {
+		require.NoError(t, ap.RefreshUsers(ctx))
+		require.NoError(t, ap.RefreshChannels(ctx))
+		assert.Equal(t, 1, client.fullUserFetches)
+		assert.Nil(t, client.knownUsers)
+
+		state := loadSyncState(ap.usersCachePath)
+		assert.False(t, state.LastFull.IsZero())
+		assert.Equal(t, state.LastFull, state.LastSync)
+		assert.Equal(t, 2, state.Members)
+	})
+
+	t.Run("forced refresh only fetches changes", func(t *testing.T) {
+		client.changedUsers = []slack.User{{ID: "U2", Name: "robert", RealName: "Robert", Updated: 1700000100}}
+		require.NoError(t, ap.ForceRefreshUsers(ctx))
+
+		assert.Equal(t, 1, client.fullUserFetches)
+		assert.Len(t, client.knownUsers, 2)
+		users := ap.ProvideUsersMap()
+		assert.Equal(t, "robert", users.Users["U2"].Name)
+		assert.Equal(t, "U2", users.UsersInv["robert"])
+		assert.Equal(t, "alice", users.Users["U1"].Name)
+
+		var cached []slack.User
+		data, err := os.ReadFile(ap.usersCachePath)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &cached))
+		assert.Len(t, cached, 2)
+
+		state := loadSyncState(ap.usersCachePath)
+		assert.True(t, state.LastSync.After(state.LastFull))
+	})
+
+	t.Run("channel changes are merged and member counts kept", func(t *testing.T) {
+		lastSync := loadSyncState(ap.channelsCachePath).LastSync
+		client.changedChannels = []slack.Channel{
+			{GroupConversation: slack.GroupConversation{Name: "random", Conversation: slack.Conversation{ID: "C2", NameNormalized: "random"}}},
+			{GroupConversation: slack.GroupConversation{Name: "general", Topic: slack.Topic{Value: "news"}, Conversation: slack.Conversation{ID: "C1", NameNormalized: "general"}}},
+			{GroupConversation: slack.GroupConversation{Name: "secret", Members: []string{"U1"}, Conversation: slack.Conversation{ID: "G1", NameNormalized: "secret", IsPrivate: true, NumMembers: 1}}},
+		}
+		ap.lastForcedChannelsRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshChannels(ctx))
+
+		assert.Equal(t, lastSync, client.channelsSince)
+		channels := ap.ProvideChannelsMaps()
+		assert.Equal(t, "C2", channels.ChannelsInv["#random"])
+		assert.Equal(t, "news", channels.Channels["C1"].Topic)
+		assert.Equal(t, 2, channels.Channels["C1"].MemberCount)
+		assert.Equal(t, []string{"U1", "U2"}, channels.Channels["C1"].Members)
+		assert.Contains(t, channels.Channels, "G1")
+	})
+
+	t.Run("archived and left channels are dropped", func(t *testing.T) {
+		client.changedChannels = []slack.Channel{
+			{GroupConversation: slack.GroupConversation{IsArchived: true, Conversation: slack.Conversation{ID: "C1"}}},
+		}
+		client.unchangedChannels = []string{"C3"}
+		ap.lastForcedChannelsRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshChannels(ctx))
+
+		channels := ap.ProvideChannelsMaps()
+		assert.NotContains(t, channels.Channels, "C1")
+		assert.NotContains(t, channels.Channels, "G1", "private channels not listed anymore are left")
+		assert.Contains(t, channels.Channels, "C2", "public channels stay until the next full refresh")
+	})
+
+	t.Run("changed member count falls back to full refresh", func(t *testing.T) {
+		client.members = 3
+		ap.lastForcedUsersRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshUsers(ctx))
+
+		assert.Equal(t, 2, client.fullUserFetches)
+		assert.Equal(t, 3, loadSyncState(ap.usersCachePath).Members)
+	})
+
+	t.Run("join offset by a leave falls back to full refresh", func(t *testing.T) {
+		client.changedChannels = []slack.Channel{
+			{GroupConversation: slack.GroupConversation{Name: "random", Members: []string{"U1", "U3"}, Conversation: slack.Conversation{ID: "C2", NameNormalized: "random", NumMembers: 2}}},
+		}
+		client.unchangedChannels = nil
+		ap.lastForcedChannelsRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshChannels(ctx))
+
+		ap.lastForcedUsersRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshUsers(ctx))
+		assert.Equal(t, 3, client.fullUserFetches, "the member count is unchanged but U3 is unknown")
+	})
+
+	t.Run("stale full refresh falls back to full refresh", func(t *testing.T) {
+		ap.fullRefreshInterval = time.Nanosecond
+		ap.lastForcedUsersRefresh = time.Time{}
+		require.NoError(t, ap.ForceRefreshUsers(ctx))
+		assert.Equal(t, 4, client.fullUserFetches)
+	})
+}
@@ -23,6 +23,13 @@ type clientUserBootForm struct {
 
 // ClientUserBoot calls the client.userBoot API.
 func (cl *Client) ClientUserBoot(ctx context.Context) (*ClientUserBootResponse, error) {
+	return cl.ClientUserBootSince(ctx, time.Time{})
+}
+
+// ClientUserBootSince calls the client.userBoot API, only returning channels updated
+// after minChannelUpdated, if set. IDs of the other channels are listed in
+// UnchangedChannelIDS.
+func (cl *Client) ClientUserBootSince(ctx context.Context, minChannelUpdated time.Time) (*ClientUserBootResponse, error) {
 	ctx, task := trace.NewTask(ctx, "ClientUserBoot")
 	defer task.End()
 
@@ -34,6 +41,9 @@ func (cl *Client) ClientUserBoot(ctx context.Context) (*ClientUserBootResponse,
 		BuildVersionTS:             future.Unix(),
 		WebClientFields:            webclientReason("initial-data"),
 	}
+	if !minChannelUpdated.IsZero() {
+		form.MinChannelUpdated = minChannelUpdated.UnixMilli()
+	}
 	var ub ClientUserBootResponse
 	resp, err := cl.PostForm(ctx, "client.userBoot", values(form, true))
 	if err != nil {
@@ -119,7 +119,41 @@ func (cl *Client) GetUsers(ctx context.Context, userID ...string) ([]UserInfo, e
 	for _, id := range userID {
 		updatedIds[id] = 0
 	}
+	return cl.getUsers(ctx, updatedIds)
+}
+
+// GetUsersChanged returns the users whose profile was updated after the
+// given "updated" timestamp, keyed by user ID.  Users that did not change are
+// not returned, this is how the Slack client keeps its user cache fresh.
+func (cl *Client) GetUsersChanged(ctx context.Context, known map[string]int64) ([]UserInfo, error) {
+	const batchSize = 500
+
+	ids := make([]string, 0, len(known))
+	for id := range known {
+		ids = append(ids, id)
+	}
+	lim := limiter.Tier3.Limiter()
+	var users []UserInfo
+	for start := 0; start < len(ids); start += batchSize {
+		if start > 0 {
+			if err := lim.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		batch := make(map[string]int64, batchSize)
+		for _, id := range ids[start:min(start+batchSize, len(ids))] {
+			batch[id] = known[id]
+		}
+		changed, err := cl.getUsers(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, changed...)
+	}
+	return users, nil
+}
 
+func (cl *Client) getUsers(ctx context.Context, updatedIds map[string]int64) ([]UserInfo, error) {
 	lim := limiter.Tier3.Limiter()
 	var users []UserInfo
 	for {
@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// encryptedMagic starts every encrypted cache file, followed by the nonce and the sealed data.
+const encryptedMagic = "SLACKMCP-ENC1\n"
+
+const cacheKeySize = 32
+
+// ErrCacheEncrypted is returned when reading an encrypted cache file without a key.
+var ErrCacheEncrypted = errors.New("cache file is encrypted, set SLACK_MCP_CACHE_KEY or SLACK_MCP_CACHE_KEY_FILE to read it")
+
+// getCacheKey returns the key cache files are encrypted with from SLACK_MCP_CACHE_KEY or the
+// file named by SLACK_MCP_CACHE_KEY_FILE, or nil if encryption is disabled. The key is 32
+// bytes, encoded as base64 or hex, e.g. from `openssl rand -base64 32`. A key file may also
+// hold the 32 raw bytes.
+func getCacheKey() ([]byte, error) {
+	encoded := os.Getenv("SLACK_MCP_CACHE_KEY")
+	source := "SLACK_MCP_CACHE_KEY"
+	if keyFile := os.Getenv("SLACK_MCP_CACHE_KEY_FILE"); keyFile != "" {
+		if encoded != "" {
+			return nil, errors.New("only one of SLACK_MCP_CACHE_KEY and SLACK_MCP_CACHE_KEY_FILE can be set")
+		}
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SLACK_MCP_CACHE_KEY_FILE: %w", err)
+		}
+		if len(data) == cacheKeySize {
+			return data, nil
+		}
+		encoded = string(data)
+		source = "SLACK_MCP_CACHE_KEY_FILE"
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == cacheKeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == cacheKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s must be %d bytes encoded as base64 or hex", source, cacheKeySize)
+}
+
+// cachedAEAD is the AEAD of the cache key, loaded once for the key settings it was
+// loaded from, so reads and writes do not read the key file again.
+var cachedAEAD struct {
+	sync.Mutex
+	key, keyFile string
+	loaded       bool
+	aead         cipher.AEAD
+}
+
+// ValidateCacheKey loads the cache key, so a misconfigured key fails startup instead of
+// every cache write.
+func ValidateCacheKey() error {
+	_, err := cacheAEAD()
+	return err
+}
+
+func cacheAEAD() (cipher.AEAD, error) {
+	key, keyFile := os.Getenv("SLACK_MCP_CACHE_KEY"), os.Getenv("SLACK_MCP_CACHE_KEY_FILE")
+
+	cachedAEAD.Lock()
+	defer cachedAEAD.Unlock()
+	if cachedAEAD.loaded && cachedAEAD.key == key && cachedAEAD.keyFile == keyFile {
+		return cachedAEAD.aead, nil
+	}
+
+	aead, err := newCacheAEAD()
+	if err != nil {
+		return nil, err
+	}
+	cachedAEAD.key, cachedAEAD.keyFile = key, keyFile
+	cachedAEAD.loaded, cachedAEAD.aead = true, aead
+	return aead, nil
+}
+
+func newCacheAEAD() (cipher.AEAD, error) {
+	key, err := getCacheKey()
+	if err != nil || key == nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+// sealCacheData encrypts data with AES-256-GCM if a cache key is set.
+func sealCacheData(data []byte) ([]byte, error) {
+	aead, err := cacheAEAD()
+	if err != nil || aead == nil {
+		return data, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(data)+aead.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, nil), nil
+}
+
+// openCacheData decrypts data written by sealCacheData. Plaintext is returned as is, so
+// caches written before encryption was enabled stay readable until they are migrated.
+func openCacheData(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+	aead, err := cacheAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nil, ErrCacheEncrypted
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted cache file is truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt cache file, was it written with another key? %w", err)
+	}
+	return plain, nil
+}
+
+// ReadCacheFile reads a cache file written by WriteCacheFile.
+func ReadCacheFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return openCacheData(data)
+}
+
+// WriteCacheFile atomically writes a cache file readable only by the current user,
+// encrypted if a cache key is set.
+func WriteCacheFile(path string, data []byte) error {
+	sealed, err := sealCacheData(data)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, sealed, 0600)
+}
+
+// MigrateCacheFile makes an existing cache file readable only by the current user and
+// encrypts it if a cache key is set and it was written in plaintext. A missing file is
+// not an error.
+func MigrateCacheFile(path string) (migrated bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+
+	aead, err := cacheAEAD()
+	if err != nil {
+		return false, err
+	}
+	if aead != nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		if !isEncrypted(data) {
+			return true, WriteCacheFile(path, data)
+		}
+	}
+	if info.Mode().Perm() != 0600 {
+		return false, os.Chmod(path, 0600)
+	}
+	return false, nil
+}
+
+// migrateCacheFiles runs MigrateCacheFile on each of paths, logging the outcome.
+func migrateCacheFiles(logger *zap.Logger, paths ...string) {
+	for _, path := range paths {
+		migrated, err := MigrateCacheFile(path)
+		if err != nil {
+			logger.Warn("Failed to migrate cache file", zap.String("cache_file", path), zap.Error(err))
+			continue
+		}
+		if migrated {
+			logger.Info("Encrypted plaintext cache file", zap.String("cache_file", path))
+		}
+	}
+}
+
+// migrateCacheDir runs MigrateCacheFile on the files in dir, skipping lock and temporary files.
+func migrateCacheDir(logger *zap.Logger, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	migrateCacheFiles(logger, paths...)
+}
+
+// migrateCaches encrypts and restricts the users and channels caches, their sync state and
+// the remembered workspaces, so existing plaintext caches are protected on first start.
+func (ap *ApiProvider) migrateCaches() {
+	migrateCacheFiles(ap.logger,
+		ap.usersCachePath,
+		syncStatePath(ap.usersCachePath),
+		ap.channelsCachePath,
+		syncStatePath(ap.channelsCachePath),
+		workspacesPath(),
+	)
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testCacheKey = []byte("0123456789abcdef0123456789abcdef")
+
+func setTestCacheKey(t *testing.T) {
+	t.Setenv("SLACK_MCP_CACHE_KEY", base64.StdEncoding.EncodeToString(testCacheKey))
+	t.Setenv("SLACK_MCP_CACHE_KEY_FILE", "")
+}
+
+func TestGetCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "raw.key")
+	require.NoError(t, os.WriteFile(rawFile, testCacheKey, 0600))
+	encodedFile := filepath.Join(dir, "encoded.key")
+	require.NoError(t, os.WriteFile(encodedFile, []byte(hex.EncodeToString(testCacheKey)+"\n"), 0600))
+
+	tests := []struct {
+		name    string
+		key     string
+		keyFile string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "base64", key: base64.StdEncoding.EncodeToString(testCacheKey), want: testCacheKey},
+		{name: "hex", key: hex.EncodeToString(testCacheKey), want: testCacheKey},
+		{name: "raw key file", keyFile: rawFile, want: testCacheKey},
+		{name: "encoded key file", keyFile: encodedFile, want: testCacheKey},
+		{name: "too short", key: base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+		{name: "missing key file", keyFile: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "both set", key: hex.EncodeToString(testCacheKey), keyFile: rawFile, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SLACK_MCP_CACHE_KEY", tt.key)
+			t.Setenv("SLACK_MCP_CACHE_KEY_FILE", tt.keyFile)
+			key, err := getCacheKey()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateCacheKey())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
+
+func TestCacheFileEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users_cache.json")
+	secret := `[{"id":"U1","profile":{"email":"alice@example.com"}}]`
+
+	setTestCacheKey(t)
+	require.NoError(t, WriteCacheFile(path, []byte(secret)))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(raw), encryptedMagic))
+	assert.NotContains(t, string(raw), "alice@example.com")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := ReadCacheFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, secret, string(data))
+
+	t.Run("without key", func(t *testing.T) {
+		t.Setenv("SLACK_MCP_CACHE_KEY", "")
+		_, err := ReadCacheFile(path)
+		assert.ErrorIs(t, err, ErrCacheEncrypted)
+	})
+
+	t.Run("with another key", func(t *testing.T) {
+		t.Setenv("SLACK_MCP_CACHE_KEY", hex.EncodeToString([]byte("fedcba9876543210fedcba9876543210")))
+		_, err := ReadCacheFile(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrateCacheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users_cache.json")
+	require.NoError(t, os.WriteFile(path, []byte("plain"), 0644))
+
+	t.Run("without key only restricts the mode", func(t *testing.T) {
+		t.Setenv("SLACK_MCP_CACHE_KEY", "")
+		migrated, err := MigrateCacheFile(path)
+		require.NoError(t, err)
+		assert.False(t, migrated)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("with key encrypts plaintext once", func(t *testing.T) {
+		setTestCacheKey(t)
+		migrated, err := MigrateCacheFile(path)
+		require.NoError(t, err)
+		assert.True(t, migrated)
+
+		migrated, err = MigrateCacheFile(path)
+		require.NoError(t, err)
+		assert.False(t, migrated)
+
+		data, err := ReadCacheFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "plain", string(data))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		migrated, err := MigrateCacheFile(filepath.Join(dir, "missing.json"))
+		require.NoError(t, err)
+		assert.False(t, migrated)
+	})
+}
+
+func TestMigrateCaches(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setTestCacheKey(t)
+
+	ap := newDeltaTestProvider(t, nil)
+	data, err := json.Marshal([]slack.User{{ID: "U1", Name: "alice"}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ap.usersCachePath, data, 0644))
+
+	ap.migrateCaches()
+
+	raw, err := os.ReadFile(ap.usersCachePath)
+	require.NoError(t, err)
+	assert.True(t, isEncrypted(raw))
+
+	// The migrated cache is used as is, no client is needed
+	require.NoError(t, ap.refreshUsersInternal(context.Background(), false))
+	assert.Equal(t, "U1", ap.ProvideUsersMap().UsersInv["alice"])
+}
+
+func TestArchiveMigratesFiles(t *testing.T) {
+	setTestCacheKey(t)
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "embeddings-model.gob")
+	require.NoError(t, os.WriteFile(plain, []byte("vectors"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users_cache.json.lock"), nil, 0600))
+
+	migrateCacheDir(zap.NewNop(), dir)
+
+	raw, err := os.ReadFile(plain)
+	require.NoError(t, err)
+	assert.True(t, isEncrypted(raw))
+	raw, err = os.ReadFile(filepath.Join(dir, "users_cache.json.lock"))
+	require.NoError(t, err)
+	assert.Empty(t, raw, "lock files are left alone")
+}
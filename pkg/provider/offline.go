@@ -0,0 +1,520 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/limiter"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider/edge"
+	"github.com/rusq/slackdump/v3/source"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const defaultOfflineHistoryLimit = 100
+
+var (
+	ErrOfflineReadOnly    = errors.New("the offline data source is read-only")
+	ErrOfflineUnsupported = errors.New("not supported by the offline data source")
+)
+
+// OfflineClient implements SlackAPI on top of a Slack export (ZIP or directory) or a
+// slackdump archive, so the regular handlers work without any token. The source is read
+// into memory once, write methods fail with ErrOfflineReadOnly.
+type OfflineClient struct {
+	name     string
+	auth     *slack.AuthTestResponse
+	users    []slack.User
+	channels []slack.Channel
+	// messages holds the top-level messages of every channel, oldest first.
+	messages map[string][]slack.Message
+	// threads holds thread replies without their parent, oldest first, keyed by channel and thread ts.
+	threads map[string]map[string][]slack.Message
+}
+
+// LoadOffline reads everything from the export or slackdump archive at path.
+func LoadOffline(ctx context.Context, path string, logger *zap.Logger) (*OfflineClient, error) {
+	src, err := source.Load(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open offline source %q: %w", path, err)
+	}
+	defer src.Close()
+
+	c := &OfflineClient{
+		name:     filepath.Base(path),
+		messages: make(map[string][]slack.Message),
+		threads:  make(map[string]map[string][]slack.Message),
+	}
+
+	srcUsers, err := src.Users(ctx)
+	if err != nil && !isOfflineNotFound(err) {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	if c.users, err = convertJSON[[]slack.User](srcUsers); err != nil {
+		return nil, err
+	}
+
+	srcChannels, err := src.Channels(ctx)
+	if err != nil && !isOfflineNotFound(err) {
+		return nil, fmt.Errorf("failed to read channels: %w", err)
+	}
+	if c.channels, err = convertJSON[[]slack.Channel](srcChannels); err != nil {
+		return nil, err
+	}
+	// Exports only carry the raw channel fields, fill in what the live API computes.
+	for i := range c.channels {
+		ch := &c.channels[i]
+		if ch.NameNormalized == "" {
+			ch.NameNormalized = ch.Name
+		}
+		if ch.NumMembers == 0 {
+			ch.NumMembers = len(ch.Members)
+		}
+	}
+
+	var total int
+	for _, ch := range c.channels {
+		n, err := c.loadChannel(ctx, src, ch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read messages of %s: %w", ch.ID, err)
+		}
+		total += n
+	}
+
+	if info, err := src.WorkspaceInfo(ctx); err == nil && info != nil && info.URL != "" {
+		auth, err := convertJSON[slack.AuthTestResponse](info)
+		if err != nil {
+			return nil, err
+		}
+		c.auth = &auth
+	} else {
+		// Exports carry no workspace information, the URL only has to be parseable.
+		c.auth = &slack.AuthTestResponse{
+			URL:  "https://offline.slack.com/",
+			Team: c.name,
+			User: "offline",
+		}
+	}
+
+	logger.Info("Loaded offline data source",
+		zap.String("path", path),
+		zap.String("type", src.Type().String()),
+		zap.Int("users", len(c.users)),
+		zap.Int("channels", len(c.channels)),
+		zap.Int("messages", total))
+	return c, nil
+}
+
+func (c *OfflineClient) loadChannel(ctx context.Context, src source.Sourcer, channelID string) (int, error) {
+	it, err := src.AllMessages(ctx, channelID)
+	if err != nil {
+		if isOfflineNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var msgs []slack.Message
+	for m, err := range it {
+		if err != nil {
+			return 0, err
+		}
+		msg, err := convertJSON[slack.Message](m)
+		if err != nil {
+			return 0, err
+		}
+		msgs = append(msgs, msg)
+	}
+	sortMessages(msgs)
+	c.messages[channelID] = msgs
+	n := len(msgs)
+
+	threads := make(map[string][]slack.Message)
+	for _, m := range msgs {
+		if m.ThreadTimestamp == "" || m.ThreadTimestamp != m.Timestamp {
+			continue
+		}
+		it, err := src.AllThreadMessages(ctx, channelID, m.ThreadTimestamp)
+		if err != nil {
+			if isOfflineNotFound(err) {
+				continue
+			}
+			return 0, err
+		}
+		var replies []slack.Message
+		for r, err := range it {
+			if err != nil {
+				return 0, err
+			}
+			if r.Timestamp == m.Timestamp {
+				continue
+			}
+			reply, err := convertJSON[slack.Message](r)
+			if err != nil {
+				return 0, err
+			}
+			replies = append(replies, reply)
+		}
+		sortMessages(replies)
+		threads[m.ThreadTimestamp] = replies
+		n += len(replies)
+	}
+	c.threads[channelID] = threads
+	return n, nil
+}
+
+// teamID identifies the source for cache namespacing. Without workspace information
+// the path, size and modification time are hashed, so a replaced file is not served from stale caches.
+func (c *OfflineClient) teamID(path string) string {
+	if c.auth.TeamID != "" {
+		return c.auth.TeamID
+	}
+	h := sha256.New()
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	h.Write([]byte(path))
+	if fi, err := os.Stat(path); err == nil {
+		fmt.Fprintf(h, "|%d|%d", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return "offline-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+func (c *OfflineClient) AuthTest() (*slack.AuthTestResponse, error) {
+	return c.auth, nil
+}
+
+func (c *OfflineClient) AuthTestContext(_ context.Context) (*slack.AuthTestResponse, error) {
+	return c.auth, nil
+}
+
+func (c *OfflineClient) GetUsersContext(_ context.Context, _ ...slack.GetUsersOption) ([]slack.User, error) {
+	return c.users, nil
+}
+
+// GetUsersInfoContext accepts comma-separated IDs like the Slack API does.
+func (c *OfflineClient) GetUsersInfoContext(_ context.Context, users ...string) (*[]slack.User, error) {
+	wanted := make(map[string]struct{})
+	for _, u := range users {
+		for _, id := range strings.Split(u, ",") {
+			wanted[strings.TrimSpace(id)] = struct{}{}
+		}
+	}
+	var res []slack.User
+	for _, u := range c.users {
+		if _, ok := wanted[u.ID]; ok {
+			res = append(res, u)
+		}
+	}
+	return &res, nil
+}
+
+func (c *OfflineClient) UsersSearch(_ context.Context, query string, count int) ([]slack.User, error) {
+	query = strings.ToLower(query)
+	var res []slack.User
+	for _, u := range c.users {
+		if count > 0 && len(res) >= count {
+			break
+		}
+		for _, field := range []string{u.Name, u.RealName, u.Profile.DisplayName, u.Profile.Email} {
+			if field != "" && strings.Contains(strings.ToLower(field), query) {
+				res = append(res, u)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (c *OfflineClient) GetConversationsContext(_ context.Context, params *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	types := make(map[string]bool)
+	for _, t := range params.Types {
+		types[t] = true
+	}
+	if len(types) == 0 {
+		types["public_channel"] = true
+	}
+
+	var res []slack.Channel
+	for _, ch := range c.channels {
+		if params.ExcludeArchived && ch.IsArchived {
+			continue
+		}
+		switch {
+		case ch.IsIM:
+			if !types["im"] {
+				continue
+			}
+		case ch.IsMpIM:
+			if !types["mpim"] {
+				continue
+			}
+		case ch.IsPrivate:
+			if !types["private_channel"] {
+				continue
+			}
+		default:
+			if !types["public_channel"] {
+				continue
+			}
+		}
+		res = append(res, ch)
+	}
+	return res, "", nil
+}
+
+// GetConversationHistoryContext returns top-level messages newest first, honoring the
+// oldest/latest bounds, the limit and cursors like conversations.history.
+func (c *OfflineClient) GetConversationHistoryContext(_ context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	msgs, ok := c.messages[params.ChannelID]
+	if !ok && !c.hasChannel(params.ChannelID) {
+		return nil, errors.New("channel_not_found")
+	}
+
+	var selected []slack.Message
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if inRange(msgs[i].Timestamp, params.Oldest, params.Latest, params.Inclusive) {
+			selected = append(selected, msgs[i])
+		}
+	}
+
+	page, next, err := paginateOffline(selected, params.Cursor, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &slack.GetConversationHistoryResponse{
+		Messages: page,
+		HasMore:  next != "",
+	}
+	resp.Ok = true
+	resp.ResponseMetaData.NextCursor = next
+	return resp, nil
+}
+
+// GetConversationRepliesContext returns the parent followed by its replies, oldest first.
+func (c *OfflineClient) GetConversationRepliesContext(_ context.Context, params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error) {
+	var parent *slack.Message
+	for i, m := range c.messages[params.ChannelID] {
+		if m.Timestamp == params.Timestamp {
+			parent = &c.messages[params.ChannelID][i]
+			break
+		}
+	}
+	if parent == nil {
+		return nil, false, "", errors.New("thread_not_found")
+	}
+
+	var selected []slack.Message
+	for _, m := range append([]slack.Message{*parent}, c.threads[params.ChannelID][params.Timestamp]...) {
+		if inRange(m.Timestamp, params.Oldest, params.Latest, params.Inclusive) {
+			selected = append(selected, m)
+		}
+	}
+
+	page, next, err := paginateOffline(selected, params.Cursor, params.Limit)
+	if err != nil {
+		return nil, false, "", err
+	}
+	return page, next != "", next, nil
+}
+
+func (c *OfflineClient) SearchContext(_ context.Context, _ string, _ slack.SearchParameters) (*slack.SearchMessages, *slack.SearchFiles, error) {
+	return nil, nil, ErrOfflineUnsupported
+}
+
+func (c *OfflineClient) GetFileInfoContext(_ context.Context, _ string, _, _ int) (*slack.File, []slack.Comment, *slack.Paging, error) {
+	return nil, nil, nil, ErrOfflineUnsupported
+}
+
+func (c *OfflineClient) GetFileContext(_ context.Context, _ string, _ io.Writer) error {
+	return ErrOfflineUnsupported
+}
+
+func (c *OfflineClient) GetEmojiContext(_ context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (c *OfflineClient) ClientUserBoot(_ context.Context) (*edge.ClientUserBootResponse, error) {
+	return &edge.ClientUserBootResponse{}, nil
+}
+
+func (c *OfflineClient) GetUserGroupsContext(_ context.Context, _ ...slack.GetUserGroupsOption) ([]slack.UserGroup, error) {
+	return nil, nil
+}
+
+func (c *OfflineClient) GetUserGroupMembersContext(_ context.Context, _ string, _ ...slack.GetUserGroupMembersOption) ([]string, error) {
+	return nil, ErrOfflineUnsupported
+}
+
+func (c *OfflineClient) PostMessageContext(_ context.Context, _ string, _ ...slack.MsgOption) (string, string, error) {
+	return "", "", ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) MarkConversationContext(_ context.Context, _, _ string) error {
+	return ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) AddReactionContext(_ context.Context, _ string, _ slack.ItemRef) error {
+	return ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) RemoveReactionContext(_ context.Context, _ string, _ slack.ItemRef) error {
+	return ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) CreateUserGroupContext(_ context.Context, _ slack.UserGroup, _ ...slack.CreateUserGroupOption) (slack.UserGroup, error) {
+	return slack.UserGroup{}, ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) UpdateUserGroupContext(_ context.Context, _ string, _ ...slack.UpdateUserGroupsOption) (slack.UserGroup, error) {
+	return slack.UserGroup{}, ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) UpdateUserGroupMembersContext(_ context.Context, _ string, _ string, _ ...slack.UpdateUserGroupMembersOption) (slack.UserGroup, error) {
+	return slack.UserGroup{}, ErrOfflineReadOnly
+}
+
+func (c *OfflineClient) hasChannel(channelID string) bool {
+	for _, ch := range c.channels {
+		if ch.ID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveData converts the loaded source into archive entries, so the archive backed
+// features (local and semantic search) work offline.
+func (c *OfflineClient) archiveData() map[string]*channelArchive {
+	data := make(map[string]*channelArchive, len(c.messages))
+	for channelID, msgs := range c.messages {
+		ca := &channelArchive{
+			ChannelID: channelID,
+			Oldest:    "0.000000",
+			Threads:   make(map[string]*threadArchive),
+		}
+		for _, m := range msgs {
+			ca.Messages = append(ca.Messages, ArchivedMessage{Message: m})
+			if compareTs(m.Timestamp, ca.Latest) > 0 || ca.Latest == "" {
+				ca.Latest = m.Timestamp
+			}
+		}
+		for threadTs, replies := range c.threads[channelID] {
+			thread := &threadArchive{}
+			for _, r := range replies {
+				thread.Replies = append(thread.Replies, ArchivedMessage{Message: r})
+				if compareTs(r.Timestamp, ca.Latest) > 0 {
+					ca.Latest = r.Timestamp
+				}
+			}
+			ca.Threads[threadTs] = thread
+		}
+		for _, thread := range ca.Threads {
+			thread.SyncedAt = ca.Latest
+		}
+		if ca.Latest == "" {
+			ca.Latest = ca.Oldest
+		}
+		data[channelID] = ca
+	}
+	return data
+}
+
+// newOfflineArchive returns an archive serving the offline source, it never syncs.
+func newOfflineArchive(teamID string, client *OfflineClient, logger *zap.Logger) *Archive {
+	dir := getCachePathWithTeamID(teamID, "archive")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		logger.Warn("Failed to create archive directory", zap.String("dir", dir), zap.Error(err))
+	}
+	return &Archive{
+		dir:      dir,
+		client:   client,
+		logger:   logger,
+		limiter:  limiter.Tier3.Limiter(),
+		resolve:  func(channel string) (string, bool) { return channel, true },
+		interval: defaultArchiveSyncInterval,
+		backfill: defaultArchiveBackfill,
+		lookback: defaultArchiveLookback,
+		data:     client.archiveData(),
+	}
+}
+
+// inRange applies the oldest/latest bounds of the Slack history APIs, which are exclusive
+// unless inclusive is set.
+func inRange(ts, oldest, latest string, inclusive bool) bool {
+	if oldest != "" {
+		if c := compareTs(ts, oldest); c < 0 || (c == 0 && !inclusive) {
+			return false
+		}
+	}
+	if latest != "" {
+		if c := compareTs(ts, latest); c > 0 || (c == 0 && !inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// paginateOffline returns one page of msgs and the cursor of the next page, if any.
+func paginateOffline(msgs []slack.Message, cursor string, limit int) ([]slack.Message, string, error) {
+	if limit <= 0 {
+		limit = defaultOfflineHistoryLimit
+	}
+	offset := 0
+	if cursor != "" {
+		decoded, err := base64.StdEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", errors.New("invalid_cursor")
+		}
+		v, ok := strings.CutPrefix(string(decoded), "offset:")
+		if !ok {
+			return nil, "", errors.New("invalid_cursor")
+		}
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return nil, "", errors.New("invalid_cursor")
+		}
+	}
+	if offset > len(msgs) {
+		offset = len(msgs)
+	}
+	end := min(offset+limit, len(msgs))
+	next := ""
+	if end < len(msgs) {
+		next = base64.StdEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(end)))
+	}
+	return msgs[offset:end], next, nil
+}
+
+func sortMessages(msgs []slack.Message) {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return compareTs(msgs[i].Timestamp, msgs[j].Timestamp) < 0
+	})
+}
+
+// convertJSON converts values of the slack-go fork used by slackdump into slack-go
+// values, both share the wire format of the Slack API.
+func convertJSON[T any](v any) (T, error) {
+	var out T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func isOfflineNotFound(err error) bool {
+	return errors.Is(err, source.ErrNotFound) || errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+// newTestExport writes a minimal Slack export directory with one channel and one thread.
+func newTestExport(t *testing.T) string {
+	dir := t.TempDir()
+	writeJSON(t, filepath.Join(dir, "users.json"), []map[string]any{
+		{"id": "U1", "name": "alice", "real_name": "Alice", "profile": map[string]any{"email": "alice@example.com"}},
+		{"id": "U2", "name": "bob", "real_name": "Bob"},
+	})
+	writeJSON(t, filepath.Join(dir, "channels.json"), []map[string]any{
+		{"id": "C1", "name": "general", "created": 1700000000, "members": []string{"U1", "U2"}},
+	})
+	writeJSON(t, filepath.Join(dir, "general", "2023-11-14.json"), []map[string]any{
+		{"type": "message", "user": "U1", "text": "first", "ts": "1700000001.000100"},
+		{"type": "message", "user": "U1", "text": "thread start", "ts": "1700000002.000100", "thread_ts": "1700000002.000100", "reply_count": 2},
+		{"type": "message", "user": "U2", "text": "reply one", "ts": "1700000003.000100", "thread_ts": "1700000002.000100"},
+		{"type": "message", "user": "U1", "text": "reply two", "ts": "1700000004.000100", "thread_ts": "1700000002.000100"},
+		{"type": "message", "user": "U2", "text": "last", "ts": "1700000005.000100"},
+	})
+	return dir
+}
+
+func TestOfflineClient(t *testing.T) {
+	ctx := context.Background()
+	c, err := LoadOffline(ctx, newTestExport(t), zap.NewNop())
+	require.NoError(t, err)
+
+	t.Run("users", func(t *testing.T) {
+		users, err := c.GetUsersContext(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+
+		found, err := c.UsersSearch(ctx, "ALICE@", 10)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "U1", found[0].ID)
+
+		info, err := c.GetUsersInfoContext(ctx, "U2,U3")
+		require.NoError(t, err)
+		require.Len(t, *info, 1)
+		assert.Equal(t, "bob", (*info)[0].Name)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		chans, _, err := c.GetConversationsContext(ctx, &slack.GetConversationsParameters{Types: []string{"public_channel"}})
+		require.NoError(t, err)
+		require.Len(t, chans, 1)
+		assert.Equal(t, "general", chans[0].Name)
+		assert.Equal(t, "general", chans[0].NameNormalized)
+		assert.Equal(t, 2, chans[0].NumMembers)
+
+		chans, _, err = c.GetConversationsContext(ctx, &slack.GetConversationsParameters{Types: []string{"im"}})
+		require.NoError(t, err)
+		assert.Empty(t, chans)
+	})
+
+	t.Run("history pages newest first without replies", func(t *testing.T) {
+		resp, err := c.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{ChannelID: "C1", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, resp.Messages, 2)
+		assert.Equal(t, "last", resp.Messages[0].Text)
+		assert.Equal(t, "thread start", resp.Messages[1].Text)
+		assert.True(t, resp.HasMore)
+
+		resp, err = c.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{ChannelID: "C1", Limit: 2, Cursor: resp.ResponseMetaData.NextCursor})
+		require.NoError(t, err)
+		require.Len(t, resp.Messages, 1)
+		assert.Equal(t, "first", resp.Messages[0].Text)
+		assert.False(t, resp.HasMore)
+
+		resp, err = c.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{ChannelID: "C1", Oldest: "1700000001.000100", Latest: "1700000005.000100"})
+		require.NoError(t, err)
+		require.Len(t, resp.Messages, 1, "bounds are exclusive")
+
+		_, err = c.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{ChannelID: "C404"})
+		assert.Error(t, err)
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		msgs, hasMore, _, err := c.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{ChannelID: "C1", Timestamp: "1700000002.000100"})
+		require.NoError(t, err)
+		assert.False(t, hasMore)
+		require.Len(t, msgs, 3)
+		assert.Equal(t, "thread start", msgs[0].Text)
+		assert.Equal(t, "reply two", msgs[2].Text)
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		_, _, err := c.PostMessageContext(ctx, "C1")
+		assert.ErrorIs(t, err, ErrOfflineReadOnly)
+		_, _, err = c.SearchContext(ctx, "first", slack.SearchParameters{})
+		assert.ErrorIs(t, err, ErrOfflineUnsupported)
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		data := c.archiveData()
+		require.Contains(t, data, "C1")
+		assert.Len(t, data["C1"].Messages, 3)
+		assert.Len(t, data["C1"].Threads["1700000002.000100"].Replies, 2)
+		assert.Equal(t, "1700000005.000100", data["C1"].Latest)
+	})
+}
+
+func TestPaginateOffline(t *testing.T) {
+	msgs := []slack.Message{{}, {}, {}}
+	page, next, err := paginateOffline(msgs, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.Empty(t, next)
+
+	_, _, err = paginateOffline(msgs, "garbage", 1)
+	assert.Error(t, err)
+}
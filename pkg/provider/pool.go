@@ -0,0 +1,326 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/limiter"
+	"github.com/rusq/slackdump/v3/auth"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTokenIdleTimeout = 30 * time.Minute
+	defaultTokenPoolSize    = 100
+	tokenPoolSweepInterval  = time.Minute
+)
+
+// ErrCredentialsRequired is returned with token pass-through when a request carries no Slack token.
+var ErrCredentialsRequired = errors.New("this server acts as the caller in Slack, send your own Slack token in the X-Slack-Token header, and the xoxd cookie in X-Slack-Cookie with an xoxc token")
+
+// Credentials are the Slack token of a caller with token pass-through: a user token (xoxp),
+// or a session token (xoxc) with its d cookie (xoxd).
+type Credentials struct {
+	Token  string
+	Cookie string
+}
+
+// identity is the pool key of c, derived so tokens are not kept as map keys.
+func (c Credentials) identity() string {
+	sum := sha256.Sum256([]byte(c.Token + "\x00" + c.Cookie))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c Credentials) validate() error {
+	switch {
+	case strings.HasPrefix(c.Token, "xoxp-"):
+		return nil
+	case strings.HasPrefix(c.Token, "xoxc-"):
+		if c.Cookie == "" {
+			return errors.New("an xoxc token needs its xoxd cookie in X-Slack-Cookie")
+		}
+		return nil
+	default:
+		return errors.New("token pass-through accepts user tokens (xoxp) or session tokens (xoxc) with their cookie (xoxd)")
+	}
+}
+
+type credentialsKey struct{}
+
+// WithCredentials attaches the Slack token of the caller to ctx.
+func WithCredentials(ctx context.Context, c Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, c)
+}
+
+// CredentialsFromContext returns the Slack token attached with WithCredentials.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	c, ok := ctx.Value(credentialsKey{}).(Credentials)
+	return c, ok && c.Token != ""
+}
+
+// TokenPassthrough reports whether tool calls on the HTTP transports act as the caller in
+// Slack, with the caller's own token, instead of with the token of the server.
+func TokenPassthrough() bool {
+	v := os.Getenv("SLACK_MCP_TOKEN_PASSTHROUGH")
+	return v == "true" || v == "1"
+}
+
+func getTokenIdleTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("SLACK_MCP_TOKEN_IDLE_TIMEOUT")); err == nil && d > 0 {
+		return d
+	}
+	return defaultTokenIdleTimeout
+}
+
+func getTokenPoolSize() int {
+	if n, err := strconv.Atoi(os.Getenv("SLACK_MCP_TOKEN_POOL_SIZE")); err == nil && n > 0 {
+		return n
+	}
+	return defaultTokenPoolSize
+}
+
+// Pool holds a provider per caller with token pass-through. Each one has its own Slack
+// client and users and channels caches, namespaced by team and user ID on disk, so callers
+// never see each other's data. Providers are built on first use and dropped after
+// SLACK_MCP_TOKEN_IDLE_TIMEOUT without calls, or when SLACK_MCP_TOKEN_POOL_SIZE is reached.
+type Pool struct {
+	transport   string
+	logger      *zap.Logger
+	idleTimeout time.Duration
+	size        int
+
+	// newProvider builds the provider of a caller and start loads its caches, replaced in tests
+	newProvider func(context.Context, Credentials) (*ApiProvider, error)
+	start       func(context.Context, *ApiProvider)
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+	onEvict []func(*ApiProvider)
+}
+
+type poolEntry struct {
+	ready    chan struct{}
+	ap       *ApiProvider
+	err      error
+	cancel   context.CancelFunc
+	lastUsed time.Time
+}
+
+// NewPool returns an empty pool, see Run for evicting idle providers.
+func NewPool(transport string, logger *zap.Logger) *Pool {
+	p := &Pool{
+		transport:   transport,
+		logger:      logger,
+		idleTimeout: getTokenIdleTimeout(),
+		size:        getTokenPoolSize(),
+		entries:     make(map[string]*poolEntry),
+	}
+	p.newProvider = p.build
+	p.start = warm
+	return p
+}
+
+// OnEvict registers fn to be called with every provider dropped from the pool, so state
+// built for it can be dropped as well.
+func (p *Pool) OnEvict(fn func(*ApiProvider)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onEvict = append(p.onEvict, fn)
+}
+
+// Get returns the provider of c, building it on first use. Concurrent first calls of the
+// same caller share one provider.
+func (p *Pool) Get(ctx context.Context, c Credentials) (*ApiProvider, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	key := c.identity()
+
+	p.mu.Lock()
+	e, ok := p.entries[key]
+	if !ok {
+		e = &poolEntry{ready: make(chan struct{})}
+		p.entries[key] = e
+		p.evictOverflowLocked(key)
+	}
+	e.lastUsed = time.Now()
+	p.mu.Unlock()
+
+	if !ok {
+		ap, err := p.newProvider(ctx, c)
+		p.mu.Lock()
+		e.ap, e.err = ap, err
+		if err != nil {
+			// Failures are not kept, the caller may fix the token and retry
+			if p.entries[key] == e {
+				delete(p.entries, key)
+			}
+		} else {
+			var warmCtx context.Context
+			warmCtx, e.cancel = context.WithCancel(context.Background())
+			go p.start(warmCtx, ap)
+		}
+		close(e.ready)
+		p.mu.Unlock()
+	}
+
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return e.ap, e.err
+}
+
+// warm loads the caches of a new provider and keeps them fresh until it is evicted.
+func warm(ctx context.Context, ap *ApiProvider) {
+	if err := ap.RefreshUsers(ctx); err != nil && ctx.Err() == nil {
+		ap.logger.Warn("Failed to load users", zap.Error(err))
+	}
+	if err := ap.RefreshChannels(ctx); err != nil && ctx.Err() == nil {
+		ap.logger.Warn("Failed to load channels", zap.Error(err))
+	}
+	ap.StartRefresher(ctx)
+}
+
+// Len returns the number of callers in the pool.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Run evicts idle providers until ctx is done.
+func (p *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(tokenPoolSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.evictIdle(now)
+		}
+	}
+}
+
+func (p *Pool) evictIdle(now time.Time) {
+	p.mu.Lock()
+	var evicted []*poolEntry
+	for key, e := range p.entries {
+		if now.Sub(e.lastUsed) >= p.idleTimeout && e.isReady() {
+			delete(p.entries, key)
+			evicted = append(evicted, e)
+		}
+	}
+	hooks := p.onEvict
+	p.mu.Unlock()
+
+	for _, e := range evicted {
+		p.release(e, hooks, "idle")
+	}
+}
+
+// evictOverflowLocked drops the least recently used providers beyond the pool size, never
+// the one of keep.
+func (p *Pool) evictOverflowLocked(keep string) {
+	for len(p.entries) > p.size {
+		var (
+			oldestKey string
+			oldest    *poolEntry
+		)
+		for key, e := range p.entries {
+			if key == keep || !e.isReady() {
+				continue
+			}
+			if oldest == nil || e.lastUsed.Before(oldest.lastUsed) {
+				oldestKey, oldest = key, e
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(p.entries, oldestKey)
+		hooks := p.onEvict
+		go p.release(oldest, hooks, "pool full")
+	}
+}
+
+func (p *Pool) release(e *poolEntry, hooks []func(*ApiProvider), reason string) {
+	if e.ap == nil {
+		return
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	for _, fn := range hooks {
+		fn(e.ap)
+	}
+	e.ap.logger.Debug("Evicted caller from token pool", zap.String("reason", reason))
+}
+
+func (e *poolEntry) isReady() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// build creates the provider of a caller. Unlike the server's own workspaces there is no
+// degraded mode: a token Slack does not accept fails the request.
+func (p *Pool) build(_ context.Context, c Credentials) (*ApiProvider, error) {
+	authProvider, err := auth.NewValueAuth(c.Token, c.Cookie)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Slack token: %w", err)
+	}
+	client, err := NewMCPSlackClient(authProvider, p.logger)
+	if err != nil {
+		return nil, fmt.Errorf("slack rejected the token: %w", err)
+	}
+	ar := client.authResponse
+	logger := p.logger.With(zap.String("team_id", ar.TeamID), zap.String("slack_user", ar.UserID))
+	logger.Info("Added caller to token pool", zap.String("user", ar.User), zap.String("team", ar.Team))
+
+	// Users see different private channels and DMs, so caches are per user, never shared
+	namespace := ar.TeamID + "_" + ar.UserID
+	ap := &ApiProvider{
+		transport: p.transport,
+		client:    client,
+		logger:    logger,
+
+		rateLimiter:         limiter.Tier2.Limiter(),
+		cacheTTL:            getCacheTTL(),
+		minRefreshInterval:  getMinRefreshInterval(),
+		fullRefreshInterval: getFullRefreshInterval(),
+
+		usersCachePath:    getCachePathWithTeamID(namespace, "users_cache.json"),
+		channelsCachePath: getCachePathWithTeamID(namespace, "channels_cache_v2.json"),
+	}
+	// Initialize with empty snapshots
+	ap.usersSnapshot.Store(&UsersCache{
+		Users:    make(map[string]slack.User),
+		UsersInv: make(map[string]string),
+	})
+	ap.channelsSnapshot.Store(&ChannelsCache{
+		Channels:    make(map[string]Channel),
+		ChannelsInv: make(map[string]string),
+	})
+	ap.usergroupsSnapshot.Store(&UsergroupsCache{
+		Usergroups: make(map[string]slack.UserGroup),
+	})
+	ap.emojiSnapshot.Store(&EmojiCache{
+		Emoji: make(map[string]string),
+	})
+	return ap, nil
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestPool(t *testing.T, size int) (*Pool, *atomic.Int32) {
+	t.Helper()
+	p := NewPool("http", zap.NewNop())
+	p.size = size
+	p.idleTimeout = time.Minute
+	var builds atomic.Int32
+	p.newProvider = func(_ context.Context, c Credentials) (*ApiProvider, error) {
+		builds.Add(1)
+		if c.Token == "xoxp-rejected" {
+			return nil, errors.New("invalid_auth")
+		}
+		// Slow enough for concurrent first calls to overlap
+		time.Sleep(10 * time.Millisecond)
+		return &ApiProvider{logger: zap.NewNop(), name: c.Token}, nil
+	}
+	p.start = func(context.Context, *ApiProvider) {}
+	return p, &builds
+}
+
+func TestPoolGet(t *testing.T) {
+	p, builds := newTestPool(t, 10)
+	ctx := context.Background()
+
+	var (
+		wg  sync.WaitGroup
+		got [5]*ApiProvider
+	)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ap, err := p.Get(ctx, Credentials{Token: "xoxp-alice"})
+			assert.NoError(t, err)
+			got[i] = ap
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), builds.Load(), "concurrent first calls share one provider")
+	for _, ap := range got {
+		assert.Same(t, got[0], ap)
+	}
+
+	bob, err := p.Get(ctx, Credentials{Token: "xoxc-bob", Cookie: "xoxd-bob"})
+	require.NoError(t, err)
+	assert.NotSame(t, got[0], bob, "every caller gets its own provider")
+
+	other, err := p.Get(ctx, Credentials{Token: "xoxc-bob", Cookie: "xoxd-other"})
+	require.NoError(t, err)
+	assert.NotSame(t, bob, other, "the cookie is part of the identity")
+
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-rejected"})
+	assert.Error(t, err)
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-rejected"})
+	assert.Error(t, err)
+	assert.Equal(t, 3, p.Len(), "failed callers are not kept")
+	assert.Equal(t, int32(5), builds.Load(), "failed callers are retried")
+}
+
+func TestPoolCredentials(t *testing.T) {
+	p, builds := newTestPool(t, 10)
+	for _, c := range []Credentials{
+		{},
+		{Token: "xoxb-bot"},
+		{Token: "xoxc-session"},
+		{Token: "not-a-token"},
+	} {
+		_, err := p.Get(context.Background(), c)
+		assert.Error(t, err, c.Token)
+	}
+	assert.Zero(t, builds.Load())
+
+	ctx := WithCredentials(context.Background(), Credentials{Token: "xoxp-alice"})
+	c, ok := CredentialsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "xoxp-alice", c.Token)
+	_, ok = CredentialsFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestPoolEviction(t *testing.T) {
+	p, builds := newTestPool(t, 2)
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		evicted []string
+	)
+	p.OnEvict(func(ap *ApiProvider) {
+		mu.Lock()
+		defer mu.Unlock()
+		evicted = append(evicted, ap.name)
+	})
+
+	_, err := p.Get(ctx, Credentials{Token: "xoxp-alice"})
+	require.NoError(t, err)
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-bob"})
+	require.NoError(t, err)
+
+	// Alice is idle, Bob is not
+	p.mu.Lock()
+	for _, e := range p.entries {
+		if e.ap.name == "xoxp-alice" {
+			e.lastUsed = time.Now().Add(-2 * time.Minute)
+		}
+	}
+	p.mu.Unlock()
+	p.evictIdle(time.Now())
+	assert.Equal(t, 1, p.Len())
+	mu.Lock()
+	assert.Equal(t, []string{"xoxp-alice"}, evicted)
+	mu.Unlock()
+
+	// A full pool drops the least recently used caller
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-carol"})
+	require.NoError(t, err)
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-dave"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Len())
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(evicted) == 2 && evicted[1] == "xoxp-bob"
+	}, time.Second, 10*time.Millisecond)
+
+	// An evicted caller is built again on its next call
+	_, err = p.Get(ctx, Credentials{Token: "xoxp-alice"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), builds.Load())
+}
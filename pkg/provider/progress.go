@@ -0,0 +1,25 @@
+package provider
+
+import "context"
+
+type progressKey struct{}
+
+// ProgressFunc receives progress updates from long-running fetches.
+// total is zero when the amount of work is not known in advance.
+type ProgressFunc func(progress, total float64, message string)
+
+// WithProgress returns a context that carries fn, so paging loops deep in the
+// provider can report progress back to whoever started the request.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress calls the ProgressFunc carried by ctx, if any.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(progress, total, message)
+	}
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportProgress(t *testing.T) {
+	// No progress func attached, must not panic
+	ReportProgress(context.Background(), 1, 0, "ignored")
+
+	var got []float64
+	ctx := WithProgress(context.Background(), func(progress, total float64, message string) {
+		got = append(got, progress)
+	})
+	ReportProgress(ctx, 10, 0, "first")
+	ReportProgress(ctx, 25, 0, "second")
+
+	assert.Equal(t, []float64{10, 25}, got)
+}
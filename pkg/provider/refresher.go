@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	CacheUsers      = "users"
+	CacheChannels   = "channels"
+	CacheUsergroups = "usergroups"
+	CacheEmoji      = "emoji"
+
+	defaultCacheRefreshInterval = 1 * time.Hour
+	minRefreshBackoff           = 30 * time.Second
+	maxRefreshBackoff           = 5 * time.Minute
+	refreshJitter               = 0.1
+)
+
+// CacheNames lists the caches kept fresh by the refresher, in display order.
+var CacheNames = []string{CacheUsers, CacheChannels, CacheUsergroups, CacheEmoji}
+
+// CacheStatus is the health of one cache as seen by the refresher.
+type CacheStatus struct {
+	Workspace           string    `json:"workspace,omitempty"`
+	Name                string    `json:"name"`
+	Ready               bool      `json:"ready"`
+	Count               int       `json:"count"`
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+	LastFailure         time.Time `json:"last_failure,omitzero"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	NextRefresh         time.Time `json:"next_refresh,omitzero"`
+}
+
+// refreshStatus tracks refresh outcomes of all caches.
+type refreshStatus struct {
+	mu     sync.RWMutex
+	caches map[string]*CacheStatus
+}
+
+func (s *refreshStatus) update(name string, fn func(*CacheStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.caches == nil {
+		s.caches = make(map[string]*CacheStatus)
+	}
+	st, ok := s.caches[name]
+	if !ok {
+		st = &CacheStatus{Name: name}
+		s.caches[name] = st
+	}
+	fn(st)
+}
+
+func (s *refreshStatus) get(name string) CacheStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st, ok := s.caches[name]; ok {
+		return *st
+	}
+	return CacheStatus{Name: name}
+}
+
+// getCacheRefreshInterval returns how often the refresher refreshes caches from
+// SLACK_MCP_CACHE_REFRESH_INTERVAL env var or default (1 hour).
+// Supports formats: "1h", "30m", "3600" (seconds), "0" (disable periodic refresh)
+// Negative values are rejected and fall back to default.
+func getCacheRefreshInterval() time.Duration {
+	intervalStr := os.Getenv("SLACK_MCP_CACHE_REFRESH_INTERVAL")
+	if intervalStr == "" {
+		return defaultCacheRefreshInterval
+	}
+
+	if d, err := time.ParseDuration(intervalStr); err == nil {
+		if d < 0 {
+			return defaultCacheRefreshInterval
+		}
+		return d
+	}
+
+	if secs, err := strconv.ParseInt(intervalStr, 10, 64); err == nil {
+		if secs < 0 {
+			return defaultCacheRefreshInterval
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	return defaultCacheRefreshInterval
+}
+
+// recordRefresh records the outcome of a cache refresh. Skipped forced refreshes are not failures.
+func (ap *ApiProvider) recordRefresh(name string, err error) {
+	if errors.Is(err, ErrRefreshRateLimited) {
+		return
+	}
+	now := time.Now()
+	ap.refreshStatus.update(name, func(st *CacheStatus) {
+		if err != nil {
+			st.LastFailure = now
+			st.LastError = err.Error()
+			st.ConsecutiveFailures++
+			return
+		}
+		st.LastSuccess = now
+		st.LastError = ""
+		st.ConsecutiveFailures = 0
+	})
+}
+
+// CacheStatuses returns the current state of all caches.
+func (ap *ApiProvider) CacheStatuses() []CacheStatus {
+	statuses := make([]CacheStatus, 0, len(CacheNames))
+	for _, name := range CacheNames {
+		st := ap.refreshStatus.get(name)
+		st.Workspace = ap.name
+		switch name {
+		case CacheUsers:
+			st.Ready = ap.usersReady
+			st.Count = len(ap.ProvideUsersMap().Users)
+		case CacheChannels:
+			st.Ready = ap.channelsReady
+			st.Count = len(ap.ProvideChannelsMaps().Channels)
+		case CacheUsergroups:
+			st.Ready = !st.LastSuccess.IsZero()
+			st.Count = len(ap.ProvideUsergroups().Usergroups)
+		case CacheEmoji:
+			st.Ready = !st.LastSuccess.IsZero()
+			st.Count = len(ap.ProvideEmoji().Emoji)
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// StartRefresher keeps the users, channels, user groups and emoji caches fresh until ctx is
+// done, each on its own schedule of SLACK_MCP_CACHE_REFRESH_INTERVAL with jitter. Failed
+// refreshes, including those at startup while Slack was unreachable, are retried with
+// exponential backoff, never waiting longer than the interval. With periodic refresh
+// disabled failed caches are still retried until they succeed once.
+// It returns immediately, the refreshes run in the background.
+func (ap *ApiProvider) StartRefresher(ctx context.Context) {
+	// Demo mode has no client and offline data sources never change
+	if os.Getenv("SLACK_MCP_XOXP_TOKEN") == "demo" || (os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo") || ap.IsOffline() {
+		return
+	}
+
+	interval := getCacheRefreshInterval()
+	ap.logger.Info("Starting cache refresher", zap.Duration("interval", interval))
+
+	refreshers := map[string]func(context.Context) error{
+		CacheUsers: func(ctx context.Context) error {
+			err := ap.refreshUsersInternal(ctx, true)
+			ap.recordRefresh(CacheUsers, err)
+			return err
+		},
+		CacheChannels: func(ctx context.Context) error {
+			err := ap.refreshChannelsInternal(ctx, true)
+			ap.recordRefresh(CacheChannels, err)
+			return err
+		},
+		CacheUsergroups: ap.RefreshUsergroups,
+		CacheEmoji:      ap.RefreshEmoji,
+	}
+	for _, name := range CacheNames {
+		go ap.superviseCache(ctx, name, refreshers[name], interval)
+	}
+}
+
+func (ap *ApiProvider) superviseCache(ctx context.Context, name string, refresh func(context.Context) error, interval time.Duration) {
+	var backoff time.Duration
+	if ap.refreshStatus.get(name).ConsecutiveFailures > 0 {
+		backoff = nextBackoff(0, interval)
+	}
+	for {
+		if backoff == 0 && interval <= 0 {
+			ap.refreshStatus.update(name, func(st *CacheStatus) {
+				st.NextRefresh = time.Time{}
+			})
+			return
+		}
+		wait := interval
+		if backoff > 0 {
+			wait = backoff
+		}
+		wait = withJitter(wait)
+		ap.refreshStatus.update(name, func(st *CacheStatus) {
+			st.NextRefresh = time.Now().Add(wait)
+		})
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = nextBackoff(backoff, interval)
+			ap.logger.Warn("Cache refresh failed, retrying",
+				zap.String("cache", name),
+				zap.Duration("retry_in", backoff),
+				zap.Error(err))
+			continue
+		}
+		if backoff > 0 {
+			ap.logger.Info("Cache refresh recovered", zap.String("cache", name))
+		}
+		backoff = 0
+	}
+}
+
+// withJitter spreads d by up to refreshJitter in both directions so caches and replicas
+// don't refresh in lockstep.
+func withJitter(d time.Duration) time.Duration {
+	spread := float64(d) * refreshJitter
+	return d + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// nextBackoff doubles the previous backoff, starting at minRefreshBackoff and capped at limit,
+// or at maxRefreshBackoff when periodic refresh is disabled.
+func nextBackoff(prev, limit time.Duration) time.Duration {
+	if limit <= 0 {
+		limit = maxRefreshBackoff
+	}
+	next := max(prev*2, minRefreshBackoff)
+	return min(next, max(limit, minRefreshBackoff))
+}
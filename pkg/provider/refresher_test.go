@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetCacheRefreshInterval(t *testing.T) {
+	tests := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{"", defaultCacheRefreshInterval},
+		{"30m", 30 * time.Minute},
+		{"600", 10 * time.Minute},
+		{"0", 0},
+		{"-1h", defaultCacheRefreshInterval},
+		{"invalid", defaultCacheRefreshInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.envValue, func(t *testing.T) {
+			t.Setenv("SLACK_MCP_CACHE_REFRESH_INTERVAL", tt.envValue)
+			assert.Equal(t, tt.expected, getCacheRefreshInterval())
+		})
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	assert.Equal(t, minRefreshBackoff, nextBackoff(0, time.Hour))
+	assert.Equal(t, 2*minRefreshBackoff, nextBackoff(minRefreshBackoff, time.Hour))
+	assert.Equal(t, time.Hour, nextBackoff(45*time.Minute, time.Hour))
+	assert.Equal(t, minRefreshBackoff, nextBackoff(0, time.Second), "backoff never drops below the minimum")
+	assert.Equal(t, maxRefreshBackoff, nextBackoff(time.Hour, 0), "without an interval backoff is capped at the maximum")
+}
+
+func TestWithJitter(t *testing.T) {
+	for range 100 {
+		d := withJitter(time.Hour)
+		assert.GreaterOrEqual(t, d, 54*time.Minute)
+		assert.LessOrEqual(t, d, 66*time.Minute)
+	}
+}
+
+func TestCacheStatuses(t *testing.T) {
+	ap := newDeltaTestProvider(t, nil)
+	ap.usersSnapshot.Store(&UsersCache{Users: map[string]slack.User{"U1": {ID: "U1"}}})
+	ap.usersReady = true
+	ap.usergroupsSnapshot.Store(&UsergroupsCache{})
+	ap.emojiSnapshot.Store(&EmojiCache{Emoji: map[string]string{"party": "https://example.com/party.gif"}})
+
+	ap.recordRefresh(CacheEmoji, nil)
+	ap.recordRefresh(CacheChannels, errors.New("boom"))
+	ap.recordRefresh(CacheChannels, errors.New("boom again"))
+	ap.recordRefresh(CacheUsers, ErrRefreshRateLimited)
+
+	statuses := ap.CacheStatuses()
+	require.Len(t, statuses, len(CacheNames))
+	byName := make(map[string]CacheStatus)
+	for _, st := range statuses {
+		byName[st.Name] = st
+	}
+
+	users := byName[CacheUsers]
+	assert.True(t, users.Ready)
+	assert.Equal(t, 1, users.Count)
+	assert.True(t, users.LastFailure.IsZero(), "rate-limited refreshes are not failures")
+
+	channels := byName[CacheChannels]
+	assert.False(t, channels.Ready)
+	assert.Equal(t, 2, channels.ConsecutiveFailures)
+	assert.Equal(t, "boom again", channels.LastError)
+
+	emoji := byName[CacheEmoji]
+	assert.True(t, emoji.Ready)
+	assert.Equal(t, 1, emoji.Count)
+	assert.False(t, emoji.LastSuccess.IsZero())
+
+	assert.False(t, byName[CacheUsergroups].Ready)
+
+	ap.recordRefresh(CacheChannels, nil)
+	channels = ap.refreshStatus.get(CacheChannels)
+	assert.Zero(t, channels.ConsecutiveFailures)
+	assert.Empty(t, channels.LastError)
+	assert.False(t, channels.LastFailure.IsZero(), "last failure is kept after a success")
+}
+
+func TestSuperviseCacheBacksOff(t *testing.T) {
+	ap := &ApiProvider{logger: zap.NewNop()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ap.superviseCache(ctx, CacheEmoji, func(context.Context) error {
+			calls.Add(1)
+			return errors.New("unavailable")
+		}, time.Millisecond)
+	}()
+
+	// After the first failure the retry waits for the backoff, not the much shorter interval
+	require.Eventually(t, func() bool {
+		return time.Until(ap.refreshStatus.get(CacheEmoji).NextRefresh) > 20*time.Second
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+
+	cancel()
+	<-done
+}
+
+func TestSuperviseCacheRetriesStartupFailure(t *testing.T) {
+	ap := &ApiProvider{logger: zap.NewNop()}
+	ap.recordRefresh(CacheEmoji, errors.New("unreachable"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ap.superviseCache(ctx, CacheEmoji, func(context.Context) error { return nil }, 0)
+	}()
+
+	// Periodic refresh is disabled, but the failed cache is still retried
+	require.Eventually(t, func() bool {
+		return !ap.refreshStatus.get(CacheEmoji).NextRefresh.IsZero()
+	}, time.Second, time.Millisecond)
+	assert.Less(t, time.Until(ap.refreshStatus.get(CacheEmoji).NextRefresh), time.Minute)
+
+	cancel()
+	<-done
+}
+
+func TestSuperviseCacheDisabled(t *testing.T) {
+	ap := &ApiProvider{logger: zap.NewNop()}
+	// Returns right away: the cache is healthy and periodic refresh is disabled
+	ap.superviseCache(context.Background(), CacheEmoji, func(context.Context) error {
+		t.Fatal("refresh must not run")
+		return nil
+	}, 0)
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"go.uber.org/zap"
+)
+
+// ErrUnknownWorkspace is returned when a workspace argument matches no configured workspace.
+var ErrUnknownWorkspace = errors.New("unknown workspace")
+
+var workspaceNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// workspaceConfig tells where the tokens and cache paths of a workspace are read from:
+// SLACK_MCP_XOXP_TOKEN with a single workspace, SLACK_MCP_ACME_XOXP_TOKEN for a workspace
+// named acme in SLACK_MCP_WORKSPACES.
+type workspaceConfig struct {
+	name   string
+	prefix string
+}
+
+var singleWorkspace = workspaceConfig{prefix: "SLACK_MCP_"}
+
+func (c workspaceConfig) env(key string) string {
+	return c.prefix + key
+}
+
+func (c workspaceConfig) getenv(key string) string {
+	return os.Getenv(c.env(key))
+}
+
+// cachePath namespaces a cache file by team ID, or by the workspace name while the team
+// is not known yet, so workspaces never share cache files.
+func (c workspaceConfig) cachePath(teamID, filename string) string {
+	if teamID == "" {
+		teamID = c.name
+	}
+	return getCachePathWithTeamID(teamID, filename)
+}
+
+// getWorkspaces parses SLACK_MCP_WORKSPACES, a comma-separated list of workspace names,
+// the first one being the default. It returns nil if the server serves a single workspace.
+func getWorkspaces() ([]workspaceConfig, error) {
+	var (
+		workspaces []workspaceConfig
+		seen       = make(map[string]string)
+	)
+	for _, name := range strings.Split(os.Getenv("SLACK_MCP_WORKSPACES"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !workspaceNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid workspace name %q, use lowercase letters, digits, '-' and '_'", name)
+		}
+		prefix := "SLACK_MCP_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		if other, ok := seen[prefix]; ok {
+			return nil, fmt.Errorf("workspace names %q and %q both read %s* variables", other, name, prefix)
+		}
+		seen[prefix] = name
+		workspaces = append(workspaces, workspaceConfig{name: name, prefix: prefix})
+	}
+	return workspaces, nil
+}
+
+// newWorkspaces creates a provider per workspace of SLACK_MCP_WORKSPACES and returns the
+// default one, which the others are reached from with Workspace.
+func newWorkspaces(transport string, workspaces []workspaceConfig, logger *zap.Logger) *ApiProvider {
+	all := make([]*ApiProvider, 0, len(workspaces))
+	for _, ws := range workspaces {
+		logger.Info("Configuring workspace",
+			zap.String("context", "console"),
+			zap.String("workspace", ws.name),
+		)
+		ap := newProvider(transport, ws, logger.With(zap.String("workspace", ws.name)))
+		ap.migrateCaches()
+		all = append(all, ap)
+	}
+	all[0].workspaces = all
+	return all[0]
+}
+
+// Name returns the name of the workspace in SLACK_MCP_WORKSPACES, empty if the server
+// serves a single workspace.
+func (ap *ApiProvider) Name() string {
+	return ap.name
+}
+
+// Workspaces returns all workspaces served, the default one first.
+func (ap *ApiProvider) Workspaces() []*ApiProvider {
+	if len(ap.workspaces) == 0 {
+		return []*ApiProvider{ap}
+	}
+	return ap.workspaces
+}
+
+// Workspace returns the workspace matching name, which is its name in SLACK_MCP_WORKSPACES,
+// its Slack subdomain or its team ID. An empty name selects the default workspace.
+func (ap *ApiProvider) Workspace(name string) (*ApiProvider, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ap, nil
+	}
+
+	workspaces := ap.Workspaces()
+	for _, ws := range workspaces {
+		if ws.matches(name) {
+			return ws, nil
+		}
+	}
+
+	known := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		if ws.name != "" {
+			known = append(known, ws.name)
+		} else if sub, err := ws.subdomain(); err == nil {
+			known = append(known, sub)
+		}
+	}
+	return nil, fmt.Errorf("%w %q, available workspaces: %s", ErrUnknownWorkspace, name, strings.Join(known, ", "))
+}
+
+func (ap *ApiProvider) matches(name string) bool {
+	if ap.name != "" && strings.EqualFold(ap.name, name) {
+		return true
+	}
+	ar, err := ap.client.AuthTest()
+	if err != nil {
+		return false
+	}
+	if ar.TeamID != "" && strings.EqualFold(ar.TeamID, name) {
+		return true
+	}
+	sub, err := text.Workspace(ar.URL)
+	return err == nil && strings.EqualFold(sub, name)
+}
+
+func (ap *ApiProvider) subdomain() (string, error) {
+	ar, err := ap.client.AuthTest()
+	if err != nil {
+		return "", err
+	}
+	return text.Workspace(ar.URL)
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetWorkspaces(t *testing.T) {
+	t.Setenv("SLACK_MCP_WORKSPACES", "")
+	workspaces, err := getWorkspaces()
+	require.NoError(t, err)
+	assert.Nil(t, workspaces)
+
+	t.Setenv("SLACK_MCP_WORKSPACES", " Acme, eng-grid ,,")
+	workspaces, err = getWorkspaces()
+	require.NoError(t, err)
+	assert.Equal(t, []workspaceConfig{
+		{name: "acme", prefix: "SLACK_MCP_ACME_"},
+		{name: "eng-grid", prefix: "SLACK_MCP_ENG_GRID_"},
+	}, workspaces)
+
+	for _, env := range []string{"acme,acme", "eng-grid,eng_grid", "acme,a b", "-acme"} {
+		t.Setenv("SLACK_MCP_WORKSPACES", env)
+		_, err := getWorkspaces()
+		assert.Error(t, err, env)
+	}
+}
+
+func TestWorkspaceCachePath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	acme := workspaceConfig{name: "acme", prefix: "SLACK_MCP_ACME_"}
+
+	assert.Equal(t, "T1_users_cache.json", filepath.Base(acme.cachePath("T1", "users_cache.json")))
+	assert.Equal(t, "acme_users_cache.json", filepath.Base(acme.cachePath("", "users_cache.json")),
+		"workspaces never share a cache file while their team is unknown")
+	assert.Equal(t, "users_cache.json", filepath.Base(singleWorkspace.cachePath("", "users_cache.json")))
+}
+
+func TestWorkspace(t *testing.T) {
+	newWorkspace := func(name, teamID, url string) *ApiProvider {
+		ap := newDeltaTestProvider(t, &OfflineClient{auth: &slack.AuthTestResponse{TeamID: teamID, URL: url}})
+		ap.name = name
+		return ap
+	}
+	acme := newWorkspace("acme", "T1", "https://acme-corp.slack.com/")
+	grid := newWorkspace("grid", "E2", "https://grid.enterprise.slack.com/")
+	acme.workspaces = []*ApiProvider{acme, grid}
+
+	for name, want := range map[string]*ApiProvider{
+		"":          acme,
+		"acme":      acme,
+		"GRID":      grid,
+		"E2":        grid,
+		"acme-corp": acme,
+		" grid ":    grid,
+	} {
+		ws, err := acme.Workspace(name)
+		require.NoError(t, err, name)
+		assert.Same(t, want, ws, name)
+	}
+
+	_, err := acme.Workspace("beta")
+	assert.ErrorIs(t, err, ErrUnknownWorkspace)
+	assert.ErrorContains(t, err, "acme, grid")
+
+	assert.Equal(t, []*ApiProvider{acme, grid}, acme.Workspaces())
+	assert.Equal(t, []*ApiProvider{grid}, grid.Workspaces(), "a single workspace serves itself")
+}
+
+func TestNewWorkspaces(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("SLACK_MCP_XOXP_TOKEN", "demo")
+	t.Setenv("SLACK_MCP_WORKSPACES", "acme,beta")
+	t.Setenv("SLACK_MCP_ACME_XOXP_TOKEN", "xoxp-acme")
+	t.Setenv("SLACK_MCP_ACME_USERS_CACHE", filepath.Join(t.TempDir(), "acme_users.json"))
+	t.Setenv("SLACK_MCP_BETA_XOXC_TOKEN", "xoxc-beta")
+	t.Setenv("SLACK_MCP_BETA_XOXD_TOKEN", "xoxd-beta")
+
+	p := New("stdio", zap.NewNop())
+	workspaces := p.Workspaces()
+	require.Len(t, workspaces, 2)
+	assert.Same(t, p, workspaces[0], "the first workspace is the default")
+	assert.Equal(t, "acme", workspaces[0].Name())
+	assert.Equal(t, "beta", workspaces[1].Name())
+	assert.Equal(t, "acme_users.json", filepath.Base(workspaces[0].usersCachePath))
+	assert.NotSame(t, workspaces[0].rateLimiter, workspaces[1].rateLimiter)
+
+	beta, err := p.Workspace("beta")
+	require.NoError(t, err)
+	assert.Same(t, workspaces[1], beta)
+}
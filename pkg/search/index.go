@@ -0,0 +1,286 @@
+// Package search implements an in-memory inverted index with BM25 ranking
+// over messages of the local archive.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	snippetRadius = 80
+)
+
+// Document is an indexed message.
+type Document struct {
+	ChannelID string
+	Ts        string
+	ThreadTs  string
+	User      string
+	Text      string
+}
+
+// ID returns the unique identifier of the document within a workspace.
+func (d *Document) ID() string {
+	return d.ChannelID + "/" + d.Ts
+}
+
+// Result is a ranked match with a snippet of the text around the first matched term.
+type Result struct {
+	Document *Document
+	Score    float64
+	Snippet  string
+}
+
+// Query selects documents containing all terms (if any) and accepted by Filter (if set).
+type Query struct {
+	Terms  []string
+	Filter func(*Document) bool
+}
+
+// Index is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]*Document
+	lengths  map[string]int
+	postings map[string]map[string]int
+	totalLen int
+	// threads tracks who participates in each thread, keyed by channel and thread ts.
+	threads map[string]map[string]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*Document),
+		lengths:  make(map[string]int),
+		postings: make(map[string]map[string]int),
+		threads:  make(map[string]map[string]int),
+	}
+}
+
+// Tokenize lowercases text and splits it on anything that is not a letter or a digit.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes doc, replacing a previously indexed version of it.
+func (idx *Index) Add(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	id := doc.ID()
+	idx.removeLocked(id)
+
+	tokens := Tokenize(doc.Text)
+	for _, t := range tokens {
+		p, ok := idx.postings[t]
+		if !ok {
+			p = make(map[string]int)
+			idx.postings[t] = p
+		}
+		p[id]++
+	}
+	idx.docs[id] = &doc
+	idx.lengths[id] = len(tokens)
+	idx.totalLen += len(tokens)
+
+	if doc.ThreadTs != "" && doc.User != "" {
+		key := threadKey(doc.ChannelID, doc.ThreadTs)
+		users, ok := idx.threads[key]
+		if !ok {
+			users = make(map[string]int)
+			idx.threads[key] = users
+		}
+		users[doc.User]++
+	}
+}
+
+// Remove drops a document from the index, it is a no-op for unknown documents.
+func (idx *Index) Remove(channelID, ts string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(channelID + "/" + ts)
+}
+
+func (idx *Index) removeLocked(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range Tokenize(doc.Text) {
+		if p, ok := idx.postings[t]; ok {
+			delete(p, id)
+			if len(p) == 0 {
+				delete(idx.postings, t)
+			}
+		}
+	}
+	if doc.ThreadTs != "" && doc.User != "" {
+		key := threadKey(doc.ChannelID, doc.ThreadTs)
+		if users, ok := idx.threads[key]; ok {
+			if users[doc.User]--; users[doc.User] <= 0 {
+				delete(users, doc.User)
+			}
+			if len(users) == 0 {
+				delete(idx.threads, key)
+			}
+		}
+	}
+	idx.totalLen -= idx.lengths[id]
+	delete(idx.lengths, id)
+	delete(idx.docs, id)
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// InThreadWith reports whether user posted in the thread doc belongs to.
+func (idx *Index) InThreadWith(doc *Document, user string) bool {
+	if doc.ThreadTs == "" {
+		return false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.threads[threadKey(doc.ChannelID, doc.ThreadTs)][user]
+	return ok
+}
+
+// Search returns matching documents ranked by BM25, newest first for equal scores.
+// Without terms all documents accepted by the filter are returned, newest first.
+func (idx *Index) Search(q Query) []Result {
+	terms := uniqueTerms(q.Terms)
+
+	idx.mu.RLock()
+	candidates := idx.candidatesLocked(terms)
+	scored := make([]Result, 0, len(candidates))
+	for _, id := range candidates {
+		doc := idx.docs[id]
+		scored = append(scored, Result{Document: doc, Score: idx.scoreLocked(id, terms)})
+	}
+	idx.mu.RUnlock()
+
+	// Filters may call back into the index, so they run without holding the lock.
+	results := scored[:0]
+	for _, r := range scored {
+		if q.Filter == nil || q.Filter(r.Document) {
+			r.Snippet = Snippet(r.Document.Text, terms)
+			results = append(results, r)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Document.Ts > results[j].Document.Ts
+	})
+	return results
+}
+
+func (idx *Index) candidatesLocked(terms []string) []string {
+	if len(terms) == 0 {
+		ids := make([]string, 0, len(idx.docs))
+		for id := range idx.docs {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// Intersect postings, starting from the rarest term.
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(idx.postings[sorted[i]]) < len(idx.postings[sorted[j]])
+	})
+	var ids []string
+	for id := range idx.postings[sorted[0]] {
+		match := true
+		for _, t := range sorted[1:] {
+			if _, ok := idx.postings[t][id]; !ok {
+				match = false
+				break
+			}
+		}
+		if match {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (idx *Index) scoreLocked(id string, terms []string) float64 {
+	if len(terms) == 0 || len(idx.docs) == 0 {
+		return 0
+	}
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+	docLen := float64(idx.lengths[id])
+
+	var score float64
+	for _, t := range terms {
+		df := float64(len(idx.postings[t]))
+		tf := float64(idx.postings[t][id])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+	}
+	return score
+}
+
+// Snippet returns up to snippetRadius characters around the first occurrence of any term.
+func Snippet(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	pos := -1
+	for _, t := range terms {
+		if i := strings.Index(lower, t); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	// Lowercasing may change byte lengths of some runes, never slice past the original text.
+	if pos < 0 || pos > len(text) {
+		pos = 0
+	}
+
+	runes := []rune(text)
+	// Convert the byte offset into a rune offset.
+	runePos := len([]rune(text[:pos]))
+	start := max(0, runePos-snippetRadius)
+	end := min(len(runes), runePos+snippetRadius)
+
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func uniqueTerms(raw []string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	for _, r := range raw {
+		for _, t := range Tokenize(r) {
+			if _, ok := seen[t]; !ok {
+				seen[t] = struct{}{}
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms
+}
+
+func threadKey(channelID, threadTs string) string {
+	return channelID + "/" + threadTs
+}
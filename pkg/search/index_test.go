@@ -0,0 +1,93 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Add(Document{ChannelID: "C1", Ts: "1700000001.000100", User: "U1", Text: "Deploy failed on staging, rolling back"})
+	idx.Add(Document{ChannelID: "C1", Ts: "1700000002.000100", ThreadTs: "1700000001.000100", User: "U2", Text: "deploy deploy deploy"})
+	idx.Add(Document{ChannelID: "C2", Ts: "1700000003.000100", User: "U3", Text: "Lunch anyone?"})
+	return idx
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "wörld", "v2", "rollout"}, Tokenize("Hello, Wörld! v2-rollout"))
+	assert.Empty(t, Tokenize("  ...  "))
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := newTestIndex()
+
+	t.Run("ranks by term frequency", func(t *testing.T) {
+		results := idx.Search(Query{Terms: []string{"deploy"}})
+		require.Len(t, results, 2)
+		assert.Equal(t, "1700000002.000100", results[0].Document.Ts)
+		assert.Greater(t, results[0].Score, results[1].Score)
+	})
+
+	t.Run("requires all terms", func(t *testing.T) {
+		results := idx.Search(Query{Terms: []string{"deploy staging"}})
+		require.Len(t, results, 1)
+		assert.Equal(t, "1700000001.000100", results[0].Document.Ts)
+	})
+
+	t.Run("no terms returns everything newest first", func(t *testing.T) {
+		results := idx.Search(Query{})
+		require.Len(t, results, 3)
+		assert.Equal(t, "1700000003.000100", results[0].Document.Ts)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		results := idx.Search(Query{Filter: func(d *Document) bool { return d.ChannelID == "C2" }})
+		require.Len(t, results, 1)
+		assert.Equal(t, "U3", results[0].Document.User)
+	})
+
+	t.Run("unknown term", func(t *testing.T) {
+		assert.Empty(t, idx.Search(Query{Terms: []string{"nothing"}}))
+	})
+}
+
+func TestIndexAddReplacesAndRemove(t *testing.T) {
+	idx := newTestIndex()
+
+	idx.Add(Document{ChannelID: "C2", Ts: "1700000003.000100", User: "U3", Text: "Dinner anyone?"})
+	assert.Equal(t, 3, idx.Len())
+	assert.Empty(t, idx.Search(Query{Terms: []string{"lunch"}}))
+	assert.Len(t, idx.Search(Query{Terms: []string{"dinner"}}), 1)
+
+	idx.Remove("C2", "1700000003.000100")
+	idx.Remove("C2", "unknown")
+	assert.Equal(t, 2, idx.Len())
+	assert.Empty(t, idx.Search(Query{Terms: []string{"dinner"}}))
+}
+
+func TestIndexInThreadWith(t *testing.T) {
+	idx := newTestIndex()
+	reply := &Document{ChannelID: "C1", ThreadTs: "1700000001.000100"}
+
+	assert.True(t, idx.InThreadWith(reply, "U2"))
+	assert.False(t, idx.InThreadWith(reply, "U3"))
+	assert.False(t, idx.InThreadWith(&Document{ChannelID: "C1"}, "U2"))
+
+	idx.Remove("C1", "1700000002.000100")
+	assert.False(t, idx.InThreadWith(reply, "U2"))
+}
+
+func TestSnippet(t *testing.T) {
+	assert.Equal(t, "short text", Snippet("short  text", []string{"text"}))
+
+	long := strings.Repeat("a ", 100) + "needle" + strings.Repeat(" b", 100)
+	snippet := Snippet(long, []string{"needle"})
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "needle")
+
+	assert.True(t, strings.HasPrefix(Snippet(long, []string{"missing"}), "a a"))
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// VectorResult is a nearest neighbour of a query vector, Score is the cosine similarity.
+type VectorResult struct {
+	ID       string
+	Document *Document
+	Score    float64
+}
+
+// VectorIndex is a brute-force cosine similarity index, which is fast enough for the
+// size of a local archive. It is safe for concurrent use.
+type VectorIndex struct {
+	mu      sync.RWMutex
+	docs    map[string]*Document
+	vectors map[string][]float32
+}
+
+func NewVectorIndex() *VectorIndex {
+	return &VectorIndex{
+		docs:    make(map[string]*Document),
+		vectors: make(map[string][]float32),
+	}
+}
+
+// Add stores vec for doc under id, replacing a previous entry with the same id.
+// Zero vectors are ignored as they have no direction.
+func (vi *VectorIndex) Add(id string, doc Document, vec []float32) {
+	normalized := normalize(vec)
+	if normalized == nil {
+		return
+	}
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	vi.docs[id] = &doc
+	vi.vectors[id] = normalized
+}
+
+// Remove drops an entry, it is a no-op for unknown ids.
+func (vi *VectorIndex) Remove(id string) {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	delete(vi.docs, id)
+	delete(vi.vectors, id)
+}
+
+// Len returns the number of indexed vectors.
+func (vi *VectorIndex) Len() int {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	return len(vi.vectors)
+}
+
+// Nearest returns up to k entries most similar to query and accepted by filter (if set).
+// Entries of a different dimension, e.g. indexed with another model, never match.
+func (vi *VectorIndex) Nearest(query []float32, k int, filter func(id string, doc *Document) bool) []VectorResult {
+	q := normalize(query)
+	if q == nil || k <= 0 {
+		return nil
+	}
+
+	vi.mu.RLock()
+	results := make([]VectorResult, 0, len(vi.vectors))
+	for id, vec := range vi.vectors {
+		if len(vec) != len(q) {
+			continue
+		}
+		doc := vi.docs[id]
+		if filter != nil && !filter(id, doc) {
+			continue
+		}
+		results = append(results, VectorResult{ID: id, Document: doc, Score: dot(q, vec)})
+	}
+	vi.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return nil
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorIndexNearest(t *testing.T) {
+	vi := NewVectorIndex()
+	vi.Add("C1/1", Document{ChannelID: "C1", Ts: "1"}, []float32{1, 0})
+	vi.Add("C1/2", Document{ChannelID: "C1", Ts: "2"}, []float32{10, 10})
+	vi.Add("C2/3", Document{ChannelID: "C2", Ts: "3"}, []float32{0, 3})
+	vi.Add("C2/4", Document{ChannelID: "C2", Ts: "4"}, []float32{0, 0})
+	vi.Add("C2/5", Document{ChannelID: "C2", Ts: "5"}, []float32{1, 0, 0})
+	assert.Equal(t, 4, vi.Len(), "zero vectors are not indexed")
+
+	results := vi.Nearest([]float32{2, 0}, 2, nil)
+	require.Len(t, results, 2)
+	assert.Equal(t, "C1/1", results[0].ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	assert.Equal(t, "C1/2", results[1].ID)
+	assert.InDelta(t, 0.7071, results[1].Score, 1e-4)
+
+	results = vi.Nearest([]float32{1, 0}, 10, func(_ string, d *Document) bool { return d.ChannelID == "C2" })
+	require.Len(t, results, 1, "vectors of another dimension never match")
+	assert.Equal(t, "C2/3", results[0].ID)
+
+	assert.Empty(t, vi.Nearest([]float32{0, 0}, 10, nil))
+	assert.Empty(t, vi.Nearest([]float32{1, 0}, 0, nil))
+}
+
+func TestVectorIndexReplaceAndRemove(t *testing.T) {
+	vi := NewVectorIndex()
+	vi.Add("a", Document{Text: "old"}, []float32{1, 0})
+	vi.Add("a", Document{Text: "new"}, []float32{0, 1})
+
+	results := vi.Nearest([]float32{0, 1}, 1, nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "new", results[0].Document.Text)
+
+	vi.Remove("a")
+	vi.Remove("unknown")
+	assert.Equal(t, 0, vi.Len())
+}
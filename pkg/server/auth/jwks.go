@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// How long fetched keys are used before they are fetched again
+	jwksRefreshInterval = time.Hour
+	// Tokens signed with an unknown key trigger a fetch, at most this often
+	jwksMinFetchInterval = time.Minute
+	jwksFetchTimeout     = 10 * time.Second
+	jwksMaxSize          = 1 << 20
+)
+
+// keySet holds the keys access tokens are verified with, read from a JWKS file or URL.
+// Keys are reloaded periodically and when a token names a key that is not known yet,
+// so the authorization server can rotate its keys without a restart.
+type keySet struct {
+	source string
+	client *http.Client
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func newKeySet(source string, logger *zap.Logger) *keySet {
+	return &keySet{
+		source: source,
+		client: &http.Client{Timeout: jwksFetchTimeout},
+		logger: logger,
+	}
+}
+
+func (k *keySet) isURL() bool {
+	return strings.HasPrefix(k.source, "https://") || strings.HasPrefix(k.source, "http://")
+}
+
+// key returns the key with the given ID, or the only key if kid is empty.
+func (k *keySet) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	stale := time.Since(k.fetchedAt) > jwksRefreshInterval
+	found := k.find(kid)
+	if found == nil || stale {
+		if k.fetchedAt.IsZero() || time.Since(k.fetchedAt) >= jwksMinFetchInterval {
+			if err := k.load(ctx); err != nil {
+				// Keys that were loaded before stay in use while the source is unavailable
+				k.logger.Warn("Failed to load OAuth signing keys",
+					zap.String("context", "http"),
+					zap.String("jwks", k.source),
+					zap.Error(err),
+				)
+			}
+			found = k.find(kid)
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return found, nil
+}
+
+func (k *keySet) find(kid string) *jose.JSONWebKey {
+	if kid == "" {
+		if len(k.keys.Keys) == 1 {
+			return &k.keys.Keys[0]
+		}
+		return nil
+	}
+	for _, key := range k.keys.Key(kid) {
+		if key.Use == "" || key.Use == "sig" {
+			return &key
+		}
+	}
+	return nil
+}
+
+// load must be called with mu held.
+func (k *keySet) load(ctx context.Context) error {
+	k.fetchedAt = time.Now()
+
+	var (
+		data []byte
+		err  error
+	)
+	if k.isURL() {
+		data, err = k.fetch(ctx)
+	} else {
+		data, err = os.ReadFile(k.source)
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return fmt.Errorf("JWKS holds no keys")
+	}
+	for _, key := range keys.Keys {
+		if !key.IsPublic() {
+			return fmt.Errorf("JWKS key %q is not a public key", key.KeyID)
+		}
+	}
+	k.keys = keys
+	return nil
+}
+
+func (k *keySet) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+}
@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultKeyName is the principal name of SLACK_MCP_API_KEY.
+const DefaultKeyName = "default"
+
+var (
+	ErrKeyExpired     = errors.New("api key expired")
+	ErrToolNotAllowed = errors.New("tool not allowed")
+)
+
+// Principal is the API key a request was authenticated with.
+type Principal struct {
+	Name string
+	// Tools the key may call, all enabled tools if empty
+	Tools []string
+	// Channels the key may read from and write to, by ID, #name or @user, in the format of
+	// SLACK_MCP_ADD_MESSAGE_TOOL: an allowlist, or a denylist if the first entry starts with "!".
+	// All channels if empty.
+	ReadChannels  []string
+	WriteChannels []string
+	ExpiresAt     time.Time
+	// Slack token the key acts as with SLACK_MCP_TOKEN_PASSTHROUGH, a user token (xoxp) or a
+	// session token (xoxc) with its cookie (xoxd)
+	SlackToken  string
+	SlackCookie string
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the API key the tool call was authenticated with. It is only
+// set on the HTTP transports with SLACK_MCP_API_KEY or SLACK_MCP_API_KEYS_FILE configured.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+type slackCallerKey struct{}
+
+// WithSlackCaller attaches the Slack identity of a caller that sent its own Slack token
+// with token pass-through, named "slack:<team ID>:<user ID>".
+func WithSlackCaller(ctx context.Context, teamID, userID string) context.Context {
+	return context.WithValue(ctx, slackCallerKey{}, "slack:"+teamID+":"+userID)
+}
+
+// SlackCallerFromContext returns the Slack identity attached with WithSlackCaller.
+func SlackCallerFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(slackCallerKey{}).(string)
+	return name, ok
+}
+
+// AllowsTool reports whether the key may call tool.
+func (p *Principal) AllowsTool(tool string) bool {
+	return len(p.Tools) == 0 || slices.Contains(p.Tools, "*") || slices.Contains(p.Tools, tool)
+}
+
+// apiKeyEntry is an entry of SLACK_MCP_API_KEYS_FILE. The key is given either in plain text
+// or as the hex encoded SHA-256 of the key, so the file does not have to contain secrets.
+type apiKeyEntry struct {
+	Name          string     `json:"name"`
+	Key           string     `json:"key,omitempty"`
+	KeySHA256     string     `json:"key_sha256,omitempty"`
+	Tools         []string   `json:"tools,omitempty"`
+	ReadChannels  []string   `json:"read_channels,omitempty"`
+	WriteChannels []string   `json:"write_channels,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	SlackToken    string     `json:"slack_token,omitempty"`
+	SlackCookie   string     `json:"slack_cookie,omitempty"`
+}
+
+type apiKey struct {
+	hash      [sha256.Size]byte
+	principal *Principal
+}
+
+// keyFile is SLACK_MCP_API_KEYS_FILE, reloaded when it changes so keys can be added and
+// revoked without a restart.
+type keyFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	keys    []apiKey
+	err     error
+}
+
+var (
+	keyFilesMu sync.Mutex
+	keyFiles   = map[string]*keyFile{}
+)
+
+// apiKeysFile returns the key file of SLACK_MCP_API_KEYS_FILE, nil if it is not set.
+func apiKeysFile() *keyFile {
+	path := os.Getenv("SLACK_MCP_API_KEYS_FILE")
+	if path == "" {
+		return nil
+	}
+	keyFilesMu.Lock()
+	defer keyFilesMu.Unlock()
+	f, ok := keyFiles[path]
+	if !ok {
+		f = &keyFile{path: path}
+		keyFiles[path] = f
+	}
+	return f
+}
+
+// LoadAPIKeysFromEnv reads SLACK_MCP_API_KEYS_FILE, if set, so a broken file fails startup
+// instead of every request.
+func LoadAPIKeysFromEnv() error {
+	f := apiKeysFile()
+	if f == nil {
+		return nil
+	}
+	_, err := f.current()
+	return err
+}
+
+// current returns the keys of the file, reading it again if it changed. A file that became
+// invalid rejects all keys until it is fixed rather than keeping revoked keys alive.
+func (f *keyFile) current() ([]apiKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLACK_MCP_API_KEYS_FILE: %w", err)
+	}
+	if f.keys != nil || f.err != nil {
+		if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+			return f.keys, f.err
+		}
+	}
+
+	f.modTime, f.size = info.ModTime(), info.Size()
+	f.keys, f.err = readKeyFile(f.path)
+	return f.keys, f.err
+}
+
+func readKeyFile(path string) ([]apiKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLACK_MCP_API_KEYS_FILE: %w", err)
+	}
+	var file struct {
+		Keys []apiKeyEntry `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid SLACK_MCP_API_KEYS_FILE: %w", err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, errors.New("invalid SLACK_MCP_API_KEYS_FILE: no keys")
+	}
+
+	keys := make([]apiKey, 0, len(file.Keys))
+	names := map[string]bool{}
+	for i, e := range file.Keys {
+		key, err := e.parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLACK_MCP_API_KEYS_FILE: key %d: %w", i+1, err)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("invalid SLACK_MCP_API_KEYS_FILE: duplicate key name %q", e.Name)
+		}
+		names[e.Name] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (e apiKeyEntry) parse() (apiKey, error) {
+	if e.Name == "" {
+		return apiKey{}, errors.New("name is required")
+	}
+	if e.Name == DefaultKeyName {
+		return apiKey{}, fmt.Errorf("name %q is reserved for SLACK_MCP_API_KEY", DefaultKeyName)
+	}
+
+	var key apiKey
+	switch {
+	case e.Key != "" && e.KeySHA256 != "":
+		return apiKey{}, fmt.Errorf("%s: set either key or key_sha256, not both", e.Name)
+	case e.Key != "":
+		key.hash = sha256.Sum256([]byte(e.Key))
+	case e.KeySHA256 != "":
+		hash, err := hex.DecodeString(e.KeySHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return apiKey{}, fmt.Errorf("%s: key_sha256 must be 64 hex characters", e.Name)
+		}
+		copy(key.hash[:], hash)
+	default:
+		return apiKey{}, fmt.Errorf("%s: key or key_sha256 is required", e.Name)
+	}
+
+	key.principal = &Principal{
+		Name:          e.Name,
+		Tools:         e.Tools,
+		ReadChannels:  e.ReadChannels,
+		WriteChannels: e.WriteChannels,
+		SlackToken:    e.SlackToken,
+		SlackCookie:   e.SlackCookie,
+	}
+	if e.ExpiresAt != nil {
+		key.principal.ExpiresAt = *e.ExpiresAt
+	}
+	return key, nil
+}
+
+// lookup returns the principal of token, nil if no key matches. Every key is compared so
+// the time taken does not tell which one was close.
+func (f *keyFile) lookup(token string) (*Principal, error) {
+	keys, err := f.current()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(token))
+	var found *Principal
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			found = k.principal
+		}
+	}
+	if found != nil && !found.ExpiresAt.IsZero() && time.Now().After(found.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s expired at %s", ErrKeyExpired, found.Name, found.ExpiresAt.Format(time.RFC3339))
+	}
+	return found, nil
+}
+
+// ToolFilter lists only the tools the API key of the request may call. Without a key file
+// it lists all tools.
+func ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	f := apiKeysFile()
+	if f == nil {
+		return tools
+	}
+	keyA := os.Getenv("SLACK_MCP_API_KEY")
+	if keyA == "" {
+		keyA = os.Getenv("SLACK_MCP_SSE_API_KEY")
+	}
+	token, _ := ctx.Value(authKey{}).(string)
+	p, err := resolveKey(keyA, f, strings.TrimPrefix(token, "Bearer "))
+	if err != nil || p == nil {
+		return nil
+	}
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if p.AllowsTool(tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
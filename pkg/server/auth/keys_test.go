@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeKeysFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_SSE_API_KEY", "")
+	t.Setenv("SLACK_MCP_OAUTH_ISSUER", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", path)
+	return path
+}
+
+func requestContext(token string) context.Context {
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return AuthFromRequest(zap.NewNop())(context.Background(), r)
+}
+
+func TestUnitLoadAPIKeysFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"keys":[{"name":"ci","key":"secret"}]}`, ""},
+		{"malformed", `{"keys":`, "invalid SLACK_MCP_API_KEYS_FILE"},
+		{"no keys", `{"keys":[]}`, "no keys"},
+		{"missing name", `{"keys":[{"key":"secret"}]}`, "name is required"},
+		{"reserved name", `{"keys":[{"name":"default","key":"secret"}]}`, "reserved"},
+		{"missing key", `{"keys":[{"name":"ci"}]}`, "key or key_sha256 is required"},
+		{"key and hash", `{"keys":[{"name":"ci","key":"a","key_sha256":"b"}]}`, "not both"},
+		{"invalid hash", `{"keys":[{"name":"ci","key_sha256":"abc"}]}`, "64 hex characters"},
+		{"duplicate name", `{"keys":[{"name":"ci","key":"a"},{"name":"ci","key":"b"}]}`, "duplicate key name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeKeysFile(t, tt.content)
+			err := LoadAPIKeysFromEnv()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, LoadAPIKeysFromEnv())
+}
+
+func TestUnitAPIKeysMiddleware(t *testing.T) {
+	hash := sha256.Sum256([]byte("hashed-secret"))
+	writeKeysFile(t, `{"keys":[
+		{"name":"reader","key":"reader-secret","tools":["channels_list"],"read_channels":["C1"]},
+		{"name":"hashed","key_sha256":"`+hex.EncodeToString(hash[:])+`"},
+		{"name":"expired","key":"expired-secret","expires_at":"2020-01-01T00:00:00Z"}
+	]}`)
+
+	var got *Principal
+	handler := BuildMiddleware("http", zap.NewNop())(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		got, _ = PrincipalFromContext(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(token, tool string) error {
+		got = nil
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		_, err := handler(requestContext(token), req)
+		return err
+	}
+
+	require.NoError(t, call("reader-secret", "channels_list"))
+	require.NotNil(t, got)
+	assert.Equal(t, "reader", got.Name)
+	assert.Equal(t, []string{"C1"}, got.ReadChannels)
+
+	err := call("reader-secret", "conversations_add_message")
+	assert.ErrorIs(t, err, ErrToolNotAllowed)
+
+	require.NoError(t, call("hashed-secret", "conversations_add_message"))
+	assert.Equal(t, "hashed", got.Name)
+
+	assert.ErrorIs(t, call("expired-secret", "channels_list"), ErrKeyExpired)
+	assert.Error(t, call("unknown", "channels_list"))
+	assert.Error(t, call("", "channels_list"))
+
+	// SLACK_MCP_API_KEY keeps working next to the file, without restrictions
+	t.Setenv("SLACK_MCP_API_KEY", "env-secret")
+	require.NoError(t, call("env-secret", "conversations_add_message"))
+	assert.Equal(t, DefaultKeyName, got.Name)
+	require.NoError(t, call("reader-secret", "channels_list"))
+	assert.Equal(t, "reader", got.Name)
+
+	// stdio has no API keys
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "conversations_add_message"
+	_, err = BuildMiddleware("stdio", zap.NewNop())(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		_, ok := PrincipalFromContext(ctx)
+		assert.False(t, ok)
+		return nil, nil
+	})(context.Background(), req)
+	assert.NoError(t, err)
+}
+
+func TestUnitAPIKeysReload(t *testing.T) {
+	path := writeKeysFile(t, `{"keys":[{"name":"old","key":"old-secret"}]}`)
+	ctx, err := Authenticate(requestContext("old-secret"), "http", zap.NewNop())
+	require.NoError(t, err)
+	p, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "old", p.Name)
+
+	// Revoking a key takes effect without a restart
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"name":"new","key":"new-secret"}]}`), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, err = Authenticate(requestContext("old-secret"), "http", zap.NewNop())
+	assert.Error(t, err)
+	_, err = Authenticate(requestContext("new-secret"), "http", zap.NewNop())
+	assert.NoError(t, err)
+
+	// A broken file rejects every key instead of keeping the previous ones
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[`), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, err = Authenticate(requestContext("new-secret"), "http", zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestUnitAPIKeysToolFilter(t *testing.T) {
+	tools := []mcp.Tool{{Name: "channels_list"}, {Name: "conversations_add_message"}}
+
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", "")
+	assert.Len(t, ToolFilter(requestContext(""), tools), 2)
+
+	writeKeysFile(t, `{"keys":[
+		{"name":"reader","key":"reader-secret","tools":["channels_list"]},
+		{"name":"admin","key":"admin-secret","tools":["*"]}
+	]}`)
+	filtered := ToolFilter(requestContext("reader-secret"), tools)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "channels_list", filtered[0].Name)
+	assert.Len(t, ToolFilter(requestContext("admin-secret"), tools), 2)
+	assert.Empty(t, ToolFilter(requestContext("unknown"), tools))
+}
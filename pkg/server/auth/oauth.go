@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+const (
+	// ScopeRead allows the read-only tools unless SLACK_MCP_OAUTH_SCOPES says otherwise
+	ScopeRead = "slack:read"
+	// ScopeWrite allows the tools that change Slack unless SLACK_MCP_OAUTH_SCOPES says otherwise
+	ScopeWrite = "slack:write"
+
+	protectedResourcePath = "/.well-known/oauth-protected-resource"
+	tokenLeeway           = time.Minute
+)
+
+// Access tokens must be signed with an asymmetric algorithm, the server only holds public keys
+var allowedAlgorithms = []string{
+	string(jose.RS256), string(jose.RS384), string(jose.RS512),
+	string(jose.PS256), string(jose.PS384), string(jose.PS512),
+	string(jose.ES256), string(jose.ES384), string(jose.ES512),
+	string(jose.EdDSA),
+}
+
+var ErrInsufficientScope = errors.New("insufficient_scope")
+
+// Claims are the verified claims of an OAuth access token.
+type Claims struct {
+	Subject  string
+	ClientID string
+	Scopes   []string
+}
+
+type claimsKey struct{}
+
+func withClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the access token the request was authorized with.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// oauthEnabled reports whether HTTP requests are authorized with OAuth access tokens
+// instead of SLACK_MCP_API_KEY.
+func oauthEnabled() bool {
+	return os.Getenv("SLACK_MCP_OAUTH_ISSUER") != ""
+}
+
+// OAuth makes the server an OAuth 2.1 resource server as described by the MCP authorization
+// spec: it accepts JWT access tokens issued by an external authorization server for this
+// server's resource URL, publishes protected resource metadata (RFC 9728) so clients can
+// find the authorization server, and maps token scopes to the tools they allow.
+type OAuth struct {
+	issuer   string
+	resource string
+	keys     *keySet
+	logger   *zap.Logger
+
+	// Scope to tools mapping from SLACK_MCP_OAUTH_SCOPES, or the defaults built with AddTool
+	configured bool
+	mu         sync.RWMutex
+	scopes     ScopeMap
+}
+
+// NewOAuthFromEnv returns nil if SLACK_MCP_OAUTH_ISSUER is not set.
+func NewOAuthFromEnv(logger *zap.Logger) (*OAuth, error) {
+	issuer := os.Getenv("SLACK_MCP_OAUTH_ISSUER")
+	if issuer == "" {
+		return nil, nil
+	}
+	if os.Getenv("SLACK_MCP_API_KEY") != "" || os.Getenv("SLACK_MCP_SSE_API_KEY") != "" || os.Getenv("SLACK_MCP_API_KEYS_FILE") != "" {
+		return nil, errors.New("SLACK_MCP_API_KEY or SLACK_MCP_API_KEYS_FILE and SLACK_MCP_OAUTH_ISSUER cannot be combined, choose one authentication mode")
+	}
+
+	resource := os.Getenv("SLACK_MCP_OAUTH_RESOURCE")
+	if resource == "" {
+		return nil, errors.New("SLACK_MCP_OAUTH_RESOURCE is required with SLACK_MCP_OAUTH_ISSUER, set it to the public URL of the server, e.g. https://mcp.example.com/mcp")
+	}
+	if u, err := url.Parse(resource); err != nil || u.Scheme == "" || u.Host == "" || u.Fragment != "" {
+		return nil, fmt.Errorf("SLACK_MCP_OAUTH_RESOURCE must be an absolute URL without fragment, got %q", resource)
+	}
+
+	jwks := os.Getenv("SLACK_MCP_OAUTH_JWKS")
+	if jwks == "" {
+		return nil, errors.New("SLACK_MCP_OAUTH_JWKS is required with SLACK_MCP_OAUTH_ISSUER, set it to the JWKS URL of the authorization server or a local JWKS file")
+	}
+
+	o := &OAuth{
+		issuer:   issuer,
+		resource: resource,
+		keys:     newKeySet(jwks, logger),
+		logger:   logger,
+		scopes:   ScopeMap{},
+	}
+	if env := os.Getenv("SLACK_MCP_OAUTH_SCOPES"); env != "" {
+		scopes, err := ParseScopeMap(env)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLACK_MCP_OAUTH_SCOPES: %w", err)
+		}
+		o.scopes = scopes
+		o.configured = true
+	}
+
+	// A local file is read right away so a wrong path fails startup, URLs are fetched on first use
+	if !o.keys.isURL() {
+		o.keys.mu.Lock()
+		err := o.keys.load(context.Background())
+		o.keys.mu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SLACK_MCP_OAUTH_JWKS: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// AddTool records the default scope of a tool: slack:read for read-only tools, slack:write
+// for the others. It does nothing if SLACK_MCP_OAUTH_SCOPES is set.
+func (o *OAuth) AddTool(name string, readOnly bool) {
+	if o.configured {
+		return
+	}
+	scope := ScopeWrite
+	if readOnly {
+		scope = ScopeRead
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.scopes[scope] = append(o.scopes[scope], name)
+}
+
+// Validate verifies the signature, issuer, audience and lifetime of an access token.
+func (o *OAuth) Validate(ctx context.Context, raw string) (*Claims, error) {
+	tok, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed access token: %w", err)
+	}
+	if len(tok.Headers) != 1 {
+		return nil, errors.New("access token must have exactly one signature")
+	}
+	header := tok.Headers[0]
+	if !slices.Contains(allowedAlgorithms, header.Algorithm) {
+		return nil, fmt.Errorf("access token signing algorithm %q is not allowed", header.Algorithm)
+	}
+
+	key, err := o.keys.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return nil, fmt.Errorf("access token algorithm %q does not match key %q", header.Algorithm, key.KeyID)
+	}
+
+	var (
+		std   jwt.Claims
+		extra struct {
+			Scope    string   `json:"scope"`
+			Scp      []string `json:"scp"`
+			ClientID string   `json:"client_id"`
+			Azp      string   `json:"azp"`
+		}
+	)
+	if err := tok.Claims(key.Key, &std, &extra); err != nil {
+		return nil, fmt.Errorf("invalid access token signature: %w", err)
+	}
+	if std.Expiry == nil {
+		return nil, errors.New("access token has no expiry")
+	}
+	err = std.ValidateWithLeeway(jwt.Expected{
+		Issuer:   o.issuer,
+		Audience: jwt.Audience{o.resource},
+		Time:     time.Now(),
+	}, tokenLeeway)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{
+		Subject:  std.Subject,
+		ClientID: extra.ClientID,
+		Scopes:   append(strings.Fields(extra.Scope), extra.Scp...),
+	}
+	if claims.ClientID == "" {
+		claims.ClientID = extra.Azp
+	}
+	return claims, nil
+}
+
+// Protect rejects requests to next without a valid access token, telling the client where
+// to find the protected resource metadata.
+func (o *OAuth) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || raw == "" {
+			o.challenge(w, "", "")
+			return
+		}
+		claims, err := o.Validate(r.Context(), strings.TrimSpace(raw))
+		if err != nil {
+			o.logger.Warn("Invalid OAuth access token",
+				zap.String("context", "http"),
+				zap.Error(err),
+			)
+			o.challenge(w, "invalid_token", "the access token is invalid or expired")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+	})
+}
+
+func (o *OAuth) challenge(w http.ResponseWriter, code, description string) {
+	value := fmt.Sprintf("Bearer resource_metadata=%q", o.metadataURL())
+	if code != "" {
+		value += fmt.Sprintf(", error=%q, error_description=%q", code, description)
+	}
+	w.Header().Set("WWW-Authenticate", value)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// metadataURL is where RFC 9728 says clients look for the metadata of the resource: the
+// well-known path inserted between the host and the path of the resource URL.
+func (o *OAuth) metadataURL() string {
+	u, _ := url.Parse(o.resource)
+	return u.Scheme + "://" + u.Host + o.metadataPath()
+}
+
+func (o *OAuth) metadataPath() string {
+	u, _ := url.Parse(o.resource)
+	return protectedResourcePath + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+type protectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers"`
+	ScopesSupported        []string `json:"scopes_supported"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+	ResourceName           string   `json:"resource_name"`
+}
+
+// HandleMetadata registers the protected resource metadata on mux.
+func (o *OAuth) HandleMetadata(mux *http.ServeMux) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		o.mu.RLock()
+		scopes := o.scopes.Scopes()
+		o.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(protectedResourceMetadata{
+			Resource:               o.resource,
+			AuthorizationServers:   []string{o.issuer},
+			ScopesSupported:        scopes,
+			BearerMethodsSupported: []string{"header"},
+			ResourceName:           "Slack MCP Server",
+		}); err != nil {
+			o.logger.Warn("Failed to write protected resource metadata", zap.Error(err))
+		}
+	}
+	mux.HandleFunc("GET "+protectedResourcePath, handler)
+	if path := o.metadataPath(); path != protectedResourcePath {
+		mux.HandleFunc("GET "+path, handler)
+	}
+}
+
+// allows reports whether the access token of ctx may call tool.
+func (o *OAuth) allows(ctx context.Context, tool string) bool {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return false
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.scopes.Allows(claims.Scopes, tool)
+}
+
+// ToolFilter lists only the tools the access token's scopes allow.
+func (o *OAuth) ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if o.allows(ctx, tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// Middleware rejects calls of tools the access token's scopes do not allow.
+func (o *OAuth) Middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !o.allows(ctx, req.Params.Name) {
+			o.logger.Warn("Access token scopes do not allow tool",
+				zap.String("context", "http"),
+				zap.String("tool", req.Params.Name),
+			)
+			return nil, fmt.Errorf("%w: the access token does not allow %s", ErrInsufficientScope, req.Params.Name)
+		}
+		return next(ctx, req)
+	}
+}
+
+// ScopeMap maps OAuth scopes to the tools they allow, "*" stands for all tools.
+type ScopeMap map[string][]string
+
+// ParseScopeMap parses scope to tools mappings like
+// "slack:read=channels_list,conversations_history;slack:admin=*".
+func ParseScopeMap(s string) (ScopeMap, error) {
+	m := ScopeMap{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scope, tools, ok := strings.Cut(entry, "=")
+		scope = strings.TrimSpace(scope)
+		if !ok || scope == "" || strings.ContainsAny(scope, " \t") {
+			return nil, fmt.Errorf("expected scope=tool,tool in %q", entry)
+		}
+		for _, tool := range strings.Split(tools, ",") {
+			if tool = strings.TrimSpace(tool); tool != "" {
+				m[scope] = append(m[scope], tool)
+			}
+		}
+		if len(m[scope]) == 0 {
+			return nil, fmt.Errorf("scope %q allows no tools", scope)
+		}
+	}
+	if len(m) == 0 {
+		return nil, errors.New("no scopes")
+	}
+	return m, nil
+}
+
+// Allows reports whether any of scopes allows tool.
+func (m ScopeMap) Allows(scopes []string, tool string) bool {
+	for _, scope := range scopes {
+		for _, allowed := range m[scope] {
+			if allowed == "*" || allowed == tool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Scopes returns the scopes of m, sorted.
+func (m ScopeMap) Scopes() []string {
+	scopes := make([]string, 0, len(m))
+	for scope := range m {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
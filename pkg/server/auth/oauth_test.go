@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testResource = "https://mcp.example.com/mcp"
+)
+
+type testAuthServer struct {
+	key *rsa.PrivateKey
+}
+
+func newTestAuthServer(t *testing.T) *testAuthServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &testAuthServer{key: key}
+}
+
+func (a *testAuthServer) jwks(t *testing.T) string {
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key: &a.key.PublicKey, KeyID: "k1", Algorithm: string(jose.RS256), Use: "sig",
+	}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func (a *testAuthServer) token(t *testing.T, claims jwt.Claims, scope string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: a.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "k1"))
+	require.NoError(t, err)
+	raw, err := jwt.Signed(signer).Claims(claims).Claims(map[string]any{
+		"scope":     scope,
+		"client_id": "claude",
+	}).CompactSerialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func validClaims() jwt.Claims {
+	return jwt.Claims{
+		Issuer:   testIssuer,
+		Subject:  "alice",
+		Audience: jwt.Audience{testResource},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func newTestOAuth(t *testing.T, as *testAuthServer) *OAuth {
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_SSE_API_KEY", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", "")
+	t.Setenv("SLACK_MCP_OAUTH_ISSUER", testIssuer)
+	t.Setenv("SLACK_MCP_OAUTH_RESOURCE", testResource)
+	t.Setenv("SLACK_MCP_OAUTH_JWKS", as.jwks(t))
+	t.Setenv("SLACK_MCP_OAUTH_SCOPES", "")
+	o, err := NewOAuthFromEnv(zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, o)
+	o.AddTool("channels_list", true)
+	o.AddTool("conversations_add_message", false)
+	return o
+}
+
+func TestUnitNewOAuthFromEnv(t *testing.T) {
+	t.Setenv("SLACK_MCP_OAUTH_ISSUER", "")
+	o, err := NewOAuthFromEnv(zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, o, "OAuth is off unless an issuer is set")
+
+	as := newTestAuthServer(t)
+	newTestOAuth(t, as)
+
+	for name, env := range map[string][2]string{
+		"api key as well":   {"SLACK_MCP_API_KEY", "secret"},
+		"api keys as well":  {"SLACK_MCP_API_KEYS_FILE", "keys.json"},
+		"relative resource": {"SLACK_MCP_OAUTH_RESOURCE", "/mcp"},
+		"missing jwks file": {"SLACK_MCP_OAUTH_JWKS", filepath.Join(t.TempDir(), "missing.json")},
+		"invalid scope map": {"SLACK_MCP_OAUTH_SCOPES", "slack:read"},
+		"missing jwks":      {"SLACK_MCP_OAUTH_JWKS", ""},
+		"missing resource":  {"SLACK_MCP_OAUTH_RESOURCE", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			_, err := NewOAuthFromEnv(zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUnitOAuthValidate(t *testing.T) {
+	as := newTestAuthServer(t)
+	o := newTestOAuth(t, as)
+	ctx := context.Background()
+
+	claims, err := o.Validate(ctx, as.token(t, validClaims(), "slack:read other"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, "claude", claims.ClientID)
+	assert.Equal(t, []string{"slack:read", "other"}, claims.Scopes)
+
+	invalid := map[string]func(c *jwt.Claims){
+		"wrong audience": func(c *jwt.Claims) { c.Audience = jwt.Audience{"https://other.example.com"} },
+		"wrong issuer":   func(c *jwt.Claims) { c.Issuer = "https://evil.example.com" },
+		"expired":        func(c *jwt.Claims) { c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour)) },
+		"no expiry":      func(c *jwt.Claims) { c.Expiry = nil },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			c := validClaims()
+			mutate(&c)
+			_, err := o.Validate(ctx, as.token(t, c, "slack:read"))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("signed by another key", func(t *testing.T) {
+		other := newTestAuthServer(t)
+		_, err := o.Validate(ctx, other.token(t, validClaims(), "slack:read"))
+		assert.Error(t, err)
+	})
+
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")},
+			(&jose.SignerOptions{}).WithHeader("kid", "k1"))
+		require.NoError(t, err)
+		raw, err := jwt.Signed(signer).Claims(validClaims()).CompactSerialize()
+		require.NoError(t, err)
+		_, err = o.Validate(ctx, raw)
+		assert.ErrorContains(t, err, "not allowed")
+	})
+}
+
+func TestUnitOAuthProtect(t *testing.T) {
+	as := newTestAuthServer(t)
+	o := newTestOAuth(t, as)
+
+	var got *Claims
+	h := o.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ClaimsFromContext(r.Context())
+	}))
+	do := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`, rec.Header().Get("WWW-Authenticate"))
+
+	rec = do("Bearer not-a-jwt")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	rec = do("Bearer " + as.token(t, validClaims(), "slack:read"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, got)
+	assert.Equal(t, "alice", got.Subject)
+
+	authenticated, err := IsAuthenticated(withClaims(context.Background(), got), "http", zap.NewNop())
+	assert.True(t, authenticated)
+	assert.NoError(t, err)
+	authenticated, _ = IsAuthenticated(withAuthKey(context.Background(), "Bearer anything"), "http", zap.NewNop())
+	assert.False(t, authenticated, "without verified claims the request is not authenticated")
+}
+
+func TestUnitOAuthScopes(t *testing.T) {
+	as := newTestAuthServer(t)
+	o := newTestOAuth(t, as)
+	readCtx := withClaims(context.Background(), &Claims{Scopes: []string{ScopeRead}})
+
+	tools := []mcp.Tool{mcp.NewTool("channels_list"), mcp.NewTool("conversations_add_message")}
+	filtered := o.ToolFilter(readCtx, tools)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "channels_list", filtered[0].Name)
+
+	next := func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "conversations_add_message"
+	_, err := o.Middleware(next)(readCtx, req)
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+
+	writeCtx := withClaims(context.Background(), &Claims{Scopes: []string{ScopeRead, ScopeWrite}})
+	res, err := o.Middleware(next)(writeCtx, req)
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+
+	mux := http.NewServeMux()
+	o.HandleMetadata(mux)
+	for _, path := range []string{"/.well-known/oauth-protected-resource", "/.well-known/oauth-protected-resource/mcp"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		var meta protectedResourceMetadata
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &meta))
+		assert.Equal(t, testResource, meta.Resource)
+		assert.Equal(t, []string{testIssuer}, meta.AuthorizationServers)
+		assert.Equal(t, []string{ScopeRead, ScopeWrite}, meta.ScopesSupported)
+	}
+}
+
+func TestUnitParseScopeMap(t *testing.T) {
+	m, err := ParseScopeMap("slack:read = channels_list, conversations_history ; slack:admin=*")
+	require.NoError(t, err)
+	assert.Equal(t, ScopeMap{
+		"slack:read":  {"channels_list", "conversations_history"},
+		"slack:admin": {"*"},
+	}, m)
+	assert.True(t, m.Allows([]string{"slack:read"}, "channels_list"))
+	assert.False(t, m.Allows([]string{"slack:read"}, "reactions_add"))
+	assert.True(t, m.Allows([]string{"other", "slack:admin"}, "reactions_add"))
+	assert.False(t, m.Allows(nil, "channels_list"))
+
+	for _, s := range []string{"", "slack:read", "slack:read=", "=channels_list", "slack read=channels_list"} {
+		_, err := ParseScopeMap(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestUnitKeySetRotation(t *testing.T) {
+	first := newTestAuthServer(t)
+	second := newTestAuthServer(t)
+	current := first
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key: &current.key.PublicKey, KeyID: "k1", Algorithm: string(jose.RS256),
+		}}})
+	}))
+	defer ts.Close()
+
+	ks := newKeySet(ts.URL, zap.NewNop())
+	ctx := context.Background()
+	key, err := ks.key(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, &first.key.PublicKey, key.Key)
+	_, err = ks.key(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches, "keys are cached")
+
+	_, err = ks.key(ctx, "k2")
+	assert.Error(t, err)
+	assert.Equal(t, 1, fetches, "unknown keys do not refetch more than once a minute")
+
+	current = second
+	ks.fetchedAt = time.Now().Add(-jwksRefreshInterval - time.Second)
+	key, err = ks.key(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, &second.key.PublicKey, key.Key, "rotated keys are picked up")
+}
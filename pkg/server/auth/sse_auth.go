@@ -23,6 +23,26 @@ func withAuthKey(ctx context.Context, auth string) context.Context {
 
 // Authenticate checks if the request is authenticated based on the provided context.
 func validateToken(ctx context.Context, logger *zap.Logger) (bool, error) {
+	if _, err := authenticate(ctx, logger); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// authenticate checks the credentials of ctx and returns the API key they belong to, nil
+// if no API key is configured or the request was authorized with OAuth.
+func authenticate(ctx context.Context, logger *zap.Logger) (*Principal, error) {
+	// With OAuth the access token was verified when the HTTP request came in, see OAuth.Protect
+	if oauthEnabled() {
+		if _, ok := ClaimsFromContext(ctx); !ok {
+			logger.Warn("Missing OAuth access token in context",
+				zap.String("context", "http"),
+			)
+			return nil, fmt.Errorf("missing access token")
+		}
+		return nil, nil
+	}
+
 	// no configured token means no authentication
 	keyA := os.Getenv("SLACK_MCP_API_KEY")
 	if keyA == "" {
@@ -31,12 +51,13 @@ func validateToken(ctx context.Context, logger *zap.Logger) (bool, error) {
 			logger.Warn("SLACK_MCP_SSE_API_KEY is deprecated, please use SLACK_MCP_API_KEY")
 		}
 	}
+	keys := apiKeysFile()
 
-	if keyA == "" {
+	if keyA == "" && keys == nil {
 		logger.Debug("No SSE API key configured, skipping authentication",
 			zap.String("context", "http"),
 		)
-		return true, nil
+		return nil, nil
 	}
 
 	keyB, ok := ctx.Value(authKey{}).(string)
@@ -44,7 +65,7 @@ func validateToken(ctx context.Context, logger *zap.Logger) (bool, error) {
 		logger.Warn("Missing auth token in context",
 			zap.String("context", "http"),
 		)
-		return false, fmt.Errorf("missing auth")
+		return nil, fmt.Errorf("missing auth")
 	}
 
 	logger.Debug("Validating auth token",
@@ -56,17 +77,37 @@ func validateToken(ctx context.Context, logger *zap.Logger) (bool, error) {
 		keyB = strings.TrimPrefix(keyB, "Bearer ")
 	}
 
-	if subtle.ConstantTimeCompare([]byte(keyA), []byte(keyB)) != 1 {
+	principal, err := resolveKey(keyA, keys, keyB)
+	if err != nil {
 		logger.Warn("Invalid auth token provided",
 			zap.String("context", "http"),
+			zap.Error(err),
 		)
-		return false, fmt.Errorf("invalid auth token")
+		return nil, err
 	}
 
 	logger.Debug("Auth token validated successfully",
 		zap.String("context", "http"),
+		zap.String("key", principal.Name),
 	)
-	return true, nil
+	return principal, nil
+}
+
+// resolveKey returns the principal of token: SLACK_MCP_API_KEY (keyA) or a key of keys.
+func resolveKey(keyA string, keys *keyFile, token string) (*Principal, error) {
+	if keyA != "" && subtle.ConstantTimeCompare([]byte(keyA), []byte(token)) == 1 {
+		return &Principal{Name: DefaultKeyName}, nil
+	}
+	if keys != nil {
+		principal, err := keys.lookup(token)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			return principal, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid auth token")
 }
 
 // AuthFromRequest extracts the auth token from the request headers.
@@ -87,7 +128,8 @@ func BuildMiddleware(transport string, logger *zap.Logger) server.ToolHandlerMid
 				zap.String("tool", req.Params.Name),
 			)
 
-			if authenticated, err := IsAuthenticated(ctx, transport, logger); !authenticated {
+			principal, err := authenticateTransport(ctx, transport, logger)
+			if err != nil {
 				logger.Error("Authentication failed",
 					zap.String("context", "http"),
 					zap.String("transport", transport),
@@ -97,6 +139,18 @@ func BuildMiddleware(transport string, logger *zap.Logger) server.ToolHandlerMid
 				return nil, err
 			}
 
+			if principal != nil {
+				if !principal.AllowsTool(req.Params.Name) {
+					logger.Warn("API key does not allow tool",
+						zap.String("context", "http"),
+						zap.String("key", principal.Name),
+						zap.String("tool", req.Params.Name),
+					)
+					return nil, fmt.Errorf("%w: API key %q may not call %s", ErrToolNotAllowed, principal.Name, req.Params.Name)
+				}
+				ctx = withPrincipal(ctx, principal)
+			}
+
 			logger.Debug("Authentication successful",
 				zap.String("context", "http"),
 				zap.String("transport", transport),
@@ -110,35 +164,50 @@ func BuildMiddleware(transport string, logger *zap.Logger) server.ToolHandlerMid
 
 // IsAuthenticated public api
 func IsAuthenticated(ctx context.Context, transport string, logger *zap.Logger) (bool, error) {
+	if _, err := authenticateTransport(ctx, transport, logger); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Authenticate is IsAuthenticated for handlers the tool middleware does not run for, such
+// as resources. It returns ctx with the API key of the request attached.
+func Authenticate(ctx context.Context, transport string, logger *zap.Logger) (context.Context, error) {
+	principal, err := authenticateTransport(ctx, transport, logger)
+	if err != nil {
+		return ctx, err
+	}
+	if principal != nil {
+		ctx = withPrincipal(ctx, principal)
+	}
+	return ctx, nil
+}
+
+// authenticateTransport authenticates ctx and returns its API key, nil on stdio and when
+// no API key is configured.
+func authenticateTransport(ctx context.Context, transport string, logger *zap.Logger) (*Principal, error) {
 	switch transport {
 	case "stdio":
-		return true, nil
+		return nil, nil
 
 	case "sse", "http":
-		authenticated, err := validateToken(ctx, logger)
+		principal, err := authenticate(ctx, logger)
 
 		if err != nil {
 			logger.Error("HTTP/SSE authentication error",
 				zap.String("context", "http"),
 				zap.Error(err),
 			)
-			return false, fmt.Errorf("authentication error: %w", err)
-		}
-
-		if !authenticated {
-			logger.Warn("HTTP/SSE unauthorized request",
-				zap.String("context", "http"),
-			)
-			return false, fmt.Errorf("unauthorized request")
+			return nil, fmt.Errorf("authentication error: %w", err)
 		}
 
-		return true, nil
+		return principal, nil
 
 	default:
 		logger.Error("Unknown transport type",
 			zap.String("context", "http"),
 			zap.String("transport", transport),
 		)
-		return false, fmt.Errorf("unknown transport type: %s", transport)
+		return nil, fmt.Errorf("unknown transport type: %s", transport)
 	}
 }
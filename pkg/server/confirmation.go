@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// confirmationPreviewFunc renders a human-readable description of what a tool call is about to do.
+type confirmationPreviewFunc func(ctx context.Context, req mcp.CallToolRequest) (string, error)
+
+// confirmationFallback decides what happens to destructive tool calls
+// when the client cannot show an elicitation prompt.
+type confirmationFallback string
+
+const (
+	confirmationFallbackAllow confirmationFallback = "allow"
+	confirmationFallbackDeny  confirmationFallback = "deny"
+)
+
+var confirmationSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"confirm": map[string]any{
+			"type":        "boolean",
+			"title":       "Confirm",
+			"description": "Approve this action",
+		},
+	},
+	"required": []string{"confirm"},
+}
+
+// readOnlyCalls tell calls of destructive tools that only read, which need no confirmation.
+var readOnlyCalls = map[string]func(req mcp.CallToolRequest) bool{
+	ToolUsergroupsMe: func(req mcp.CallToolRequest) bool { return req.GetString("action", "") == "list" },
+}
+
+func isConfirmationEnabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("SLACK_MCP_CONFIRM_DESTRUCTIVE")))
+	return v == "true" || v == "1" || v == "yes"
+}
+
+func parseConfirmationFallback(v string) (confirmationFallback, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", string(confirmationFallbackDeny):
+		return confirmationFallbackDeny, nil
+	case string(confirmationFallbackAllow):
+		return confirmationFallbackAllow, nil
+	default:
+		return "", fmt.Errorf("invalid SLACK_MCP_CONFIRM_FALLBACK value %q, expected \"allow\" or \"deny\"", v)
+	}
+}
+
+// buildConfirmationMiddleware asks the user to approve calls of tools annotated with
+// destructiveHint=true through an MCP elicitation request before running them.
+// Clients that do not support elicitation are handled according to the fallback policy.
+func buildConfirmationMiddleware(previews map[string]confirmationPreviewFunc, fallback confirmationFallback, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			srv := server.ServerFromContext(ctx)
+			if srv == nil || !isDestructiveTool(srv, req.Params.Name) {
+				return next(ctx, req)
+			}
+			if readOnly, ok := readOnlyCalls[req.Params.Name]; ok && readOnly(req) {
+				return next(ctx, req)
+			}
+
+			if !clientSupportsElicitation(ctx) {
+				if fallback == confirmationFallbackAllow {
+					logger.Warn("Client does not support elicitation, running destructive tool without confirmation",
+						zap.String("tool", req.Params.Name),
+					)
+					return next(ctx, req)
+				}
+				logger.Warn("Client does not support elicitation, refusing destructive tool",
+					zap.String("tool", req.Params.Name),
+				)
+				return nil, fmt.Errorf("%s requires human confirmation, but the client does not support elicitation. "+
+					"Set SLACK_MCP_CONFIRM_FALLBACK=allow to run destructive tools without confirmation", req.Params.Name)
+			}
+
+			message := fmt.Sprintf("Allow %s?", req.Params.Name)
+			if preview, ok := previews[req.Params.Name]; ok {
+				text, err := preview(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				message = text + "\n\n" + message
+			}
+
+			result, err := srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+				Request: mcp.Request{Method: string(mcp.MethodElicitationCreate)},
+				Params: mcp.ElicitationParams{
+					Message:         message,
+					RequestedSchema: confirmationSchema,
+				},
+			})
+			if err != nil {
+				logger.Error("Elicitation request failed", zap.String("tool", req.Params.Name), zap.Error(err))
+				return nil, fmt.Errorf("failed to request confirmation for %s: %w", req.Params.Name, err)
+			}
+
+			if !isConfirmed(result) {
+				logger.Info("Destructive tool call was not approved",
+					zap.String("tool", req.Params.Name),
+					zap.String("action", string(result.Action)),
+				)
+				return nil, errors.New("the user did not approve this action")
+			}
+
+			return next(ctx, req)
+		}
+	}
+}
+
+func isDestructiveTool(srv *server.MCPServer, name string) bool {
+	tool := srv.GetTool(name)
+	if tool == nil {
+		return false
+	}
+	// destructiveHint defaults to true in mcp-go and is only meaningful for tools that are not read-only.
+	annotations := tool.Tool.Annotations
+	if annotations.ReadOnlyHint != nil && *annotations.ReadOnlyHint {
+		return false
+	}
+	return annotations.DestructiveHint != nil && *annotations.DestructiveHint
+}
+
+func clientSupportsElicitation(ctx context.Context) bool {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return false
+	}
+	return session.GetClientCapabilities().Elicitation != nil
+}
+
+func isConfirmed(result *mcp.ElicitationResult) bool {
+	if result == nil || result.Action != mcp.ElicitationResponseActionAccept {
+		return false
+	}
+	content, ok := result.Content.(map[string]any)
+	if !ok {
+		return false
+	}
+	confirm, _ := content["confirm"].(bool)
+	return confirm
+}
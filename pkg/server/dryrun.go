@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// dryRunArgument is the argument of write tools that makes a call a dry run.
+const dryRunArgument = "dry_run"
+
+func isDryRunEnabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("SLACK_MCP_DRY_RUN")))
+	return v == "true" || v == "1" || v == "yes"
+}
+
+// withDryRunArgument adds the dry_run argument to tools that have a dry run.
+func withDryRunArgument(dryRuns map[string]server.ToolHandlerFunc) func(*mcp.Tool) {
+	return func(tool *mcp.Tool) {
+		if _, ok := dryRuns[tool.Name]; !ok {
+			return
+		}
+		mcp.WithBoolean(dryRunArgument,
+			mcp.Description("If true, validate the call and return what would have been sent to Slack without sending it. Default is boolean false."),
+		)(tool)
+	}
+}
+
+// isDryRunCall returns whether a call is answered with the dry run of its tool, by
+// SLACK_MCP_DRY_RUN or its dry_run argument.
+func isDryRunCall(dryRuns map[string]server.ToolHandlerFunc, global bool) func(mcp.CallToolRequest) bool {
+	return func(req mcp.CallToolRequest) bool {
+		_, ok := dryRuns[req.Params.Name]
+		return ok && (global || req.GetBool(dryRunArgument, false))
+	}
+}
+
+// buildDryRunMiddleware answers calls that are dry runs, by SLACK_MCP_DRY_RUN or their
+// dry_run argument, with the dry run of their tool instead of running it. With
+// SLACK_MCP_DRY_RUN, destructive tools without a dry run are refused, so nothing reaches
+// Slack. It must run after the policy middleware, so dry runs are checked like calls.
+func buildDryRunMiddleware(dryRuns map[string]server.ToolHandlerFunc, global bool, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !global && !req.GetBool(dryRunArgument, false) {
+				return next(ctx, req)
+			}
+
+			dryRun, ok := dryRuns[req.Params.Name]
+			if !ok {
+				srv := server.ServerFromContext(ctx)
+				if global && srv != nil && isDestructiveTool(srv, req.Params.Name) {
+					logger.Warn("Refusing tool without a dry run", zap.String("tool", req.Params.Name))
+					return nil, fmt.Errorf("%s does not support dry runs and SLACK_MCP_DRY_RUN is set, so it cannot be called", req.Params.Name)
+				}
+				return next(ctx, req)
+			}
+
+			logger.Debug("Dry run", zap.String("tool", req.Params.Name))
+			audit.SetDryRun(ctx)
+			return dryRun(ctx, req)
+		}
+	}
+}
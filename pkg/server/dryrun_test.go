@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitDryRunMiddleware(t *testing.T) {
+	dryRuns := map[string]server.ToolHandlerFunc{
+		"post": func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("would post"), nil
+		},
+	}
+	newServer := func(global bool) *server.MCPServer {
+		s := server.NewMCPServer("test", "0", server.WithToolHandlerMiddleware(buildDryRunMiddleware(dryRuns, global, zap.NewNop())))
+		handler := func(name string) server.ToolHandlerFunc {
+			return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ran " + name), nil
+			}
+		}
+		for _, tool := range []mcp.Tool{
+			mcp.NewTool("post", mcp.WithDestructiveHintAnnotation(true)),
+			mcp.NewTool("delete", mcp.WithDestructiveHintAnnotation(true)),
+			mcp.NewTool("read", mcp.WithReadOnlyHintAnnotation(true)),
+		} {
+			withDryRunArgument(dryRuns)(&tool)
+			s.AddTool(tool, handler(tool.Name))
+		}
+		return s
+	}
+	call := func(s *server.MCPServer, name string, args map[string]any) string {
+		msg, err := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": name, "arguments": args},
+		})
+		require.NoError(t, err)
+		switch res := s.HandleMessage(context.Background(), msg).(type) {
+		case mcp.JSONRPCResponse:
+			return res.Result.(*mcp.CallToolResult).Content[0].(mcp.TextContent).Text
+		case mcp.JSONRPCError:
+			return "error: " + res.Error.Message
+		default:
+			t.Fatalf("unexpected response %T", res)
+			return ""
+		}
+	}
+
+	s := newServer(false)
+	assert.Equal(t, "ran post", call(s, "post", nil))
+	assert.Equal(t, "would post", call(s, "post", map[string]any{"dry_run": true}))
+	assert.Contains(t, s.GetTool("post").Tool.InputSchema.Properties, "dry_run")
+	assert.NotContains(t, s.GetTool("delete").Tool.InputSchema.Properties, "dry_run")
+
+	s = newServer(true)
+	assert.Equal(t, "would post", call(s, "post", nil))
+	assert.Equal(t, "error: delete does not support dry runs and SLACK_MCP_DRY_RUN is set, so it cannot be called", call(s, "delete", nil))
+	assert.Equal(t, "ran read", call(s, "read", nil))
+}
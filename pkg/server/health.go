@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"go.uber.org/zap"
+)
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Caches []provider.CacheStatus `json:"caches"`
+}
+
+// handleHealth registers the /healthz and /readyz probes on mux. /healthz reports
+// cache state and answers 200 while the process is up, /readyz answers 503 until
+// the users and channels caches of every workspace are loaded. Both report "degraded"
+// while Slack is unreachable or a cache fails to refresh.
+func (s *MCPServer) handleHealth(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		s.writeHealth(w, "ok", http.StatusOK)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		for _, ws := range s.provider.Workspaces() {
+			if ready, _ := ws.IsReady(); !ready {
+				s.writeHealth(w, "not_ready", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		s.writeHealth(w, "ready", http.StatusOK)
+	})
+}
+
+func (s *MCPServer) writeHealth(w http.ResponseWriter, status string, code int) {
+	res := healthResponse{Status: status}
+	for _, ws := range s.provider.Workspaces() {
+		res.Caches = append(res.Caches, ws.CacheStatuses()...)
+		if err := ws.ConnectionError(); err != nil && res.Error == "" {
+			res.Error = err.Error()
+			if ws.Name() != "" {
+				res.Error = ws.Name() + ": " + res.Error
+			}
+		}
+	}
+	for _, st := range res.Caches {
+		if st.ConsecutiveFailures > 0 && res.Error == "" {
+			res.Error = st.Name + ": " + st.LastError
+			if st.Workspace != "" {
+				res.Error = st.Workspace + "/" + res.Error
+			}
+		}
+	}
+	if res.Error != "" && code == http.StatusOK {
+		res.Status = "degraded"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		s.logger.Warn("Failed to write health response", zap.Error(err))
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+const (
+	headerSlackToken  = "X-Slack-Token"
+	headerSlackCookie = "X-Slack-Cookie"
+)
+
+// newTokenPool returns the pool of caller providers with SLACK_MCP_TOKEN_PASSTHROUGH, nil
+// without it. The server's own token is still needed to start, but tool calls, resources
+// and prompts only ever use the caller's token.
+func newTokenPool(p *provider.ApiProvider, logger *zap.Logger) (*provider.Pool, error) {
+	if !provider.TokenPassthrough() {
+		return nil, nil
+	}
+	if transport := p.ServerTransport(); transport != "sse" && transport != "http" {
+		return nil, errors.New("SLACK_MCP_TOKEN_PASSTHROUGH needs the sse or http transport, stdio has a single user")
+	}
+	if len(p.Workspaces()) > 1 {
+		return nil, errors.New("SLACK_MCP_TOKEN_PASSTHROUGH and SLACK_MCP_WORKSPACES cannot be combined, the caller's token selects the workspace")
+	}
+	if p.IsOffline() {
+		return nil, errors.New("SLACK_MCP_TOKEN_PASSTHROUGH and SLACK_MCP_OFFLINE_SOURCE cannot be combined")
+	}
+	if os.Getenv("SLACK_MCP_ARCHIVE_CHANNELS") != "" {
+		logger.Warn("The local archive holds what the server's token can read, its search tools are disabled with SLACK_MCP_TOKEN_PASSTHROUGH",
+			zap.String("context", "console"),
+		)
+	}
+	return provider.NewPool(p.ServerTransport(), logger), nil
+}
+
+// credentialsFromRequest attaches the Slack token of the X-Slack-Token and X-Slack-Cookie
+// headers to ctx.
+func credentialsFromRequest(ctx context.Context, r *http.Request) context.Context {
+	token := strings.TrimSpace(r.Header.Get(headerSlackToken))
+	if token == "" {
+		return ctx
+	}
+	return provider.WithCredentials(ctx, provider.Credentials{
+		Token:  token,
+		Cookie: strings.TrimSpace(r.Header.Get(headerSlackCookie)),
+	})
+}
+
+// buildSlackCallerMiddleware names callers sending their own pass-through token by the
+// Slack team and user of it, see auth.WithSlackCaller, for the policy and the audit log.
+// That includes callers behind an API key without a slack_token, e.g. a key shared by a
+// team, which would otherwise all look the same. The caller's provider is built here on
+// first use, tool handlers reuse it.
+func buildSlackCallerMiddleware(pool *provider.Pool, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.SlackToken != "" {
+				return next(ctx, req)
+			}
+			creds, ok := provider.CredentialsFromContext(ctx)
+			if !ok {
+				return nil, provider.ErrCredentialsRequired
+			}
+			ap, err := pool.Get(ctx, creds)
+			if err != nil {
+				return nil, err
+			}
+			ar, err := ap.Slack().AuthTest()
+			if err != nil {
+				logger.Error("AuthTest failed", zap.String("tool", req.Params.Name), zap.Error(err))
+				return nil, err
+			}
+
+			ctx = auth.WithSlackCaller(ctx, ar.TeamID, ar.UserID)
+			name, _ := auth.SlackCallerFromContext(ctx)
+			audit.SetPrincipal(ctx, name)
+			return next(ctx, req)
+		}
+	}
+}
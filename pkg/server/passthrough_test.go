@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitNewTokenPool(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("SLACK_MCP_XOXP_TOKEN", "demo")
+
+	t.Setenv("SLACK_MCP_TOKEN_PASSTHROUGH", "")
+	pool, err := newTokenPool(provider.New("http", zap.NewNop()), zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, pool)
+
+	t.Setenv("SLACK_MCP_TOKEN_PASSTHROUGH", "true")
+	pool, err = newTokenPool(provider.New("http", zap.NewNop()), zap.NewNop())
+	require.NoError(t, err)
+	assert.NotNil(t, pool)
+
+	_, err = newTokenPool(provider.New("stdio", zap.NewNop()), zap.NewNop())
+	assert.ErrorContains(t, err, "sse or http")
+}
+
+func TestUnitCallerCredentials(t *testing.T) {
+	_, err := callerCredentials(context.Background())
+	assert.ErrorIs(t, err, provider.ErrCredentialsRequired)
+
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	r.Header.Set("X-Slack-Token", "xoxc-alice")
+	r.Header.Set("X-Slack-Cookie", "xoxd-alice")
+	ctx := credentialsFromRequest(context.Background(), r)
+	creds, err := callerCredentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, provider.Credentials{Token: "xoxc-alice", Cookie: "xoxd-alice"}, creds)
+
+	// The token of the caller's API key takes precedence over headers
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(`{"keys":[
+		{"name":"bob","key":"bob-secret","slack_token":"xoxp-bob"}
+	]}`), 0600))
+	t.Setenv("SLACK_MCP_API_KEY", "")
+	t.Setenv("SLACK_MCP_API_KEYS_FILE", filepath.Join(dir, "keys.json"))
+	r.Header.Set("Authorization", "Bearer bob-secret")
+	ctx, err = auth.Authenticate(auth.AuthFromRequest(zap.NewNop())(ctx, r), "http", zap.NewNop())
+	require.NoError(t, err)
+	creds, err = callerCredentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, provider.Credentials{Token: "xoxp-bob"}, creds)
+}
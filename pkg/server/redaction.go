@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// redactionsMetaKey is the _meta field of results that reports what was redacted.
+const redactionsMetaKey = "redactions"
+
+// buildRedactionMiddleware redacts the text and structured content of tool results. Results
+// with redactions end with a note of what was redacted and report it in their _meta.
+func buildRedactionMiddleware(r *text.Redactor, logger *zap.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			res, err := next(ctx, req)
+			if res == nil {
+				return res, err
+			}
+
+			counts := text.RedactCounts{}
+			for i, content := range res.Content {
+				res.Content[i] = redactContent(r, content, counts)
+			}
+			if res.StructuredContent != nil {
+				res.StructuredContent = redactStructured(r, res.StructuredContent, counts)
+			}
+			if counts.Total() == 0 {
+				return res, err
+			}
+
+			logger.Debug("Redacted tool result",
+				zap.String("tool", req.Params.Name),
+				zap.String("redactions", counts.String()),
+			)
+			res.Content = append(res.Content, mcp.NewTextContent(
+				fmt.Sprintf("Note: %d items were redacted from this result (%s).", counts.Total(), counts),
+			))
+			if res.Meta == nil {
+				res.Meta = &mcp.Meta{}
+			}
+			if res.Meta.AdditionalFields == nil {
+				res.Meta.AdditionalFields = map[string]any{}
+			}
+			res.Meta.AdditionalFields[redactionsMetaKey] = map[string]int(counts)
+			return res, err
+		}
+	}
+}
+
+// buildResourceRedactionMiddleware redacts the text of resources, reporting what was
+// redacted in the _meta of each content.
+func buildResourceRedactionMiddleware(r *text.Redactor) server.ResourceHandlerMiddleware {
+	return func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			contents, err := next(ctx, req)
+			for i, content := range contents {
+				if c, ok := content.(mcp.TextResourceContents); ok {
+					contents[i] = redactResource(r, c, nil)
+				}
+			}
+			return contents, err
+		}
+	}
+}
+
+func redactContent(r *text.Redactor, content mcp.Content, counts text.RedactCounts) mcp.Content {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		redacted, n := r.Redact(c.Text)
+		counts.Add(n)
+		c.Text = redacted
+		return c
+	case mcp.EmbeddedResource:
+		if rc, ok := c.Resource.(mcp.TextResourceContents); ok {
+			c.Resource = redactResource(r, rc, counts)
+		}
+		return c
+	}
+	return content
+}
+
+// redactResource redacts c and adds its counts to counts, or reports them in its _meta if
+// counts is nil.
+func redactResource(r *text.Redactor, c mcp.TextResourceContents, counts text.RedactCounts) mcp.TextResourceContents {
+	redacted, n := r.Redact(c.Text)
+	c.Text = redacted
+	if counts != nil {
+		counts.Add(n)
+		return c
+	}
+	if n.Total() > 0 {
+		meta := make(map[string]any, len(c.Meta)+1)
+		for k, v := range c.Meta {
+			meta[k] = v
+		}
+		meta[redactionsMetaKey] = map[string]int(n)
+		c.Meta = meta
+	}
+	return c
+}
+
+// redactStructured redacts the strings of structured content, which may be any value that
+// marshals to JSON.
+func redactStructured(r *text.Redactor, v any, counts text.RedactCounts) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v
+	}
+	n := text.RedactCounts{}
+	decoded = r.RedactValue(decoded, n)
+	if n.Total() == 0 {
+		return v
+	}
+	counts.Add(n)
+	return decoded
+}
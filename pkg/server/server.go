@@ -2,14 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/korotovsky/slack-mcp-server/pkg/audit"
 	"github.com/korotovsky/slack-mcp-server/pkg/handler"
+	"github.com/korotovsky/slack-mcp-server/pkg/policy"
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
 	"github.com/korotovsky/slack-mcp-server/pkg/text"
@@ -20,8 +24,11 @@ import (
 )
 
 type MCPServer struct {
-	server *server.MCPServer
-	logger *zap.Logger
+	server   *server.MCPServer
+	provider *provider.ApiProvider
+	oauth    *auth.OAuth
+	pool     *provider.Pool
+	logger   *zap.Logger
 }
 
 const (
@@ -38,6 +45,15 @@ const (
 	ToolUsergroupsCreate            = "usergroups_create"
 	ToolUsergroupsUpdate            = "usergroups_update"
 	ToolUsergroupsUsersUpdate       = "usergroups_users_update"
+	ToolMessagesSearchLocal         = "messages_search_local"
+	ToolMessagesSemanticSearch      = "messages_semantic_search"
+	ToolConversationsExport         = "conversations_export"
+	ToolCacheStatus                 = "cache_status"
+)
+
+const (
+	PromptChannelSummary = "channel_summary"
+	PromptUserMessages   = "user_messages"
 )
 
 var ValidToolNames = []string{
@@ -54,6 +70,10 @@ var ValidToolNames = []string{
 	ToolUsergroupsCreate,
 	ToolUsergroupsUpdate,
 	ToolUsergroupsUsersUpdate,
+	ToolMessagesSearchLocal,
+	ToolMessagesSemanticSearch,
+	ToolConversationsExport,
+	ToolCacheStatus,
 }
 
 func ValidateEnabledTools(tools []string) error {
@@ -77,6 +97,11 @@ func ValidateEnabledTools(tools []string) error {
 }
 
 func shouldAddTool(name string, enabledTools []string, envVarName string) bool {
+	// SLACK_MCP_POLICY_FILE replaces the SLACK_MCP_*_TOOL variables
+	if p, err := policy.Current(); err == nil && p.FromFile() {
+		return p.Enabled(name) && (len(enabledTools) == 0 || slices.Contains(enabledTools, name))
+	}
+
 	if envVarName == "" {
 		if len(enabledTools) == 0 {
 			return true
@@ -96,20 +121,142 @@ func shouldAddTool(name string, enabledTools []string, envVarName string) bool {
 }
 
 func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledTools []string) *MCPServer {
-	s := server.NewMCPServer(
-		"Slack MCP Server",
-		version.Version,
+	// With token pass-through every call acts as the caller, see newTokenPool
+	pool, err := newTokenPool(provider, logger)
+	if err != nil {
+		logger.Fatal("Invalid token pass-through configuration", zap.String("context", "console"), zap.Error(err))
+	}
+
+	conversations := newWorkspaceHandlers(provider, pool, logger, handler.NewConversationsHandler, nil)
+	channels := newWorkspaceHandlers(provider, pool, logger, handler.NewChannelsHandler, nil)
+	usergroups := newWorkspaceHandlers(provider, pool, logger, handler.NewUsergroupsHandler, nil)
+
+	// Prompts and argument completion serve the default workspace
+	conversationsHandler, _ := conversations.get(provider)
+	completionsHandler := handler.NewCompletionsHandler(provider, logger)
+
+	auditLog, err := audit.FromEnv()
+	if err != nil {
+		logger.Fatal("Invalid audit log configuration", zap.String("context", "console"), zap.Error(err))
+	}
+
+	serverOpts := []server.ServerOption{
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithCompletions(),
 		server.WithToolHandlerMiddleware(buildErrorRecoveryMiddleware(logger)),
-		server.WithToolHandlerMiddleware(buildLoggerMiddleware(logger)),
+	}
+	// The audit log records calls that API keys, OAuth scopes and the policy deny as well
+	if auditLog != nil {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(audit.BuildMiddleware(auditLog, provider.ServerTransport(), logger)))
+	}
+	serverOpts = append(serverOpts,
+		// Authentication runs before the logger so it can record which API key made the call
 		server.WithToolHandlerMiddleware(auth.BuildMiddleware(provider.ServerTransport(), logger)),
+		server.WithToolHandlerMiddleware(buildLoggerMiddleware(logger)),
 	)
+	if pool != nil {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(buildSlackCallerMiddleware(pool, logger)))
+	}
+	// OAuth access tokens only exist on the HTTP transports
+	var oauth *auth.OAuth
+	if transport := provider.ServerTransport(); transport == "sse" || transport == "http" {
+		var err error
+		oauth, err = auth.NewOAuthFromEnv(logger)
+		if err != nil {
+			logger.Fatal("Invalid OAuth configuration", zap.String("context", "console"), zap.Error(err))
+		}
+		if err := auth.LoadAPIKeysFromEnv(); err != nil {
+			logger.Fatal("Invalid API keys file", zap.String("context", "console"), zap.Error(err))
+		}
+		if os.Getenv("SLACK_MCP_API_KEYS_FILE") != "" {
+			serverOpts = append(serverOpts, server.WithToolFilter(auth.ToolFilter))
+		}
+	}
+	if oauth != nil {
+		serverOpts = append(serverOpts,
+			server.WithToolFilter(oauth.ToolFilter),
+			server.WithToolHandlerMiddleware(oauth.Middleware),
+		)
+	}
 
-	conversationsHandler := handler.NewConversationsHandler(provider, logger)
+	// Dry runs return what write tools would send to Slack, see the dry-run middleware below
+	dryRuns := map[string]server.ToolHandlerFunc{
+		ToolConversationsAddMessage: conversations.tool((*handler.ConversationsHandler).AddMessageDryRun),
+		ToolReactionsAdd:            conversations.tool((*handler.ConversationsHandler).ReactionDryRun),
+		ToolReactionsRemove:         conversations.tool((*handler.ConversationsHandler).ReactionDryRun),
+		ToolUsergroupsCreate:        usergroups.tool((*handler.UsergroupsHandler).UsergroupsCreateDryRun),
+		ToolUsergroupsUpdate:        usergroups.tool((*handler.UsergroupsHandler).UsergroupsUpdateDryRun),
+		ToolUsergroupsUsersUpdate:   usergroups.tool((*handler.UsergroupsHandler).UsergroupsUsersUpdateDryRun),
+		ToolUsergroupsMe:            usergroups.tool((*handler.UsergroupsHandler).UsergroupsMeDryRun),
+	}
+	dryRunGlobal := isDryRunEnabled()
+	// Calls OAuth scopes reject and dry runs do not count towards the rate of the policy
+	serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(policy.BuildMiddleware(isDryRunCall(dryRuns, dryRunGlobal), logger)))
+
+	redactor, err := text.RedactorFromEnv()
+	if err != nil {
+		logger.Fatal("Invalid redaction configuration", zap.String("context", "console"), zap.Error(err))
+	}
+	if redactor != nil {
+		serverOpts = append(serverOpts,
+			server.WithToolHandlerMiddleware(buildRedactionMiddleware(redactor, logger)),
+			server.WithResourceHandlerMiddleware(buildResourceRedactionMiddleware(redactor)),
+		)
+	}
+
+	// Completions come from the caches of the server's token, which callers must not see
+	if pool == nil {
+		serverOpts = append(serverOpts,
+			server.WithPromptCompletionProvider(completionsHandler),
+			server.WithResourceCompletionProvider(completionsHandler),
+		)
+	}
+
+	// Dry runs are answered without a confirmation prompt
+	serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(buildDryRunMiddleware(dryRuns, dryRunGlobal, logger)))
+
+	if isConfirmationEnabled() {
+		fallback, err := parseConfirmationFallback(os.Getenv("SLACK_MCP_CONFIRM_FALLBACK"))
+		if err != nil {
+			logger.Fatal("Invalid confirmation fallback policy", zap.String("context", "console"), zap.Error(err))
+		}
+		previews := map[string]confirmationPreviewFunc{
+			ToolConversationsAddMessage: conversations.preview((*handler.ConversationsHandler).AddMessagePreview),
+			ToolReactionsAdd:            conversations.preview((*handler.ConversationsHandler).ReactionPreview),
+			ToolReactionsRemove:         conversations.preview((*handler.ConversationsHandler).ReactionPreview),
+			ToolUsergroupsCreate:        usergroups.preview((*handler.UsergroupsHandler).UsergroupsCreatePreview),
+			ToolUsergroupsUpdate:        usergroups.preview((*handler.UsergroupsHandler).UsergroupsUpdatePreview),
+			ToolUsergroupsUsersUpdate:   usergroups.preview((*handler.UsergroupsHandler).UsergroupsUsersUpdatePreview),
+			ToolUsergroupsMe:            usergroups.preview((*handler.UsergroupsHandler).UsergroupsMePreview),
+		}
+		serverOpts = append(serverOpts,
+			server.WithElicitation(),
+			server.WithToolHandlerMiddleware(buildConfirmationMiddleware(previews, fallback, logger)),
+		)
+	}
+
+	s := server.NewMCPServer(
+		"Slack MCP Server",
+		version.Version,
+		serverOpts...,
+	)
+
+	// With several workspaces every tool takes an optional workspace argument
+	workspaceArg := workspaceArgument(provider)
+	dryRunArg := withDryRunArgument(dryRuns)
+	addTool := func(tool mcp.Tool, h server.ToolHandlerFunc) {
+		workspaceArg(&tool)
+		dryRunArg(&tool)
+		if oauth != nil {
+			readOnly := tool.Annotations.ReadOnlyHint
+			oauth.AddTool(tool.Name, readOnly != nil && *readOnly)
+		}
+		s.AddTool(tool, h)
+	}
 
 	if shouldAddTool(ToolConversationsHistory, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolConversationsHistory,
+		addTool(mcp.NewTool(ToolConversationsHistory,
 		mcp.WithDescription("Get messages from the channel (or DM) by channel_id, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
 		mcp.WithTitleAnnotation("Get Conversation History"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -128,11 +275,30 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.DefaultString("1d"),
 			mcp.Description("Limit of messages to fetch in format of maximum ranges of time (e.g. 1d - 1 day, 1w - 1 week, 30d - 30 days, 90d - 90 days which is a default limit for free tier history) or number of messages (e.g. 50). Must be empty when 'cursor' is provided."),
 		),
-	), conversationsHandler.ConversationsHistoryHandler)
+		mcp.WithBoolean("auto_paginate",
+			mcp.Description("If true, keep fetching pages until the requested time range is covered, or until max_messages or max_tokens is reached, and return one merged result with a single cursor in the last row. Default is boolean false."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("max_messages",
+			mcp.Description("Maximum number of messages to collect when auto_paginate is true. Defaults to a numeric 'limit' if given, otherwise 1000."),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate maximum number of tokens to collect when auto_paginate is true. The last page is never truncated, so the result may exceed it by up to one page."),
+		),
+		mcp.WithString("expand_threads",
+			mcp.DefaultString("none"),
+			mcp.Enum("none", "summary", "all"),
+			mcp.Description("Inline threads after their parent message: 'none' keeps parents only, 'summary' adds one row per thread with reply count, participants and last reply time, 'all' adds the replies themselves. Inlined rows have depth=1. Default is 'none'."),
+		),
+		mcp.WithNumber("max_thread_replies",
+			mcp.DefaultNumber(20),
+			mcp.Description("Maximum number of replies to inline per thread when expand_threads is 'all'. Default is 20."),
+		),
+	), conversations.tool((*handler.ConversationsHandler).ConversationsHistoryHandler))
 	}
 
 	if shouldAddTool(ToolConversationsReplies, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolConversationsReplies,
+		addTool(mcp.NewTool(ToolConversationsReplies,
 		mcp.WithDescription("Get a thread of messages posted to a conversation by channelID and thread_ts, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
 		mcp.WithTitleAnnotation("Get Thread Replies"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -155,11 +321,21 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.DefaultString("1d"),
 			mcp.Description("Limit of messages to fetch in format of maximum ranges of time (e.g. 1d - 1 day, 30d - 30 days, 90d - 90 days which is a default limit for free tier history) or number of messages (e.g. 50). Must be empty when 'cursor' is provided."),
 		),
-	), conversationsHandler.ConversationsRepliesHandler)
+		mcp.WithBoolean("auto_paginate",
+			mcp.Description("If true, keep fetching pages until the requested time range is covered, or until max_messages or max_tokens is reached, and return one merged result with a single cursor in the last row. Default is boolean false."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("max_messages",
+			mcp.Description("Maximum number of messages to collect when auto_paginate is true. Defaults to a numeric 'limit' if given, otherwise 1000."),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate maximum number of tokens to collect when auto_paginate is true. The last page is never truncated, so the result may exceed it by up to one page."),
+		),
+	), conversations.tool((*handler.ConversationsHandler).ConversationsRepliesHandler))
 	}
 
 	if shouldAddTool(ToolConversationsAddMessage, enabledTools, "SLACK_MCP_ADD_MESSAGE_TOOL") {
-		s.AddTool(mcp.NewTool(ToolConversationsAddMessage,
+		addTool(mcp.NewTool(ToolConversationsAddMessage,
 		mcp.WithDescription("Add a message to a public channel, private channel, or direct message (DM, or IM) conversation by channel_id and thread_ts."),
 		mcp.WithTitleAnnotation("Send Message"),
 		mcp.WithDestructiveHintAnnotation(true),
@@ -177,11 +353,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.DefaultString("text/markdown"),
 			mcp.Description("Content type of the message. Default is 'text/markdown'. Allowed values: 'text/markdown', 'text/plain'."),
 		),
-	), conversationsHandler.ConversationsAddMessageHandler)
+	), conversations.tool((*handler.ConversationsHandler).ConversationsAddMessageHandler))
 	}
 
 	if shouldAddTool(ToolReactionsAdd, enabledTools, "SLACK_MCP_REACTION_TOOL") {
-		s.AddTool(mcp.NewTool(ToolReactionsAdd,
+		addTool(mcp.NewTool(ToolReactionsAdd,
 		mcp.WithDescription("Add an emoji reaction to a message in a public channel, private channel, or direct message (DM, or IM) conversation."),
 		mcp.WithDestructiveHintAnnotation(true),
 		mcp.WithString("channel_id",
@@ -196,11 +372,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.Required(),
 			mcp.Description("The name of the emoji to add as a reaction (without colons). Example: 'thumbsup', 'heart', 'rocket'."),
 		),
-	), conversationsHandler.ReactionsAddHandler)
+	), conversations.tool((*handler.ConversationsHandler).ReactionsAddHandler))
 	}
 
 	if shouldAddTool(ToolReactionsRemove, enabledTools, "SLACK_MCP_REACTION_TOOL") {
-		s.AddTool(mcp.NewTool(ToolReactionsRemove,
+		addTool(mcp.NewTool(ToolReactionsRemove,
 		mcp.WithDescription("Remove an emoji reaction from a message in a public channel, private channel, or direct message (DM, or IM) conversation."),
 		mcp.WithDestructiveHintAnnotation(true),
 		mcp.WithString("channel_id",
@@ -215,11 +391,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.Required(),
 			mcp.Description("The name of the emoji to remove as a reaction (without colons). Example: 'thumbsup', 'heart', 'rocket'."),
 		),
-	), conversationsHandler.ReactionsRemoveHandler)
+	), conversations.tool((*handler.ConversationsHandler).ReactionsRemoveHandler))
 	}
 
 	if shouldAddTool(ToolAttachmentGetData, enabledTools, "SLACK_MCP_ATTACHMENT_TOOL") {
-		s.AddTool(mcp.NewTool(ToolAttachmentGetData,
+		addTool(mcp.NewTool(ToolAttachmentGetData,
 		mcp.WithDescription("Download an attachment's content by file ID. Returns file metadata and content (text files as-is, binary files as base64). Maximum file size is 5MB."),
 		mcp.WithTitleAnnotation("Get Attachment Data"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -227,7 +403,7 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.Required(),
 			mcp.Description("The ID of the attachment to download, in format Fxxxxxxxxxx. Attachment IDs can be found in message metadata when HasMedia is true or AttachmentCount > 0."),
 		),
-	), conversationsHandler.FilesGetHandler)
+	), conversations.tool((*handler.ConversationsHandler).FilesGetHandler))
 	}
 
 	conversationsSearchTool := mcp.NewTool(ToolConversationsSearchMessages,
@@ -270,15 +446,119 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 		),
 		mcp.WithNumber("limit",
 			mcp.DefaultNumber(20),
-			mcp.Description("The maximum number of items to return. Must be an integer between 1 and 100."),
+			mcp.Description("The maximum number of items to return per page. Must be an integer between 1 and 100."),
+		),
+		mcp.WithBoolean("auto_paginate",
+			mcp.Description("If true, keep fetching pages until all results are fetched, or until max_messages or max_tokens is reached, and return one merged result with a single cursor in the last row. Default is boolean false."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("max_messages",
+			mcp.Description("Maximum number of messages to collect when auto_paginate is true. Default is 1000."),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate maximum number of tokens to collect when auto_paginate is true. The last page is never truncated, so the result may exceed it by up to one page."),
 		),
 	)
-	// Only register search tool for non-bot tokens (bot tokens cannot use search.messages API)
-	if !provider.IsBotToken() && shouldAddTool(ToolConversationsSearchMessages, enabledTools, "") {
-		s.AddTool(conversationsSearchTool, conversationsHandler.ConversationsSearchHandler)
+	// Only register search tool for workspaces with non-bot tokens (bot tokens cannot use
+	// search.messages API), offline sources are searched with messages_search_local instead
+	searchable := conversations.only(searchSupported)
+	if pool != nil {
+		// Callers always have user or session tokens, which can search
+		searchable = conversations
+	}
+	if !searchable.empty() && shouldAddTool(ToolConversationsSearchMessages, enabledTools, "") {
+		addTool(conversationsSearchTool, searchable.tool((*handler.ConversationsHandler).ConversationsSearchHandler))
+	}
+
+	// Local search works with any token type, it only needs the local archive to be enabled.
+	// The archive holds what the server's token reads, so it is not served to callers.
+	if pool == nil && slices.ContainsFunc(provider.Workspaces(), archiveEnabled) && shouldAddTool(ToolMessagesSearchLocal, enabledTools, "") {
+		localSearch := newWorkspaceHandlers(provider, nil, logger, handler.NewLocalSearchHandler, archiveEnabled)
+		addTool(mcp.NewTool(ToolMessagesSearchLocal,
+			mcp.WithDescription("Full-text search over messages of the local archive (see SLACK_MCP_ARCHIVE_CHANNELS), ranked by relevance. Works with bot tokens and without network access. Supports the same inline filters as Slack search: in:, from:, with:, before:, after:, on:, during: and is:thread."),
+			mcp.WithTitleAnnotation("Search Local Archive"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("search_query",
+				mcp.Required(),
+				mcp.Description("Search terms with optional inline filters. Example: 'deploy rollback in:#ops from:@alice after:2025-01-01'. All terms must match."),
+			),
+			mcp.WithNumber("limit",
+				mcp.DefaultNumber(20),
+				mcp.Description("The maximum number of items to return. Must be an integer between 1 and 100."),
+			),
+			mcp.WithString("cursor",
+				mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+			),
+		), localSearch.tool((*handler.LocalSearchHandler).MessagesSearchLocalHandler))
+	}
+
+	// Semantic search additionally needs an embeddings endpoint, see SLACK_MCP_EMBEDDINGS_URL.
+	if pool == nil && slices.ContainsFunc(provider.Workspaces(), archiveEnabled) && shouldAddTool(ToolMessagesSemanticSearch, enabledTools, "") {
+		semanticSearch := newWorkspaceHandlers(provider, nil, logger, handler.NewSemanticSearchHandler, archiveEnabled)
+		semanticSearch.retain((*handler.SemanticSearchHandler).Enabled)
+		if !semanticSearch.empty() {
+			addTool(mcp.NewTool(ToolMessagesSemanticSearch,
+				mcp.WithDescription("Find messages and threads of the local archive (see SLACK_MCP_ARCHIVE_CHANNELS) by meaning rather than exact keywords, e.g. 'deploy broke' also finds 'release failure'. Returns the nearest messages with their permalinks, channel names and similarity scores."),
+				mcp.WithTitleAnnotation("Semantic Search"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Natural language description of what to look for."),
+				),
+				mcp.WithString("channel_id",
+					mcp.Description("Optional channel to search in, by ID or name, e.g. 'C1234567890', '#general' or '@username_dm'."),
+				),
+				mcp.WithNumber("limit",
+					mcp.DefaultNumber(10),
+					mcp.Description("The maximum number of items to return. Must be an integer between 1 and 50."),
+				),
+			), semanticSearch.tool((*handler.SemanticSearchHandler).MessagesSemanticSearchHandler))
+		}
 	}
 
-	s.AddTool(mcp.NewTool("users_search",
+	// Exports are files shared by all callers of the server, so not offered with token pass-through
+	if pool == nil && shouldAddTool(ToolConversationsExport, enabledTools, "") {
+		addTool(mcp.NewTool(ToolConversationsExport,
+			mcp.WithDescription("Export a channel, or a single thread, over a date range to a JSONL, Markdown or HTML file on the server. Pages through the whole range within the rate limits and returns a link to the written file, which can be read as a resource."),
+			mcp.WithTitleAnnotation("Export Conversation"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("channel_id",
+				mcp.Required(),
+				mcp.Description("ID of the channel in format Cxxxxxxxxxx or its name starting with #... or @... aka #general or @username_dm."),
+			),
+			mcp.WithString("thread_ts",
+				mcp.Description("Optional timestamp of a thread's parent message in format 1234567890.123456 to export only that thread."),
+			),
+			mcp.WithString("since",
+				mcp.Description("Optional first day to export, inclusive. Accepts YYYY-MM-DD, MM/DD/YYYY, 'July 2025', 'Yesterday' or 'Today'."),
+			),
+			mcp.WithString("until",
+				mcp.Description("Optional last day to export, inclusive. Same formats as 'since'."),
+			),
+			mcp.WithString("format",
+				mcp.DefaultString(handler.ExportFormatMarkdown),
+				mcp.Enum(handler.ExportFormatJSONL, handler.ExportFormatMarkdown, handler.ExportFormatHTML),
+				mcp.Description("Output format: 'jsonl' (one raw Slack message per line), 'markdown' (readable transcript) or 'html' (self-contained page)."),
+			),
+			mcp.WithBoolean("include_threads",
+				mcp.Description("If true, replies are exported under their parent message. Ignored when thread_ts is set. Default is boolean true."),
+				mcp.DefaultBool(true),
+			),
+			mcp.WithNumber("max_messages",
+				mcp.DefaultNumber(10000),
+				mcp.Description("Maximum number of messages, including replies, to export."),
+			),
+		), conversations.tool((*handler.ConversationsHandler).ConversationsExportHandler))
+
+		s.AddResourceTemplate(mcp.NewResourceTemplate(
+			"file://"+filepath.ToSlash(handler.ExportDir())+"/{name}",
+			"Conversation export",
+			mcp.WithTemplateDescription("Files written by the conversations_export tool."),
+		), conversationsHandler.ExportResource)
+	}
+
+	addTool(mcp.NewTool("users_search",
 		mcp.WithDescription("Search for users by name, email, or display name. Returns user details and DM channel ID if available."),
 		mcp.WithTitleAnnotation("Search Users"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -290,13 +570,19 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.DefaultNumber(10),
 			mcp.Description("Maximum number of results to return (1-100). Default is 10."),
 		),
-	), conversationsHandler.UsersSearchHandler)
+	), conversations.tool((*handler.ConversationsHandler).UsersSearchHandler))
 
-	channelsHandler := handler.NewChannelsHandler(provider, logger)
-	usergroupsHandler := handler.NewUsergroupsHandler(provider, logger)
+	if shouldAddTool(ToolCacheStatus, enabledTools, "") {
+		cacheStatus := newWorkspaceHandlers(provider, pool, logger, handler.NewCacheStatusHandler, nil)
+		addTool(mcp.NewTool(ToolCacheStatus,
+			mcp.WithDescription("Report the state of the users, channels, user groups and emoji caches: whether they are loaded, how many entries they hold, when they were last refreshed successfully or failed, and when the next background refresh is due."),
+			mcp.WithTitleAnnotation("Cache Status"),
+			mcp.WithReadOnlyHintAnnotation(true),
+		), cacheStatus.tool((*handler.CacheStatusHandler).CacheStatusHandler))
+	}
 
 	if shouldAddTool(ToolChannelsList, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolChannelsList,
+		addTool(mcp.NewTool(ToolChannelsList,
 		mcp.WithDescription("Get list of channels"),
 		mcp.WithTitleAnnotation("List Channels"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -314,12 +600,12 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 		mcp.WithString("cursor",
 			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
 		),
-	), channelsHandler.ChannelsHandler)
+	), channels.tool((*handler.ChannelsHandler).ChannelsHandler))
 	}
 
 	// User groups tools
 	if shouldAddTool(ToolUsergroupsList, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolUsergroupsList,
+		addTool(mcp.NewTool(ToolUsergroupsList,
 			mcp.WithDescription("List all user groups (subteams) in the Slack workspace. User groups are mention groups like @engineering or @design that notify all members. Use this to discover available groups, check group membership counts, or find a group's ID before joining/updating it. Returns CSV with columns: id, name, handle, description, user_count, is_external."),
 			mcp.WithTitleAnnotation("List User Groups"),
 			mcp.WithReadOnlyHintAnnotation(true),
@@ -335,13 +621,15 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 				mcp.Description("Include disabled/archived groups. Default is false."),
 				mcp.DefaultBool(false),
 			),
-		), usergroupsHandler.UsergroupsListHandler)
+		), usergroups.tool((*handler.UsergroupsHandler).UsergroupsListHandler))
 	}
 
 	if shouldAddTool(ToolUsergroupsMe, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolUsergroupsMe,
+		addTool(mcp.NewTool(ToolUsergroupsMe,
 			mcp.WithDescription("Manage your own user group membership. Use action='list' to see which groups you belong to. Use action='join' with a usergroup_id to add yourself to a group (e.g., to receive @mentions). Use action='leave' with a usergroup_id to remove yourself. This is the easiest way to join/leave groups without needing to know the full member list."),
 			mcp.WithTitleAnnotation("My User Groups"),
+			// Joining and leaving change the group, listing needs no confirmation, see readOnlyCalls
+			mcp.WithDestructiveHintAnnotation(true),
 			mcp.WithString("action",
 				mcp.Required(),
 				mcp.Description("Action to perform: 'list' returns CSV of groups you're a member of, 'join' adds you to a group, 'leave' removes you from a group."),
@@ -349,11 +637,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.WithString("usergroup_id",
 				mcp.Description("ID of the user group (starts with 'S', e.g., 'S0123456789'). Required for 'join' and 'leave' actions. Get IDs from usergroups_list."),
 			),
-		), usergroupsHandler.UsergroupsMeHandler)
+		), usergroups.tool((*handler.UsergroupsHandler).UsergroupsMeHandler))
 	}
 
 	if shouldAddTool(ToolUsergroupsCreate, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolUsergroupsCreate,
+		addTool(mcp.NewTool(ToolUsergroupsCreate,
 			mcp.WithDescription("Create a new user group (mention group) in the Slack workspace. After creation, use usergroups_users_update to add members, or users can join themselves with usergroups_me. The handle becomes the @mention (e.g., handle='engineering' creates @engineering)."),
 			mcp.WithTitleAnnotation("Create User Group"),
 			mcp.WithDestructiveHintAnnotation(true),
@@ -370,11 +658,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.WithString("channels",
 				mcp.Description("Comma-separated channel IDs where this group is commonly mentioned. Members get suggestions to join these channels."),
 			),
-		), usergroupsHandler.UsergroupsCreateHandler)
+		), usergroups.tool((*handler.UsergroupsHandler).UsergroupsCreateHandler))
 	}
 
 	if shouldAddTool(ToolUsergroupsUpdate, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolUsergroupsUpdate,
+		addTool(mcp.NewTool(ToolUsergroupsUpdate,
 			mcp.WithDescription("Update a user group's metadata: name, handle (@mention), description, or default channels. Does NOT change members - use usergroups_users_update for that. At least one field must be provided."),
 			mcp.WithTitleAnnotation("Update User Group"),
 			mcp.WithDestructiveHintAnnotation(true),
@@ -394,11 +682,11 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 			mcp.WithString("channels",
 				mcp.Description("New default channel IDs (comma-separated). Replaces existing default channels."),
 			),
-		), usergroupsHandler.UsergroupsUpdateHandler)
+		), usergroups.tool((*handler.UsergroupsHandler).UsergroupsUpdateHandler))
 	}
 
 	if shouldAddTool(ToolUsergroupsUsersUpdate, enabledTools, "") {
-		s.AddTool(mcp.NewTool(ToolUsergroupsUsersUpdate,
+		addTool(mcp.NewTool(ToolUsergroupsUsersUpdate,
 			mcp.WithDescription("Replace all members of a user group with a new list. WARNING: This completely replaces the member list - any user not in the 'users' parameter will be removed. To add/remove just yourself, use usergroups_me instead. To add a single user without removing others, first get current members from usergroups_list with include_users=true, then call this with the combined list."),
 			mcp.WithTitleAnnotation("Update User Group Members"),
 			mcp.WithDestructiveHintAnnotation(true),
@@ -410,54 +698,54 @@ func NewMCPServer(provider *provider.ApiProvider, logger *zap.Logger, enabledToo
 				mcp.Required(),
 				mcp.Description("Comma-separated user IDs that will become the COMPLETE member list (e.g., 'U0123456789,U9876543210'). All current members not in this list will be removed."),
 			),
-		), usergroupsHandler.UsergroupsUsersUpdateHandler)
+		), usergroups.tool((*handler.UsergroupsHandler).UsergroupsUsersUpdateHandler))
 	}
 
-	logger.Info("Authenticating with Slack API...",
-		zap.String("context", "console"),
-	)
-	ar, err := provider.Slack().AuthTest()
-	if err != nil {
-		logger.Fatal("Failed to authenticate with Slack",
-			zap.String("context", "console"),
-			zap.Error(err),
-		)
-	}
+	s.AddPrompt(mcp.NewPrompt(PromptChannelSummary,
+		mcp.WithPromptDescription("Summarize recent discussion in a channel, including threads."),
+		mcp.WithArgument("channel_id",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("ID of the channel in format Cxxxxxxxxxx or its name starting with #... or @... aka #general or @username_dm."),
+		),
+		mcp.WithArgument("limit",
+			mcp.ArgumentDescription("Time range to summarize, e.g. 1d, 1w or 30d. Default is 1d."),
+		),
+	), conversations.prompt((*handler.ConversationsHandler).ChannelSummaryPrompt))
 
-	logger.Info("Successfully authenticated with Slack",
-		zap.String("context", "console"),
-		zap.String("team", ar.Team),
-		zap.String("user", ar.User),
-		zap.String("enterprise", ar.EnterpriseID),
-		zap.String("url", ar.URL),
-	)
+	s.AddPrompt(mcp.NewPrompt(PromptUserMessages,
+		mcp.WithPromptDescription("Find and summarize recent messages from a user, optionally in a single channel."),
+		mcp.WithArgument("filter_users_from",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("User ID or display name. Example: 'U1234567890' or '@username'."),
+		),
+		mcp.WithArgument("filter_in_channel",
+			mcp.ArgumentDescription("Channel ID or name. Example: 'C1234567890' or '#general'."),
+		),
+	), conversations.prompt((*handler.ConversationsHandler).UserMessagesPrompt))
 
-	ws, err := text.Workspace(ar.URL)
-	if err != nil {
-		logger.Fatal("Failed to parse workspace from URL",
-			zap.String("context", "console"),
-			zap.String("url", ar.URL),
-			zap.Error(err),
-		)
+	for _, ws := range provider.Workspaces() {
+		if err := addWorkspaceResources(s, ws, channels, conversations, logger); err != nil {
+			logger.Warn("Workspace is not known yet, directory resources are added once Slack can be reached",
+				zap.String("context", "console"),
+				zap.Error(err),
+			)
+			ws.WhenConnected(func() {
+				if err := addWorkspaceResources(s, ws, channels, conversations, logger); err != nil {
+					logger.Error("Failed to add directory resources",
+						zap.String("context", "console"),
+						zap.Error(err),
+					)
+				}
+			})
+		}
 	}
 
-	s.AddResource(mcp.NewResource(
-		"slack://"+ws+"/channels",
-		"Directory of Slack channels",
-		mcp.WithResourceDescription("This resource provides a directory of Slack channels."),
-		mcp.WithMIMEType("text/csv"),
-	), channelsHandler.ChannelsResource)
-
-	s.AddResource(mcp.NewResource(
-		"slack://"+ws+"/users",
-		"Directory of Slack users",
-		mcp.WithResourceDescription("This resource provides a directory of Slack users."),
-		mcp.WithMIMEType("text/csv"),
-	), conversationsHandler.UsersResource)
-
 	return &MCPServer{
-		server: s,
-		logger: logger,
+		server:   s,
+		provider: provider,
+		oauth:    oauth,
+		pool:     pool,
+		logger:   logger,
 	}
 }
 
@@ -469,14 +757,24 @@ func (s *MCPServer) ServeSSE(addr string) *server.SSEServer {
 		zap.String("commit_hash", version.CommitHash),
 		zap.String("address", addr),
 	)
-	return server.NewSSEServer(s.server,
+	mux := http.NewServeMux()
+	sseServer := server.NewSSEServer(s.server,
+		server.WithHTTPServer(&http.Server{Handler: mux}),
 		server.WithBaseURL(fmt.Sprintf("http://%s", addr)),
 		server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
 			ctx = auth.AuthFromRequest(s.logger)(ctx, r)
+			if s.pool != nil {
+				ctx = credentialsFromRequest(ctx, r)
+			}
 
 			return ctx
 		}),
 	)
+	s.handleMCP(mux, "/", sseServer)
+	s.handleHealth(mux)
+	s.runTokenPool()
+
+	return sseServer
 }
 
 func (s *MCPServer) ServeHTTP(addr string) *server.StreamableHTTPServer {
@@ -487,14 +785,42 @@ func (s *MCPServer) ServeHTTP(addr string) *server.StreamableHTTPServer {
 		zap.String("commit_hash", version.CommitHash),
 		zap.String("address", addr),
 	)
-	return server.NewStreamableHTTPServer(s.server,
+	mux := http.NewServeMux()
+	httpServer := server.NewStreamableHTTPServer(s.server,
+		server.WithStreamableHTTPServer(&http.Server{Handler: mux}),
 		server.WithEndpointPath("/mcp"),
 		server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
 			ctx = auth.AuthFromRequest(s.logger)(ctx, r)
+			if s.pool != nil {
+				ctx = credentialsFromRequest(ctx, r)
+			}
 
 			return ctx
 		}),
 	)
+	s.handleMCP(mux, "/mcp", httpServer)
+	s.handleHealth(mux)
+	s.runTokenPool()
+
+	return httpServer
+}
+
+// handleMCP serves the MCP endpoint h on pattern. With OAuth configured requests need an
+// access token, and the metadata clients discover the authorization server with is published.
+func (s *MCPServer) handleMCP(mux *http.ServeMux, pattern string, h http.Handler) {
+	if s.oauth == nil {
+		mux.Handle(pattern, h)
+		return
+	}
+	mux.Handle(pattern, s.oauth.Protect(h))
+	s.oauth.HandleMetadata(mux)
+}
+
+// runTokenPool evicts idle callers of the token pool in the background.
+func (s *MCPServer) runTokenPool() {
+	if s.pool != nil {
+		go s.pool.Run(context.Background())
+	}
 }
 
 func (s *MCPServer) ServeStdio() error {
@@ -522,18 +848,44 @@ func buildErrorRecoveryMiddleware(logger *zap.Logger) server.ToolHandlerMiddlewa
 					zap.String("tool", req.Params.Name),
 					zap.Error(err),
 				)
-				return mcp.NewToolResultError(err.Error()), nil
+				return toolErrorResult(err), nil
 			}
 			return res, nil
 		}
 	}
 }
 
+// unavailableError is the structured content of tool errors caused by the server not being
+// able to serve the request yet, so clients can tell them from errors of the request itself.
+type unavailableError struct {
+	// "not_ready" while the caches are loading, "degraded" while Slack is unreachable
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+}
+
+func toolErrorResult(err error) *mcp.CallToolResult {
+	res := mcp.NewToolResultError(err.Error())
+	switch {
+	case errors.Is(err, provider.ErrUsersNotReady), errors.Is(err, provider.ErrChannelsNotReady):
+		res.StructuredContent = unavailableError{Status: "not_ready", Error: err.Error(), Retryable: true}
+	case errors.Is(err, provider.ErrSlackUnavailable):
+		res.StructuredContent = unavailableError{Status: "degraded", Error: err.Error(), Retryable: true}
+	}
+	return res
+}
+
 func buildLoggerMiddleware(logger *zap.Logger) server.ToolHandlerMiddleware {
 	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
 		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var key zap.Field = zap.Skip()
+			if principal, ok := auth.PrincipalFromContext(ctx); ok {
+				key = zap.String("key", principal.Name)
+			}
+
 			logger.Info("Request received",
 				zap.String("tool", req.Params.Name),
+				key,
 				zap.Any("params", req.Params),
 			)
 
@@ -545,6 +897,7 @@ func buildLoggerMiddleware(logger *zap.Logger) server.ToolHandlerMiddleware {
 
 			logger.Info("Request finished",
 				zap.String("tool", req.Params.Name),
+				key,
 				zap.Duration("duration", duration),
 			)
 
@@ -3,11 +3,13 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"testing"
 
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -106,6 +108,10 @@ func TestValidToolNames(t *testing.T) {
 			ToolUsergroupsCreate:            true,
 			ToolUsergroupsUpdate:            true,
 			ToolUsergroupsUsersUpdate:       true,
+			ToolMessagesSearchLocal:         true,
+			ToolMessagesSemanticSearch:      true,
+			ToolConversationsExport:         true,
+			ToolCacheStatus:                 true,
 		}
 
 		assert.Equal(t, len(expectedTools), len(ValidToolNames), "ValidToolNames should have %d tools", len(expectedTools))
@@ -129,6 +135,10 @@ func TestValidToolNames(t *testing.T) {
 		assert.Equal(t, "usergroups_create", ToolUsergroupsCreate)
 		assert.Equal(t, "usergroups_update", ToolUsergroupsUpdate)
 		assert.Equal(t, "usergroups_users_update", ToolUsergroupsUsersUpdate)
+		assert.Equal(t, "messages_search_local", ToolMessagesSearchLocal)
+		assert.Equal(t, "messages_semantic_search", ToolMessagesSemanticSearch)
+		assert.Equal(t, "conversations_export", ToolConversationsExport)
+		assert.Equal(t, "cache_status", ToolCacheStatus)
 	})
 }
 
@@ -462,3 +472,106 @@ func TestShouldAddTool_Matrix(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitParseConfirmationFallback(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    confirmationFallback
+		wantErr bool
+	}{
+		{in: "", want: confirmationFallbackDeny},
+		{in: "deny", want: confirmationFallbackDeny},
+		{in: "ALLOW", want: confirmationFallbackAllow},
+		{in: "maybe", wantErr: true},
+	} {
+		got, err := parseConfirmationFallback(tc.in)
+		if tc.wantErr {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestUnitConfirmationMiddleware(t *testing.T) {
+	logger := zap.NewNop()
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	}
+
+	callTool := func(c *client.Client) *mcp.CallToolResult {
+		var callReq mcp.CallToolRequest
+		callReq.Params.Name = "test_tool"
+		result, err := c.CallTool(context.Background(), callReq)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		return result
+	}
+
+	t.Run("client without elicitation is denied by default", func(t *testing.T) {
+		c := setupMCPClientServer(t, []server.ServerOption{
+			server.WithToolHandlerMiddleware(buildErrorRecoveryMiddleware(logger)),
+			server.WithToolHandlerMiddleware(buildConfirmationMiddleware(nil, confirmationFallbackDeny, logger)),
+		}, handler)
+
+		result := callTool(c)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "requires human confirmation")
+	})
+
+	t.Run("client without elicitation is allowed by fallback policy", func(t *testing.T) {
+		c := setupMCPClientServer(t, []server.ServerOption{
+			server.WithToolHandlerMiddleware(buildErrorRecoveryMiddleware(logger)),
+			server.WithToolHandlerMiddleware(buildConfirmationMiddleware(nil, confirmationFallbackAllow, logger)),
+		}, handler)
+
+		result := callTool(c)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "done", result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("listing own user groups needs no confirmation", func(t *testing.T) {
+		s := server.NewMCPServer("test", "1.0.0",
+			server.WithToolHandlerMiddleware(buildConfirmationMiddleware(nil, confirmationFallbackDeny, logger)),
+		)
+		s.AddTool(mcp.NewTool(ToolUsergroupsMe, mcp.WithDestructiveHintAnnotation(true)), handler)
+		call := func(action string) mcp.JSONRPCMessage {
+			msg, err := json.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"method":  "tools/call",
+				"params":  map[string]any{"name": ToolUsergroupsMe, "arguments": map[string]any{"action": action}},
+			})
+			require.NoError(t, err)
+			return s.HandleMessage(context.Background(), msg)
+		}
+
+		assert.IsType(t, mcp.JSONRPCResponse{}, call("list"))
+		res, ok := call("join").(mcp.JSONRPCError)
+		require.True(t, ok)
+		assert.Contains(t, res.Error.Message, "requires human confirmation")
+	})
+}
+
+func TestUnitToolErrorResult(t *testing.T) {
+	t.Run("not ready", func(t *testing.T) {
+		res := toolErrorResult(provider.ErrUsersNotReady)
+		assert.True(t, res.IsError)
+		assert.Equal(t, unavailableError{Status: "not_ready", Error: provider.ErrUsersNotReady.Error(), Retryable: true}, res.StructuredContent)
+	})
+
+	t.Run("degraded", func(t *testing.T) {
+		err := fmt.Errorf("%w: timeout", provider.ErrSlackUnavailable)
+		res := toolErrorResult(err)
+		assert.True(t, res.IsError)
+		require.IsType(t, unavailableError{}, res.StructuredContent)
+		assert.Equal(t, "degraded", res.StructuredContent.(unavailableError).Status)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		res := toolErrorResult(fmt.Errorf("channel_not_found"))
+		assert.True(t, res.IsError)
+		assert.Nil(t, res.StructuredContent)
+	})
+}
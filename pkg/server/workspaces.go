@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/handler"
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/server/auth"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// workspaceHandlers holds a handler per workspace and dispatches tool calls to the one
+// selected by their optional "workspace" argument, see provider.ApiProvider.Workspace.
+// With token pass-through calls are dispatched to a handler of the caller's own provider.
+type workspaceHandlers[H any] struct {
+	provider *provider.ApiProvider
+	handlers map[*provider.ApiProvider]H
+	callers  *callerHandlers[H]
+}
+
+// callerHandlers holds a handler per caller of the token pool, dropped with its provider.
+type callerHandlers[H any] struct {
+	pool       *provider.Pool
+	newHandler func(*provider.ApiProvider, *zap.Logger) H
+	logger     *zap.Logger
+
+	mu       sync.Mutex
+	handlers map[*provider.ApiProvider]H
+}
+
+// newWorkspaceHandlers creates a handler for each workspace that supports the tools it
+// serves, or for every workspace if supported is nil. With a token pool, tool calls are
+// served by handlers created on first use for the caller's provider instead.
+func newWorkspaceHandlers[H any](
+	p *provider.ApiProvider,
+	pool *provider.Pool,
+	logger *zap.Logger,
+	newHandler func(*provider.ApiProvider, *zap.Logger) H,
+	supported func(*provider.ApiProvider) bool,
+) *workspaceHandlers[H] {
+	w := &workspaceHandlers[H]{
+		provider: p,
+		handlers: make(map[*provider.ApiProvider]H),
+	}
+	if pool != nil {
+		callers := &callerHandlers[H]{
+			pool:       pool,
+			newHandler: newHandler,
+			logger:     logger,
+			handlers:   make(map[*provider.ApiProvider]H),
+		}
+		pool.OnEvict(func(ap *provider.ApiProvider) {
+			callers.mu.Lock()
+			defer callers.mu.Unlock()
+			delete(callers.handlers, ap)
+		})
+		w.callers = callers
+	}
+	for _, ws := range p.Workspaces() {
+		if supported != nil && !supported(ws) {
+			continue
+		}
+		wsLogger := logger
+		if ws.Name() != "" {
+			wsLogger = logger.With(zap.String("workspace", ws.Name()))
+		}
+		w.handlers[ws] = newHandler(ws, wsLogger)
+	}
+	return w
+}
+
+// only returns the handlers of the workspaces supported accepts, shared with w.
+func (w *workspaceHandlers[H]) only(supported func(*provider.ApiProvider) bool) *workspaceHandlers[H] {
+	subset := &workspaceHandlers[H]{
+		provider: w.provider,
+		handlers: make(map[*provider.ApiProvider]H),
+		callers:  w.callers,
+	}
+	for ws, h := range w.handlers {
+		if supported(ws) {
+			subset.handlers[ws] = h
+		}
+	}
+	return subset
+}
+
+// retain drops the handlers keep rejects.
+func (w *workspaceHandlers[H]) retain(keep func(H) bool) {
+	for ws, h := range w.handlers {
+		if !keep(h) {
+			delete(w.handlers, ws)
+		}
+	}
+}
+
+func (w *workspaceHandlers[H]) empty() bool {
+	return len(w.handlers) == 0
+}
+
+// get returns the handler of ws, if ws supports it.
+func (w *workspaceHandlers[H]) get(ws *provider.ApiProvider) (H, bool) {
+	h, ok := w.handlers[ws]
+	return h, ok
+}
+
+// forRequest returns the handler of the workspace named by the request's workspace argument,
+// or with token pass-through the handler of the caller.
+func (w *workspaceHandlers[H]) forRequest(ctx context.Context, request mcp.CallToolRequest) (H, error) {
+	var zero H
+	if w.callers != nil {
+		return w.callers.get(ctx)
+	}
+	name := request.GetString("workspace", "")
+	ws, err := w.provider.Workspace(name)
+	if err != nil {
+		return zero, err
+	}
+	h, ok := w.handlers[ws]
+	if !ok {
+		if name == "" {
+			name = ws.Name()
+		}
+		return zero, fmt.Errorf("%s is not available in workspace %q", request.Params.Name, name)
+	}
+	return h, nil
+}
+
+func (w *workspaceHandlers[H]) tool(fn func(H, context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		h, err := w.forRequest(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		return fn(h, ctx, request)
+	}
+}
+
+func (w *workspaceHandlers[H]) preview(fn func(H, context.Context, mcp.CallToolRequest) (string, error)) confirmationPreviewFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+		h, err := w.forRequest(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		return fn(h, ctx, request)
+	}
+}
+
+// forContext returns the handler of ws, or with token pass-through the handler of the
+// caller. Resources and prompts have no middleware, so the caller is authenticated here.
+func (w *workspaceHandlers[H]) forContext(ctx context.Context, ws *provider.ApiProvider) (H, context.Context, error) {
+	if w.callers == nil {
+		h, _ := w.get(ws)
+		return h, ctx, nil
+	}
+	var zero H
+	ctx, err := auth.Authenticate(ctx, ws.ServerTransport(), w.callers.logger)
+	if err != nil {
+		return zero, ctx, err
+	}
+	h, err := w.callers.get(ctx)
+	return h, ctx, err
+}
+
+func (w *workspaceHandlers[H]) resource(ws *provider.ApiProvider, fn func(H, context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		h, ctx, err := w.forContext(ctx, ws)
+		if err != nil {
+			return nil, err
+		}
+		return fn(h, ctx, request)
+	}
+}
+
+func (w *workspaceHandlers[H]) prompt(fn func(H, context.Context, mcp.GetPromptRequest) (*mcp.GetPromptResult, error)) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		h, ctx, err := w.forContext(ctx, w.provider)
+		if err != nil {
+			return nil, err
+		}
+		return fn(h, ctx, request)
+	}
+}
+
+// get returns the handler of the caller of ctx, creating it on first use.
+func (c *callerHandlers[H]) get(ctx context.Context) (H, error) {
+	var zero H
+	creds, err := callerCredentials(ctx)
+	if err != nil {
+		return zero, err
+	}
+	ap, err := c.pool.Get(ctx, creds)
+	if err != nil {
+		return zero, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.handlers[ap]
+	if !ok {
+		h = c.newHandler(ap, c.logger)
+		c.handlers[ap] = h
+	}
+	return h, nil
+}
+
+// callerCredentials returns the Slack token of the caller: the one of its API key, see
+// SLACK_MCP_API_KEYS_FILE, or the one sent in the X-Slack-Token and X-Slack-Cookie headers.
+func callerCredentials(ctx context.Context) (provider.Credentials, error) {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.SlackToken != "" {
+		return provider.Credentials{Token: principal.SlackToken, Cookie: principal.SlackCookie}, nil
+	}
+	if creds, ok := provider.CredentialsFromContext(ctx); ok {
+		return creds, nil
+	}
+	return provider.Credentials{}, provider.ErrCredentialsRequired
+}
+
+// workspaceArgument adds the optional workspace argument to tools when more than one
+// workspace is served.
+func workspaceArgument(p *provider.ApiProvider) mcp.ToolOption {
+	workspaces := p.Workspaces()
+	if len(workspaces) < 2 {
+		return func(*mcp.Tool) {}
+	}
+	names := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		names = append(names, ws.Name())
+	}
+	return mcp.WithString("workspace",
+		mcp.Description(fmt.Sprintf("Workspace to use: one of %s, or a team ID. Default is %s.", strings.Join(names, ", "), names[0])),
+	)
+}
+
+func searchSupported(ws *provider.ApiProvider) bool {
+	// Bot tokens cannot use the search.messages API, offline sources are searched locally
+	return !ws.IsBotToken() && !ws.IsOffline()
+}
+
+func archiveEnabled(ws *provider.ApiProvider) bool {
+	return ws.Archive() != nil
+}
+
+// addWorkspaceResources adds the channels and users directories of ws under
+// slack://<workspace>/. A single workspace is named after its Slack subdomain, which is
+// only known once Slack has been reached, in this or an earlier run.
+func addWorkspaceResources(s *server.MCPServer, ws *provider.ApiProvider, channels *workspaceHandlers[*handler.ChannelsHandler], conversations *workspaceHandlers[*handler.ConversationsHandler], logger *zap.Logger) error {
+	name := ws.Name()
+	if name == "" {
+		logger.Info("Authenticating with Slack API...",
+			zap.String("context", "console"),
+		)
+		ar, err := ws.Slack().AuthTest()
+		if err != nil {
+			return err
+		}
+
+		logger.Info("Successfully authenticated with Slack",
+			zap.String("context", "console"),
+			zap.String("team", ar.Team),
+			zap.String("user", ar.User),
+			zap.String("enterprise", ar.EnterpriseID),
+			zap.String("url", ar.URL),
+		)
+
+		name, err = text.Workspace(ar.URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse workspace from URL %q: %w", ar.URL, err)
+		}
+	}
+
+	s.AddResource(mcp.NewResource(
+		"slack://"+name+"/channels",
+		"Directory of Slack channels",
+		mcp.WithResourceDescription("This resource provides a directory of Slack channels."),
+		mcp.WithMIMEType("text/csv"),
+	), channels.resource(ws, (*handler.ChannelsHandler).ChannelsResource))
+
+	s.AddResource(mcp.NewResource(
+		"slack://"+name+"/users",
+		"Directory of Slack users",
+		mcp.WithResourceDescription("This resource provides a directory of Slack users."),
+		mcp.WithMIMEType("text/csv"),
+	), conversations.resource(ws, (*handler.ConversationsHandler).UsersResource))
+	return nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUnitWorkspaceHandlers(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("SLACK_MCP_XOXP_TOKEN", "demo")
+	t.Setenv("SLACK_MCP_WORKSPACES", "acme,beta")
+	t.Setenv("SLACK_MCP_ACME_XOXP_TOKEN", "xoxp-acme")
+	t.Setenv("SLACK_MCP_BETA_XOXP_TOKEN", "xoxp-beta")
+	p := provider.New("stdio", zap.NewNop())
+
+	names := newWorkspaceHandlers(p, nil, zap.NewNop(), func(ws *provider.ApiProvider, _ *zap.Logger) string {
+		return ws.Name()
+	}, nil)
+	call := func(h *workspaceHandlers[string], workspace string) (string, error) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "whoami"
+		if workspace != "" {
+			req.Params.Arguments = map[string]any{"workspace": workspace}
+		}
+		res, err := h.tool(func(name string, _ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(name), nil
+		})(context.Background(), req)
+		if err != nil {
+			return "", err
+		}
+		return res.Content[0].(mcp.TextContent).Text, nil
+	}
+
+	got, err := call(names, "")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got, "calls without a workspace go to the default one")
+
+	got, err = call(names, "beta")
+	require.NoError(t, err)
+	assert.Equal(t, "beta", got)
+
+	_, err = call(names, "gamma")
+	assert.ErrorIs(t, err, provider.ErrUnknownWorkspace)
+
+	acmeOnly := names.only(func(ws *provider.ApiProvider) bool { return ws.Name() == "acme" })
+	_, err = call(acmeOnly, "beta")
+	assert.ErrorContains(t, err, `whoami is not available in workspace "beta"`)
+	assert.False(t, acmeOnly.empty())
+
+	names.retain(func(string) bool { return false })
+	assert.True(t, names.empty())
+
+	tool := mcp.NewTool("whoami", workspaceArgument(p))
+	require.Contains(t, tool.InputSchema.Properties, "workspace")
+	assert.NotContains(t, tool.InputSchema.Required, "workspace")
+}
@@ -0,0 +1,384 @@
+package text
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RedactAction is what a redaction rule does with the text it matches.
+type RedactAction string
+
+const (
+	// RedactMask replaces the match with asterisks, keeping what identifies it without
+	// revealing it, e.g. the domain of an email or the last digits of a card number
+	RedactMask RedactAction = "mask"
+	// RedactHash replaces the match with a keyed hash, equal matches get equal hashes
+	RedactHash RedactAction = "hash"
+	// RedactDrop removes the match
+	RedactDrop RedactAction = "drop"
+)
+
+// redactRule is a kind of sensitive text and what to do with it.
+type redactRule struct {
+	name    string
+	pattern *regexp.Regexp
+	// valid filters matches of pattern, all matches count if nil
+	valid func(string) bool
+	// mask returns the masked match
+	mask   func(string) string
+	action RedactAction
+}
+
+// builtinRules are the rules SLACK_MCP_REDACT can enable, in the order they are applied:
+// secrets first, so their parts are not taken for other kinds of text.
+var builtinRules = []redactRule{
+	{
+		name:    "private_key",
+		pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		mask:    func(string) string { return "-----PRIVATE KEY********-----" },
+	},
+	{
+		name:    "slack_token",
+		pattern: regexp.MustCompile(`\bxox[abcdeoprs]-[0-9A-Za-z-]{10,}|\bxapp-\d-[0-9A-Za-z-]{10,}|https://hooks\.slack\.com/services/[0-9A-Za-z/]+`),
+		mask:    maskSecret,
+	},
+	{
+		name:    "github_token",
+		pattern: regexp.MustCompile(`\b(?:gh[pousr]_[0-9A-Za-z]{36,}|github_pat_[0-9A-Za-z_]{22,})\b`),
+		mask:    maskSecret,
+	},
+	{
+		name:    "aws_key",
+		pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b|(?i:aws_?secret_?access_?key["']?\s*[:=]\s*["']?)[0-9A-Za-z/+=]{40}`),
+		mask:    maskSecret,
+	},
+	{
+		name:    "gcp_key",
+		pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b|\bya29\.[0-9A-Za-z_-]{20,}`),
+		mask:    maskSecret,
+	},
+	{
+		name:    "credit_card",
+		pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid:   luhn,
+		mask:    maskDigits(4),
+	},
+	{
+		name:    "phone",
+		pattern: regexp.MustCompile(`\+\d[\d ().-]{6,18}\d\b|\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`),
+		valid: func(s string) bool {
+			n := countDigits(s)
+			return n >= 8 && n <= 15
+		},
+		mask: maskDigits(2),
+	},
+	{
+		name:    "email",
+		pattern: regexp.MustCompile(`[0-9A-Za-z._%+-]+@[0-9A-Za-z.-]+\.[A-Za-z]{2,}`),
+		mask: func(s string) string {
+			return "***" + s[strings.LastIndex(s, "@"):]
+		},
+	},
+}
+
+// secretRules are enabled together by "secrets".
+var secretRules = []string{"private_key", "slack_token", "github_token", "aws_key", "gcp_key"}
+
+// FindSecrets returns the secrets the "secrets" rules find in s, masked and named by their
+// rule, e.g. "slack_token xoxb********".
+func FindSecrets(s string) []string {
+	var found []string
+	for _, rule := range builtinRules {
+		if !slices.Contains(secretRules, rule.name) {
+			continue
+		}
+		for _, match := range rule.pattern.FindAllString(s, -1) {
+			if rule.valid == nil || rule.valid(match) {
+				found = append(found, rule.name+" "+rule.mask(match))
+			}
+		}
+	}
+	return found
+}
+
+// Redactor removes sensitive text from output before it reaches the LLM, see
+// RedactorFromEnv.
+type Redactor struct {
+	rules   []redactRule
+	hashKey []byte
+}
+
+// customRule is an entry of SLACK_MCP_REDACT_PATTERNS.
+type customRule struct {
+	Name    string       `json:"name"`
+	Pattern string       `json:"pattern"`
+	Action  RedactAction `json:"action"`
+}
+
+// RedactorFromEnv returns the redactor of the environment, nil if it redacts nothing:
+//   - SLACK_MCP_REDACT enables built-in rules, comma separated with an optional action,
+//     e.g. "email:hash,phone,secrets:drop". Rules are email, phone, credit_card,
+//     private_key, slack_token, github_token, aws_key, gcp_key, "secrets" for the tokens
+//     and keys, or "all". The action is mask, hash or drop, mask by default.
+//   - SLACK_MCP_REDACT_PATTERNS adds rules as a JSON list of objects with a name, a
+//     regular expression pattern and an action.
+//   - SLACK_MCP_REDACT_HASH_KEY keys the hashes, so they stay the same across restarts.
+//     Without it a random key is used.
+func RedactorFromEnv() (*Redactor, error) {
+	var rules []redactRule
+
+	actions := map[string]RedactAction{}
+	for _, item := range strings.Split(os.Getenv("SLACK_MCP_REDACT"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, action, ok := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		a := RedactMask
+		if ok {
+			a = RedactAction(strings.TrimSpace(action))
+			if err := a.validate(); err != nil {
+				return nil, fmt.Errorf("invalid SLACK_MCP_REDACT entry %q: %w", item, err)
+			}
+		}
+		var names []string
+		switch name {
+		case "all":
+			for _, r := range builtinRules {
+				names = append(names, r.name)
+			}
+		case "secrets":
+			names = secretRules
+		default:
+			if !isBuiltinRule(name) {
+				return nil, fmt.Errorf("invalid SLACK_MCP_REDACT entry %q: unknown rule %q", item, name)
+			}
+			names = []string{name}
+		}
+		for _, n := range names {
+			actions[n] = a
+		}
+	}
+	for _, r := range builtinRules {
+		if a, ok := actions[r.name]; ok {
+			r.action = a
+			rules = append(rules, r)
+		}
+	}
+
+	if patterns := os.Getenv("SLACK_MCP_REDACT_PATTERNS"); patterns != "" {
+		var custom []customRule
+		if err := json.Unmarshal([]byte(patterns), &custom); err != nil {
+			return nil, fmt.Errorf("invalid SLACK_MCP_REDACT_PATTERNS: %w", err)
+		}
+		for _, c := range custom {
+			r, err := c.parse()
+			if err != nil {
+				return nil, fmt.Errorf("invalid SLACK_MCP_REDACT_PATTERNS: %w", err)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	key := []byte(os.Getenv("SLACK_MCP_REDACT_HASH_KEY"))
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Redactor{rules: rules, hashKey: key}, nil
+}
+
+func (c customRule) parse() (redactRule, error) {
+	if c.Name == "" || c.Pattern == "" {
+		return redactRule{}, fmt.Errorf("every pattern needs a name and a pattern")
+	}
+	if isBuiltinRule(c.Name) {
+		return redactRule{}, fmt.Errorf("pattern %q has the name of a built-in rule", c.Name)
+	}
+	pattern, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return redactRule{}, fmt.Errorf("pattern %q: %w", c.Name, err)
+	}
+	if c.Action == "" {
+		c.Action = RedactMask
+	}
+	if err := c.Action.validate(); err != nil {
+		return redactRule{}, fmt.Errorf("pattern %q: %w", c.Name, err)
+	}
+	return redactRule{
+		name:    c.Name,
+		pattern: pattern,
+		mask:    func(string) string { return "********" },
+		action:  c.Action,
+	}, nil
+}
+
+func (a RedactAction) validate() error {
+	switch a {
+	case RedactMask, RedactHash, RedactDrop:
+		return nil
+	}
+	return fmt.Errorf("unknown action %q, expected mask, hash or drop", a)
+}
+
+func isBuiltinRule(name string) bool {
+	for _, r := range builtinRules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactCounts counts redacted items by rule name.
+type RedactCounts map[string]int
+
+// Total returns the number of redacted items.
+func (c RedactCounts) Total() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// Add adds the counts of other to c.
+func (c RedactCounts) Add(other RedactCounts) {
+	for k, v := range other {
+		c[k] += v
+	}
+}
+
+// String lists the counts like "email=2, slack_token=1".
+func (c RedactCounts) String() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, c[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Redact applies the rules to s and counts what they redacted.
+func (r *Redactor) Redact(s string) (string, RedactCounts) {
+	counts := RedactCounts{}
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			counts[rule.name]++
+			switch rule.action {
+			case RedactHash:
+				return r.hash(rule.name, match)
+			case RedactDrop:
+				return ""
+			default:
+				return rule.mask(match)
+			}
+		})
+	}
+	return s, counts
+}
+
+// RedactValue applies the rules to the strings of a decoded JSON value, in place where
+// possible, and returns the value.
+func (r *Redactor) RedactValue(v any, counts RedactCounts) any {
+	switch v := v.(type) {
+	case string:
+		redacted, c := r.Redact(v)
+		counts.Add(c)
+		return redacted
+	case []any:
+		for i := range v {
+			v[i] = r.RedactValue(v[i], counts)
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = r.RedactValue(v[k], counts)
+		}
+	}
+	return v
+}
+
+func (r *Redactor) hash(rule, match string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(match))
+	return "[" + rule + ":" + hex.EncodeToString(mac.Sum(nil))[:12] + "]"
+}
+
+// maskSecret keeps the prefix that tells which kind of secret it was.
+func maskSecret(s string) string {
+	if len(s) > 8 {
+		return s[:4] + "********"
+	}
+	return "********"
+}
+
+// maskDigits masks all digits but the last keep.
+func maskDigits(keep int) func(string) string {
+	return func(s string) string {
+		left := countDigits(s) - keep
+		b := []byte(s)
+		for i, c := range b {
+			if left <= 0 {
+				break
+			}
+			if c >= '0' && c <= '9' {
+				b[i] = '*'
+				left--
+			}
+		}
+		return string(b)
+	}
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// luhn reports whether the digits of s pass the Luhn check of card numbers.
+func luhn(s string) bool {
+	sum, double, n := 0, false, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
@@ -90,18 +90,7 @@ func IsUnfurlingEnabled(text string, opt string, logger *zap.Logger) bool {
 		allowed[d] = struct{}{}
 	}
 
-	urlRe := regexp.MustCompile(`https?://[^\s]+`)
-	urls := urlRe.FindAllString(text, -1)
-	for _, rawURL := range urls {
-		u, err := url.Parse(rawURL)
-		if err != nil || u.Host == "" {
-			continue
-		}
-		host := strings.ToLower(u.Host)
-		if idx := strings.Index(host, ":"); idx != -1 {
-			host = host[:idx]
-		}
-		host = strings.TrimPrefix(host, "www.")
+	for _, host := range LinkDomains(text) {
 		if _, ok := allowed[host]; !ok {
 			if logger != nil {
 				logger.Warn("Security: attempt to unfurl non-whitelisted host",
@@ -113,30 +102,52 @@ func IsUnfurlingEnabled(text string, opt string, logger *zap.Logger) bool {
 		}
 	}
 
-	txtNoURLs := urlRe.ReplaceAllString(text, " ")
+	return true
+}
 
-	domRe := regexp.MustCompile(`\b(?:[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}\b`)
-	doms := domRe.FindAllString(txtNoURLs, -1)
+var (
+	linkURLRe    = regexp.MustCompile(`(?i)\bhttps?://[^\s<>|]+`)
+	linkHostRe   = regexp.MustCompile(`(?i)^https?://([^/?#()\[\]"'*]+)`)
+	linkDomainRe = regexp.MustCompile(`\b(?:[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}\b`)
+)
 
-	for _, d := range doms {
-		d = strings.ToLower(d)
+// LinkDomains returns the lowercase domains text links to, once each: the hosts of its
+// URLs without "www.", and the bare domains Slack would turn into links.
+func LinkDomains(text string) []string {
+	var domains []string
+	seen := map[string]bool{}
+	add := func(d string) {
+		if d != "" && !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
 
-		if _, icann := publicsuffix.PublicSuffix(d); !icann {
+	for _, rawURL := range linkURLRe.FindAllString(text, -1) {
+		m := linkHostRe.FindStringSubmatch(rawURL)
+		if m == nil {
 			continue
 		}
+		host := m[1]
+		if idx := strings.LastIndex(host, "@"); idx != -1 {
+			host = host[idx+1:]
+		}
+		if idx := strings.Index(host, ":"); idx != -1 {
+			host = host[:idx]
+		}
+		add(strings.TrimPrefix(strings.ToLower(host), "www."))
+	}
 
-		if _, ok := allowed[d]; !ok {
-			if logger != nil {
-				logger.Warn("Security: attempt to unfurl non-whitelisted host",
-					zap.String("host", d),
-					zap.String("allowed", opt),
-				)
-			}
-			return false
+	txtNoURLs := linkURLRe.ReplaceAllString(text, " ")
+	for _, d := range linkDomainRe.FindAllString(txtNoURLs, -1) {
+		d = strings.ToLower(d)
+		if _, icann := publicsuffix.PublicSuffix(d); !icann {
+			continue
 		}
+		add(d)
 	}
 
-	return true
+	return domains
 }
 
 func Workspace(rawURL string) (string, error) {
@@ -1,6 +1,7 @@
 package text
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -160,3 +161,21 @@ func TestFilterSpecialCharsWithCommas(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitLinkDomains(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no links here", nil},
+		{"see https://www.Example.com/a/b.io?q=1 and example.com", []string{"example.com"}},
+		{"<https://evil.com|github.com> and [docs](https://docs.io)", []string{"evil.com", "docs.io", "github.com"}},
+		{"http://user:pw@host.org:8080/x", []string{"host.org"}},
+		{"file.invalidtld", nil},
+	}
+	for _, tt := range tests {
+		if got := LinkDomains(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LinkDomains(%q) = %v; want %v", tt.text, got, tt.want)
+		}
+	}
+}